@@ -0,0 +1,43 @@
+package api
+
+// Brifle error codes reported in ResponseStatus.ErrorCode on non-2xx
+// responses.
+const (
+	ErrorCodeBadRequest              = 40000
+	ErrorCodeCsrInvalid              = 40001
+	ErrorCodeInvalidDocumentState    = 40002
+	ErrorCodeAlreadyLinkedToAccount  = 40003
+	ErrorCodeOwnerCanNotBeRemoved    = 40004
+	ErrorCodeTeamMemberAlreadyExists = 40005
+	ErrorCodeInvalidLoginParameters  = 40006
+	ErrorCodeInvalidCode             = 40007
+	ErrorCodeUnauthorized            = 40100
+	ErrorCodeLoginFailed             = 40101
+	ErrorCodeNoAccessToTenant        = 40102
+	ErrorCodeAccessNotGranted        = 40103
+	ErrorCodeCsrRejected             = 40104
+	ErrorCodeNoAccessToAccount       = 40105
+	ErrorCodeChallengeStarted        = 40106
+	ErrorCodeForbidden               = 40300
+	ErrorCodeSignatureInvalid        = 40301
+	ErrorCodeAlreadySigned           = 40302
+	ErrorCodeAlreadyRejected         = 40303
+	ErrorCodeAccountCreationDenied   = 40304
+	ErrorCodeInvalidScope            = 40305
+	ErrorCodeNotFound                = 40400
+	ErrorCodeReceiverNotFound        = 40401
+	ErrorCodeTenantNotFound          = 40402
+	ErrorCodeAccountNotFound         = 40403
+	ErrorCodeCertificateNotFound     = 40404
+	ErrorCodeDocumentNotFound        = 40405
+	ErrorCodeRequestNotFound         = 40406
+	ErrorCodeCostCenterNotFound      = 40407
+	ErrorCodePostalAddressNotFound   = 40408
+	ErrorCodeFeatureNotEnabled       = 40499
+	ErrorCodeEmailAlreadyInUse       = 40901
+	ErrorCodeUnprocessableEntity     = 42200
+	ErrorCodeContentTypeNotSupported = 42201
+	ErrorCodeWrongEncoding           = 42202
+	ErrorCodeInvalidIban             = 42203
+	ErrorCodeInternalError           = 50000
+)
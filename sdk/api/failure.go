@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// IsTransientFailure reports whether a failed request may succeed when it is
+// made again later: transport errors, timeouts, 408, rate limiting (429) and
+// server errors (5xx). A canceled context is not transient.
+//
+// A transient failure does not mean the request had no effect: a timeout or
+// a 5xx response may follow a request the server processed. Repeat only
+// idempotent requests on transient failures, see IsUnsentFailure.
+func IsTransientFailure(status *ResponseStatus, err error) bool {
+	if err != nil {
+		var urlErr *url.Error
+		var netErr net.Error
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+	}
+	if status == nil {
+		return false
+	}
+	return status.HttpStatus == http.StatusTooManyRequests ||
+		status.HttpStatus == http.StatusRequestTimeout ||
+		status.HttpStatus >= 500
+}
+
+// IsUnsentFailure reports whether a failed request provably did not reach
+// the server, so that making it again cannot process it twice: the
+// connection could not be established, or the request was rejected by rate
+// limiting (429).
+func IsUnsentFailure(status *ResponseStatus, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		var dnsErr *net.DNSError
+		return errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	return status != nil && status.HttpStatus == http.StatusTooManyRequests
+}
@@ -36,6 +36,7 @@ func ParseResponseAsString(response *http.Response) (*ResponseStatus, string, er
 			return &ResponseStatus{
 				ErrorCode:  errResponse.Code,
 				HttpStatus: response.StatusCode,
+				Body:       &errResponse,
 			}, "", nil
 		}
 
@@ -76,6 +77,7 @@ func ParseResponseAsBytes(response *http.Response) (*ResponseStatus, []byte, err
 			return &ResponseStatus{
 				ErrorCode:  errResponse.Code,
 				HttpStatus: response.StatusCode,
+				Body:       &errResponse,
 			}, nil, nil
 		}
 
@@ -112,6 +114,7 @@ func parseResponse(response *http.Response, responseType any) (*ResponseStatus,
 			return &ResponseStatus{
 				ErrorCode:  errResponse.Code,
 				HttpStatus: response.StatusCode,
+				Body:       &errResponse,
 			}, nil
 		}
 
@@ -1,8 +1,30 @@
 package api
 
+import "fmt"
+
 type ResponseStatus struct {
 	ErrorCode  int
 	HttpStatus int
+	// Body is the error returned with non-2xx responses, if any.
+	Body *ResponseError
+}
+
+// Format prints the status as its ErrorCode and HttpStatus, e.g. "{40400 404}"
+// with %v or %d. The body is only printed with %+v.
+func (s ResponseStatus) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		fmt.Fprintf(f, "{ErrorCode:%d HttpStatus:%d Body:%v}", s.ErrorCode, s.HttpStatus, s.Body)
+		return
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), struct{ ErrorCode, HttpStatus int }{s.ErrorCode, s.HttpStatus})
+}
+
+// Message returns the error message of a non-2xx response, if any.
+func (s *ResponseStatus) Message() string {
+	if s == nil || s.Body == nil {
+		return ""
+	}
+	return s.Body.Message
 }
 
 type ResponseError struct {
@@ -10,3 +32,7 @@ type ResponseError struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
 }
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("brifle error %d: %s", e.Code, e.Message)
+}
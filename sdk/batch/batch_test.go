@@ -0,0 +1,149 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/batch"
+	"github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+var mapping = &batch.Mapping{
+	Columns: map[batch.Field]string{
+		batch.FieldEmail:        "E-Mail",
+		batch.FieldSubject:      "Betreff",
+		batch.FieldDocumentPath: "Datei",
+		batch.FieldKey:          "Nummer",
+	},
+	Defaults: map[batch.Field]string{batch.FieldType: content.Letter},
+	BaseDir:  "test",
+}
+
+const letters = "Nummer;E-Mail;Betreff;Datei;type\n" +
+	"A-1;max@example.com;Hallo;doc.pdf;\n" +
+	"A-2;unknown@example.com;Hallo;doc.pdf;\n" +
+	"A-3;kein-email;;missing.pdf;memo\n"
+
+func TestReadCSV(t *testing.T) {
+	rows, err := batch.ReadCSV(strings.NewReader(letters), mapping)
+	if err != nil {
+		t.Errorf("ReadCSV failed: %v", err)
+		return
+	}
+	if len(rows) != 3 {
+		t.Errorf("Expected 3 rows, got %d", len(rows))
+		return
+	}
+	if !rows[0].Valid() || *rows[0].Request.Type != content.Letter || *rows[0].Request.To.Email.Email != "max@example.com" {
+		t.Errorf("Expected row 1 to be mapped, got %+v", rows[0])
+	}
+	// invalid email, missing subject, unknown type and missing file
+	if n := len(rows[2].Errors); n != 4 {
+		t.Errorf("Expected 4 errors in row 3, got %v", rows[2].Errors)
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"key": "I-1", "first_name": "Max", "last_name": "Mustermann", "place_of_birth": "Berlin", "date_of_birth": "1999-12-12", "subject": "Rechnung", "type": "invoice", "document_path": "doc.pdf", "amount": 12.50, "currency": "eur", "iban": "DE89 3704 0044 0532 0130 00", "reference": "R-1", "due_date": "2025-03-31"}
+{"key": "I-1", "email": "max@example.com", "subject": "Brief", "type": "letter", "document_path": "doc.pdf", "amount": 5}`
+	rows, err := batch.ReadJSONL(strings.NewReader(input), &batch.Mapping{BaseDir: "test"})
+	if err != nil {
+		t.Errorf("ReadJSONL failed: %v", err)
+		return
+	}
+	if !rows[0].Valid() {
+		t.Errorf("Expected row 1 to be valid, got %v", rows[0].Errors)
+		return
+	}
+	details := rows[0].Request.PaymentInfo.Details
+	if details.Amount == nil || *details.Amount != payments.Cents(1250) || *details.Iban != "DE89370400440532013000" {
+		t.Errorf("Unexpected payment details %+v", details)
+	}
+	// payment on a letter and a duplicate key
+	if len(rows[1].Errors) < 2 {
+		t.Errorf("Expected row 2 to be invalid, got %v", rows[1].Errors)
+	}
+}
+
+func TestReadCSVPayment(t *testing.T) {
+	input := "email;subject;type;document_path;amount;currency;iban;reference;due_date\n" +
+		"max@example.com;Rechnung;invoice;doc.pdf;1.234,50;EUR;DE89370400440532013000;R-1;2025-03-31\n" +
+		"max@example.com;Rechnung;invoice;doc.pdf;1.234,505;EUR;DE89370400440532013000;R-1;2025-03-31\n" +
+		"max@example.com;Rechnung;invoice;doc.pdf;0,00;EUR;DE89370400440532013000;R-1;2025-03-31\n" +
+		"max@example.com;Rechnung;invoice;doc.pdf;12,50;EUR;DE89370400440532013001;R_1;2025-03-31\n"
+	rows, err := batch.ReadCSV(strings.NewReader(input), &batch.Mapping{BaseDir: "test"})
+	if err != nil {
+		t.Errorf("ReadCSV failed: %v", err)
+		return
+	}
+	if !rows[0].Valid() || *rows[0].Request.PaymentInfo.Details.Amount != payments.Cents(123450) {
+		t.Errorf("Expected 1.234,50 EUR, got %+v and %v", rows[0].Request.PaymentInfo.Details.Amount, rows[0].Errors)
+	}
+	// too many decimals, a zero amount and an invalid IBAN
+	for _, row := range rows[1:] {
+		if row.Valid() {
+			t.Errorf("Expected row %d to be invalid", row.Line)
+		}
+	}
+}
+
+func TestSendAndReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.ApiSendContentSendContentRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if *req.To.Email == "unknown@example.com" {
+			w.WriteHeader(404)
+			_ = json.NewEncoder(w).Encode(api.ResponseError{Code: api.ErrorCodeReceiverNotFound, Message: "receiver not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.ContentCreateResponse{Id: sdk.String("doc-1")})
+	}))
+	defer server.Close()
+	apiClient, _ := api.NewClient(server.URL)
+
+	rows, err := batch.ReadCSV(strings.NewReader(letters), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	sender := &content.BulkSender{Client: &client.BrifleClient{ApiClient: apiClient}, Tenant: "tenant"}
+	report, err := batch.Send(ctx, sender, rows)
+	if err != nil {
+		t.Errorf("Send failed: %v", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := batch.WriteReportCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"1", "A-1", content.BulkSent, "doc-1", "200", "", ""},
+		{"2", "A-2", content.BulkFailed, "", "404", "40401", "receiver not found"},
+	}
+	for i, want := range expected {
+		if strings.Join(records[i+1], "|") != strings.Join(want, "|") {
+			t.Errorf("Row %d: expected %v, got %v", i+1, want, records[i+1])
+		}
+	}
+	if records[3][2] != batch.StatusInvalid {
+		t.Errorf("Expected row 3 to be invalid, got %v", records[3])
+	}
+}
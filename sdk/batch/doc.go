@@ -0,0 +1,23 @@
+// Package batch reads mail merge runs from CSV or JSONL files, sends them
+// with a content.BulkSender and writes a result report per row.
+//
+// A [Mapping] maps the columns of the file onto the fields of a
+// content.SendContentRequest (receiver, subject, document file, type, payment
+// details and paper mail fallback). Every row is validated while reading, so
+// problems show up before anything is sent:
+//
+//	mapping, _ := batch.LoadMapping("mapping.json")
+//	file, _ := os.Open("letters.csv")
+//	rows, err := batch.ReadCSV(file, mapping)
+//	for _, row := range rows {
+//		if !row.Valid() {
+//			fmt.Println(row.Line, row.Errors)
+//		}
+//	}
+//
+//	sender := &content.BulkSender{Client: client, Tenant: tenant}
+//	report, err := batch.Send(ctx, sender, rows)
+//	batch.WriteReportCSV(os.Stdout, report)
+//
+// See docs/batch.md for the list of fields.
+package batch
@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Field is a value of a SendContentRequest that can be read from a column.
+type Field string
+
+// Fields that can be mapped to columns.
+const (
+	// FieldKey identifies the row across runs; defaults to the line number.
+	FieldKey Field = "key"
+
+	// receiver by email or phone
+	FieldEmail       Field = "email"
+	FieldPhone       Field = "phone"
+	FieldName        Field = "name"
+	FieldDateOfBirth Field = "date_of_birth"
+
+	// receiver by birth information; FieldDateOfBirth is shared
+	FieldFirstName     Field = "first_name"
+	FieldLastName      Field = "last_name"
+	FieldPlaceOfBirth  Field = "place_of_birth"
+	FieldNameAtBirth   Field = "name_at_birth"
+	FieldPostalAddress Field = "postal_address"
+
+	// document
+	FieldSubject      Field = "subject"
+	FieldType         Field = "type"
+	FieldDocumentPath Field = "document_path"
+	FieldContentType  Field = "content_type"
+
+	// payment details for invoices
+	FieldPayable            Field = "payable"
+	FieldAmount             Field = "amount"
+	FieldCurrency           Field = "currency"
+	FieldIban               Field = "iban"
+	FieldReference          Field = "reference"
+	FieldPaymentDescription Field = "payment_description"
+	FieldDueDate            Field = "due_date"
+
+	// paper mail fallback
+	FieldPhysicalDelivery Field = "physical_delivery"
+	FieldAddressLine1     Field = "address_line1"
+	FieldAddressLine2     Field = "address_line2"
+	FieldAddressLine3     Field = "address_line3"
+	FieldPostalCode       Field = "postal_code"
+	FieldCity             Field = "city"
+	FieldCountry          Field = "country"
+)
+
+// AllFields lists every field in a stable order.
+var AllFields = []Field{
+	FieldKey,
+	FieldEmail, FieldPhone, FieldName, FieldDateOfBirth,
+	FieldFirstName, FieldLastName, FieldPlaceOfBirth, FieldNameAtBirth, FieldPostalAddress,
+	FieldSubject, FieldType, FieldDocumentPath, FieldContentType,
+	FieldPayable, FieldAmount, FieldCurrency, FieldIban, FieldReference, FieldPaymentDescription, FieldDueDate,
+	FieldPhysicalDelivery, FieldAddressLine1, FieldAddressLine2, FieldAddressLine3, FieldPostalCode, FieldCity, FieldCountry,
+}
+
+// Mapping maps the columns of a CSV file (or the keys of JSONL objects) onto
+// the fields of a SendContentRequest.
+//
+//	{
+//	  "columns":  {"email": "E-Mail", "subject": "Betreff", "document_path": "Datei"},
+//	  "defaults": {"type": "letter"},
+//	  "base_dir": "/data/letters"
+//	}
+type Mapping struct {
+	// Columns maps a field to the column holding its value. Fields without a
+	// column are read from a column named like the field, if present.
+	Columns map[Field]string `json:"columns,omitempty"`
+	// Defaults are used when a row has no value for a field.
+	Defaults map[Field]string `json:"defaults,omitempty"`
+	// BaseDir resolves relative document paths. Defaults to the working
+	// directory.
+	BaseDir string `json:"base_dir,omitempty"`
+}
+
+// LoadMapping reads a JSON mapping file.
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// column returns the column name of f.
+func (m *Mapping) column(f Field) string {
+	if m != nil && m.Columns != nil {
+		if c, ok := m.Columns[f]; ok {
+			return c
+		}
+	}
+	return string(f)
+}
+
+// value returns the value of f in record, falling back to the default.
+func (m *Mapping) value(record map[string]string, f Field) string {
+	if v := record[m.column(f)]; v != "" {
+		return v
+	}
+	if m != nil && m.Defaults != nil {
+		return m.Defaults[f]
+	}
+	return ""
+}
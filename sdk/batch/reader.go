@@ -0,0 +1,342 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// Row is a single record of a batch file. Its request is complete except for
+// the document content, which is read from DocumentPath when the row is
+// sent, so that large batches do not have to be held in memory.
+type Row struct {
+	// Line is the line of the record in the file, starting at 1 for the
+	// first data row.
+	Line int
+	// Key identifies the row across runs.
+	Key string
+	// DocumentPath is the resolved path of the document file.
+	DocumentPath string
+	// ContentType of the document; non-PDF documents are converted with
+	// content.ConvertToPdf.
+	ContentType string
+	// Request is the request without body.
+	Request *content.SendContentRequest
+	// Errors lists every validation problem of the row.
+	Errors []error
+}
+
+// Valid reports whether the row passed validation.
+func (r *Row) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// LoadRequest returns the request including the document content.
+func (r *Row) LoadRequest() (*content.SendContentRequest, error) {
+	data, err := os.ReadFile(r.DocumentPath)
+	if err != nil {
+		return nil, err
+	}
+	pdfBytes, err := content.ConvertToPdf(data, r.ContentType, nil)
+	if err != nil {
+		return nil, err
+	}
+	req := *r.Request
+	req.Body = &[]content.ContentItem{content.PdfContentItem(pdfBytes)}
+	return &req, nil
+}
+
+// ReadCSV reads a CSV file with a header row. The delimiter (comma or
+// semicolon, as written by spreadsheet applications with German locale) is
+// detected from the header.
+func ReadCSV(r io.Reader, m *Mapping) ([]Row, error) {
+	br := bufio.NewReader(r)
+	// skip a UTF-8 byte order mark
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+		_, _ = br.Discard(3)
+	}
+	header, _ := br.Peek(4096)
+	firstLine, _, _ := bytes.Cut(header, []byte("\n"))
+
+	reader := csv.NewReader(br)
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	var rows []Row
+	for line := 1; ; line++ {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := make(map[string]string, len(columns))
+		for i, c := range columns {
+			if i < len(values) {
+				record[c] = strings.TrimSpace(values[i])
+			}
+		}
+		rows = append(rows, m.parse(line, record, payments.ParseMoney))
+	}
+	markDuplicateKeys(rows)
+	return rows, nil
+}
+
+// ReadJSONL reads a file with one JSON object per line. Numbers and booleans
+// are accepted as values as well as strings. An amount given as a JSON
+// number is read with payments.ParseDecimal, a string like a CSV value.
+func ReadJSONL(r io.Reader, m *Mapping) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var rows []Row
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var raw map[string]any
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		record := make(map[string]string, len(raw))
+		for k, v := range raw {
+			switch val := v.(type) {
+			case nil:
+			case string:
+				record[k] = strings.TrimSpace(val)
+			default:
+				record[k] = fmt.Sprint(val)
+			}
+		}
+		parseAmount := payments.ParseMoney
+		if _, ok := raw[m.column(FieldAmount)].(json.Number); ok {
+			parseAmount = payments.ParseDecimal
+		}
+		rows = append(rows, m.parse(line, record, parseAmount))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	markDuplicateKeys(rows)
+	return rows, nil
+}
+
+var (
+	isoDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	countryCode = regexp.MustCompile(`^[A-Za-z]{2}$`)
+)
+
+// parse maps and validates a single record. parseAmount reads the amount in
+// major units.
+func (m *Mapping) parse(line int, record map[string]string, parseAmount func(amount, currency string) (payments.Money, error)) Row {
+	v := func(f Field) string { return m.value(record, f) }
+	row := Row{Line: line, Key: v(FieldKey), ContentType: v(FieldContentType)}
+	if row.Key == "" {
+		row.Key = strconv.Itoa(line)
+	}
+	fail := func(format string, args ...any) {
+		row.Errors = append(row.Errors, fmt.Errorf(format, args...))
+	}
+	ptr := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	req := &content.SendContentRequest{
+		Subject: ptr(v(FieldSubject)),
+		Type:    ptr(strings.ToLower(v(FieldType))),
+	}
+
+	// receiver: exactly one of birth information, email or phone
+	receiver := &content.ReceiverData{}
+	kinds := 0
+	if v(FieldFirstName) != "" || v(FieldLastName) != "" || v(FieldPlaceOfBirth) != "" {
+		kinds++
+		receiver.BirthInformation = &content.BirthInformationReceiver{
+			FirstName:     ptr(v(FieldFirstName)),
+			LastName:      ptr(v(FieldLastName)),
+			PlaceOfBirth:  ptr(v(FieldPlaceOfBirth)),
+			DateOfBirth:   ptr(v(FieldDateOfBirth)),
+			NameAtBirth:   ptr(v(FieldNameAtBirth)),
+			PostalAddress: ptr(v(FieldPostalAddress)),
+		}
+		for _, f := range []Field{FieldFirstName, FieldLastName, FieldPlaceOfBirth, FieldDateOfBirth} {
+			if v(f) == "" {
+				fail("%s is required for a receiver by birth information", f)
+			}
+		}
+	}
+	if v(FieldEmail) != "" {
+		kinds++
+		receiver.Email = &content.EmailReceiver{Email: ptr(v(FieldEmail)), Name: ptr(v(FieldName)), DateOfBirth: ptr(v(FieldDateOfBirth))}
+		if !strings.Contains(v(FieldEmail), "@") {
+			fail("email %q is invalid", v(FieldEmail))
+		}
+	}
+	if v(FieldPhone) != "" {
+		kinds++
+		receiver.Phone = &content.PhoneReceiver{PhoneNumber: ptr(v(FieldPhone)), Name: ptr(v(FieldName)), DateOfBirth: ptr(v(FieldDateOfBirth))}
+	}
+	switch kinds {
+	case 0:
+		fail("no receiver given: set email, phone or birth information")
+	case 1:
+		req.To = receiver
+	default:
+		fail("more than one receiver given: set only one of email, phone or birth information")
+	}
+	if dob := v(FieldDateOfBirth); dob != "" && !validDate(dob) {
+		fail("date_of_birth %q is not a date in the format YYYY-MM-DD", dob)
+	}
+
+	// document
+	if req.Subject == nil {
+		fail("subject is required")
+	}
+	switch strVal(req.Type) {
+	case content.Letter, content.Invoice, content.Contract:
+	case "":
+		fail("type is required")
+	default:
+		fail("type %q is not one of letter, invoice or contract", strVal(req.Type))
+	}
+	if path := v(FieldDocumentPath); path == "" {
+		fail("document_path is required")
+	} else {
+		if !filepath.IsAbs(path) && m != nil && m.BaseDir != "" {
+			path = filepath.Join(m.BaseDir, path)
+		}
+		row.DocumentPath = path
+		if info, err := os.Stat(path); err != nil {
+			fail("document %q can not be read: %v", path, err)
+		} else if info.Size() == 0 {
+			fail("document %q is empty", path)
+		}
+	}
+
+	// payment
+	if v(FieldAmount) != "" || v(FieldIban) != "" || v(FieldPayable) != "" {
+		if strVal(req.Type) != content.Invoice {
+			fail("payment details are only allowed for invoices")
+		}
+		payable, err := parseBool(v(FieldPayable), true)
+		if err != nil {
+			fail("payable: %v", err)
+		}
+		req.PaymentInfo = &content.PaymentInfo{Payable: &payable}
+		if v(FieldAmount) != "" || v(FieldIban) != "" {
+			details := &content.PaymentDetails{
+				Description: ptr(v(FieldPaymentDescription)),
+				DueDate:     ptr(v(FieldDueDate)),
+				Iban:        ptr(payments.NormalizeIban(v(FieldIban))),
+				Reference:   ptr(v(FieldReference)),
+			}
+			req.PaymentInfo.Details = details
+			if currency := strings.ToUpper(v(FieldCurrency)); currency == "" {
+				fail("currency is required with payment details")
+			} else if amount, err := parseAmount(v(FieldAmount), currency); err != nil {
+				fail("amount: %v", err)
+			} else {
+				details.Amount = &amount
+			}
+			if details.DueDate == nil || !validDate(*details.DueDate) {
+				fail("due_date %q is not a date in the format YYYY-MM-DD", v(FieldDueDate))
+			}
+			if details.Reference == nil {
+				fail("reference is required with payment details")
+			}
+			// amount, IBAN, currency and reference, as checked on send
+			if details.Amount != nil {
+				if err := req.PaymentInfo.Validate(); err != nil {
+					fail("payment: %v", err)
+				}
+			}
+		}
+	}
+
+	// paper mail fallback
+	physical, err := parseBool(v(FieldPhysicalDelivery), false)
+	if err != nil {
+		fail("physical_delivery: %v", err)
+	}
+	if physical || v(FieldAddressLine1) != "" {
+		recipient := &content.Recipient{
+			AddressLine1: ptr(v(FieldAddressLine1)),
+			AddressLine2: ptr(v(FieldAddressLine2)),
+			AddressLine3: ptr(v(FieldAddressLine3)),
+			PostalCode:   ptr(v(FieldPostalCode)),
+			City:         ptr(v(FieldCity)),
+			Country:      ptr(strings.ToUpper(v(FieldCountry))),
+		}
+		for _, f := range []Field{FieldAddressLine1, FieldPostalCode, FieldCity} {
+			if v(f) == "" {
+				fail("%s is required for paper mail", f)
+			}
+		}
+		if c := v(FieldCountry); c != "" && !countryCode.MatchString(c) {
+			fail("country %q is not an ISO 3166-1 alpha-2 code", c)
+		}
+		req.Fallback = &content.Fallback{
+			EnabledPhysicalDelivery: physical,
+			PaperMail:               &content.PaperMail{Recipient: recipient},
+		}
+	}
+
+	row.Request = req
+	return row
+}
+
+func validDate(s string) bool {
+	if !isoDate.MatchString(s) {
+		return false
+	}
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func parseBool(s string, def bool) (bool, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return def, nil
+	case "1", "true", "yes", "y", "ja", "j", "x":
+		return true, nil
+	case "0", "false", "no", "n", "nein":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", s)
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,152 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+// StatusInvalid marks rows that failed validation and were not sent. The
+// other statuses are those of content.BulkResult.
+const StatusInvalid = "invalid"
+
+// ReportRow is the result of a single row.
+type ReportRow struct {
+	Line       int    `json:"line"`
+	Key        string `json:"key"`
+	Status     string `json:"status"`
+	DocumentId string `json:"document_id,omitempty"`
+	HttpStatus int    `json:"http_status,omitempty"`
+	ErrorCode  int    `json:"error_code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Send sends every valid row with sender and returns a report covering all
+// rows, including the invalid ones. Documents are read from disk right before
+// they are sent.
+//
+//	rows, err := batch.ReadCSV(file, mapping)
+//	report, err := batch.Send(ctx, &content.BulkSender{Client: client, Tenant: tenant}, rows)
+//	err = batch.WriteReportCSV(out, report)
+func Send(ctx context.Context, sender *content.BulkSender, rows []Row) ([]ReportRow, error) {
+	if sender == nil {
+		return nil, errors.New("sender is required")
+	}
+	report := make([]ReportRow, len(rows))
+	byKey := make(map[string]int, len(rows))
+	for i, row := range rows {
+		report[i] = ReportRow{Line: row.Line, Key: row.Key}
+		if !row.Valid() {
+			report[i].Status = StatusInvalid
+			report[i].Message = joinErrors(row.Errors)
+			continue
+		}
+		byKey[row.Key] = i
+	}
+
+	jobs := make(chan content.BulkJob)
+	fed := make(chan struct{})
+	feedCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		defer close(fed)
+		defer close(jobs)
+		for i, row := range rows {
+			if report[i].Status == StatusInvalid {
+				continue
+			}
+			req, err := row.LoadRequest()
+			if err != nil {
+				report[i].Status = content.BulkFailed
+				report[i].Message = err.Error()
+				continue
+			}
+			select {
+			case jobs <- content.BulkJob{Key: row.Key, Request: req}:
+			case <-feedCtx.Done():
+				return
+			}
+		}
+	}()
+
+	result, err := sender.Run(ctx, jobs)
+	stop()
+	<-fed
+	if result == nil {
+		return nil, err
+	}
+	for _, r := range result.Results {
+		i, ok := byKey[r.Key]
+		if !ok {
+			continue
+		}
+		report[i].Status = r.Status
+		report[i].DocumentId = r.DocumentId
+		report[i].HttpStatus = r.HttpStatus
+		report[i].ErrorCode = r.ErrorCode
+		report[i].Message = r.ErrorMessage
+		if report[i].Message == "" && r.Err != nil {
+			report[i].Message = r.Err.Error()
+		}
+	}
+	return report, err
+}
+
+// WriteReportCSV writes the report as CSV with a header row.
+func WriteReportCSV(w io.Writer, report []ReportRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "key", "status", "document_id", "http_status", "error_code", "message"}); err != nil {
+		return err
+	}
+	for _, r := range report {
+		record := []string{strconv.Itoa(r.Line), r.Key, r.Status, r.DocumentId, "", "", r.Message}
+		if r.HttpStatus != 0 {
+			record[4] = strconv.Itoa(r.HttpStatus)
+		}
+		if r.ErrorCode != 0 {
+			record[5] = strconv.Itoa(r.ErrorCode)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteReportJSON writes the report as an indented JSON array.
+func WriteReportJSON(w io.Writer, report []ReportRow) error {
+	if report == nil {
+		report = []ReportRow{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// markDuplicateKeys flags rows that reuse the key of an earlier row.
+func markDuplicateKeys(rows []Row) {
+	first := make(map[string]int, len(rows))
+	for i := range rows {
+		if line, ok := first[rows[i].Key]; ok {
+			rows[i].Errors = append(rows[i].Errors, fmt.Errorf("key %q is already used in line %d", rows[i].Key, line))
+			continue
+		}
+		first[rows[i].Key] = rows[i].Line
+	}
+}
@@ -0,0 +1,161 @@
+package certificates
+
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/xmlsig"
+)
+
+// TypeAes is the type of delivery certificates sealed with an advanced
+// electronic seal.
+const TypeAes = "aes"
+
+// Certificate is a parsed delivery certificate. Values read from the XML are
+// not trusted until the certificate was verified; use the SignedFields of
+// the Report for values covered by the seal.
+type Certificate struct {
+	// Id, DocumentId and Type are the metadata returned with the certificate.
+	Id         string
+	DocumentId string
+	Type       string
+	// XML is the raw certificate.
+	XML []byte
+	// Root is the name of the root element.
+	Root string
+	// Fields are all values of the certificate in document order, and
+	// Content their typed view.
+	Fields  []Field
+	Content Content
+	// Signature is the seal of the certificate. It is nil for unsigned
+	// certificates.
+	Signature *Signature
+}
+
+// Field is a single value of a certificate: the text of a leaf element or an
+// attribute.
+type Field struct {
+	// Path is the slash separated path of element names below the root;
+	// attributes are appended with "@".
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Content is the typed view of the values of a certificate. The schema of
+// the certificate XML is not published, so values are looked up by field
+// name like Certificate.Field does, accepting common spellings; values the
+// certificate does not contain are empty.
+type Content struct {
+	// DocumentId identifies the delivered document; DocumentHash is the
+	// SHA-256 hash of its content, hex encoded.
+	DocumentId   string    `json:"document_id,omitempty"`
+	DocumentHash string    `json:"document_hash,omitempty"`
+	Subject      string    `json:"subject,omitempty"`
+	Sender       string    `json:"sender,omitempty"`
+	Receiver     string    `json:"receiver,omitempty"`
+	SentAt       time.Time `json:"sent_at,omitempty"`
+	DeliveredAt  time.Time `json:"delivered_at,omitempty"`
+}
+
+func newContent(in []Field) Content {
+	date := func(names ...string) time.Time {
+		t, _ := time.Parse(time.RFC3339, lookup(in, names...))
+		return t
+	}
+	return Content{
+		DocumentId:   lookup(in, "document_id"),
+		DocumentHash: lookup(in, "document_hash", "document_sha256"),
+		Subject:      lookup(in, "subject"),
+		Sender:       lookup(in, "sender", "sender_name"),
+		Receiver:     lookup(in, "receiver", "receiver_name", "recipient"),
+		SentAt:       date("sent_at", "sent_date", "send_date"),
+		DeliveredAt:  date("delivered_at", "delivered_date", "delivery_date"),
+	}
+}
+
+// Signature describes the seal of a certificate as read from the XML.
+type Signature struct {
+	SignatureMethod string
+	DigestMethod    string
+	Value           []byte
+	// Certificates are the certificates embedded in the signature; the first
+	// one is the sealing certificate.
+	Certificates []*x509.Certificate
+	// SigningTime is the XAdES signing time, if present. It is not verified
+	// and may not be covered by the seal.
+	SigningTime time.Time
+}
+
+// Parse parses a delivery certificate returned by
+// content.GetDeliveryCertificate.
+//
+//	res, _, err := content.GetDeliveryCertificate(client, ctx, &documentId)
+//	cert, err := certificates.Parse(res)
+func Parse(cert *content.DeliveryCertificate) (*Certificate, error) {
+	if cert == nil || cert.Certificate == nil {
+		return nil, errors.New("certificate is required")
+	}
+	c, err := ParseXML([]byte(*cert.Certificate))
+	if err != nil {
+		return nil, err
+	}
+	if m := cert.Meta; m != nil {
+		c.Id = strVal(m.Id)
+		c.DocumentId = strVal(m.DocumentId)
+		c.Type = strVal(m.Type)
+	}
+	return c, nil
+}
+
+// ParseXML parses the XML of a delivery certificate, e.g. from an archive.
+// The metadata fields are left empty.
+func ParseXML(data []byte) (*Certificate, error) {
+	root, sig, err := xmlsig.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &Certificate{XML: data, Root: root.Tag, Fields: fields(xmlsig.Fields(root))}
+	c.Content = newContent(c.Fields)
+	if sig != nil {
+		c.Signature = &Signature{
+			SignatureMethod: sig.SignatureMethod,
+			DigestMethod:    sig.DigestMethod,
+			Value:           sig.Value,
+			Certificates:    sig.Certificates,
+			SigningTime:     sig.SigningTime,
+		}
+	}
+	return c, nil
+}
+
+// Field returns the value of the first field named like one of names.
+// Names are compared case insensitively, ignoring underscores and dashes.
+func (c *Certificate) Field(names ...string) string {
+	return lookup(c.Fields, names...)
+}
+
+func fields(in []xmlsig.Field) []Field {
+	out := make([]Field, len(in))
+	for i, f := range in {
+		out[i] = Field{Path: f.Path, Name: f.Name, Value: f.Value}
+	}
+	return out
+}
+
+func lookup(in []Field, names ...string) string {
+	conv := make([]xmlsig.Field, len(in))
+	for i, f := range in {
+		conv[i] = xmlsig.Field{Path: f.Path, Name: f.Name, Value: f.Value}
+	}
+	return xmlsig.Lookup(conv, names...)
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
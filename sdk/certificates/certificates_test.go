@@ -0,0 +1,169 @@
+package certificates_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/certificates"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/xmlsig/xmlsigtest"
+)
+
+var document = []byte("%PDF-1.4 delivered document")
+
+func documentHash() string {
+	sum := sha256.Sum256(document)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// certificateXml returns a certificate for document with the given sealed
+// document hash; the hash is omitted if empty.
+func certificateXml(hash string) string {
+	if hash != "" {
+		hash = `<DocumentHash>` + hash + `</DocumentHash>`
+	}
+	return `<DeliveryCertificate Id="cert-1"><Document><DocumentId>` + documentHash() + `</DocumentId>` + hash + `<Subject>Rechnung</Subject></Document><DeliveredAt>2025-03-01T10:00:00Z</DeliveredAt></DeliveryCertificate>`
+}
+
+func deliveryCertificate(xml []byte, documentId string) *content.DeliveryCertificate {
+	res := &content.DeliveryCertificate{Certificate: sdk.String(string(xml))}
+	res.Meta = &struct {
+		DocumentId *string `json:"document_id,omitempty"`
+		Id         *string `json:"id,omitempty"`
+		Type       *string `json:"type,omitempty"`
+	}{DocumentId: &documentId, Id: sdk.String("cert-1"), Type: sdk.String(certificates.TypeAes)}
+	return res
+}
+
+func checkResults(report *certificates.Report) map[string]string {
+	results := map[string]string{}
+	for _, c := range report.Checks {
+		results[c.Name] = c.Result
+	}
+	return results
+}
+
+func TestVerifyValidCertificate(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Brifle Delivery Seal")
+	cert, err := certificates.Parse(deliveryCertificate(authority.Sign(t, certificateXml(documentHash())), documentHash()))
+	if err != nil {
+		t.Errorf("Parse failed: %v", err)
+		return
+	}
+	if cert.Root != "DeliveryCertificate" || cert.Field("delivered_at") != "2025-03-01T10:00:00Z" || cert.Type != certificates.TypeAes {
+		t.Errorf("Unexpected certificate %+v", cert)
+	}
+	want := certificates.Content{
+		DocumentId:   documentHash(),
+		DocumentHash: documentHash(),
+		Subject:      "Rechnung",
+		DeliveredAt:  time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if cert.Content != want {
+		t.Errorf("Expected content %+v, got %+v", want, cert.Content)
+	}
+
+	report, err := (&certificates.Verifier{Roots: authority.Roots()}).Verify(cert, document)
+	if err != nil {
+		t.Errorf("Verify failed: %v", err)
+		return
+	}
+	if !report.Valid {
+		t.Errorf("Expected a valid report, got %+v", report.Checks)
+	}
+	for name, result := range checkResults(report) {
+		if result != certificates.CheckPassed {
+			t.Errorf("Expected %s to pass, got %s", name, result)
+		}
+	}
+	if report.SignedContent == nil || *report.SignedContent != want {
+		t.Errorf("Expected signed content %+v, got %+v", want, report.SignedContent)
+	}
+	if report.Signer == nil || !strings.Contains(report.Signer.Subject, "Brifle Delivery Seal") || len(report.Chain) != 3 {
+		t.Errorf("Unexpected signer %+v and chain %d", report.Signer, len(report.Chain))
+	}
+}
+
+func TestVerifyDefaults(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Brifle Delivery Seal")
+	cert, err := certificates.Parse(deliveryCertificate(authority.Sign(t, certificateXml("")), documentHash()))
+	if err != nil {
+		t.Errorf("Parse failed: %v", err)
+		return
+	}
+	// the signing time is not covered by the seal
+	cert.Signature.SigningTime = time.Now().AddDate(-20, 0, 0)
+
+	report, err := (&certificates.Verifier{Roots: authority.Roots()}).Verify(cert, document)
+	if err != nil {
+		t.Errorf("Verify failed: %v", err)
+		return
+	}
+	if !report.Valid || !report.ValidAt.Equal(report.VerifiedAt) {
+		t.Errorf("Expected the certificate to be valid now, got %s: %+v", report.ValidAt, report.Checks)
+	}
+	// the document id is not a sealed document hash
+	if c, _ := report.Check(certificates.CheckSignedDocumentHash); c.Result != certificates.CheckSkipped {
+		t.Errorf("Expected the sealed document hash check to be skipped, got %+v", c)
+	}
+}
+
+func TestVerifyRequiresDocumentBinding(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Brifle Delivery Seal")
+	// an archived certificate without metadata and without sealed hash
+	cert, err := certificates.ParseXML(authority.Sign(t, certificateXml("")))
+	if err != nil {
+		t.Fatalf("ParseXML failed: %v", err)
+	}
+	verifier := &certificates.Verifier{Roots: authority.Roots()}
+	report, err := verifier.Verify(cert, document)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.Valid || checkResults(report)[certificates.CheckDocumentBinding] != certificates.CheckFailed {
+		t.Errorf("Expected an unbound document to be invalid, got %+v", report.Checks)
+	}
+
+	// without a document only the seal is verified
+	report, err = verifier.Verify(cert, nil)
+	if err != nil || !report.Valid {
+		t.Errorf("Expected the seal to be valid, got %v %+v", err, report.Checks)
+	}
+}
+
+func TestVerifyDetectsProblems(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Brifle Delivery Seal")
+	signed := authority.Sign(t, certificateXml(documentHash()))
+
+	tests := []struct {
+		name     string
+		xml      []byte
+		roots    *xmlsigtest.Authority
+		document []byte
+		failed   string
+	}{
+		{"tampered", []byte(strings.Replace(string(signed), "Rechnung", "Mahnung", 1)), authority, document, certificates.CheckSignature},
+		{"untrusted", signed, xmlsigtest.NewAuthority(t, "Other"), document, certificates.CheckCertificateChain},
+		{"other document", signed, authority, []byte("other"), certificates.CheckDocumentId},
+		{"sealed hash", authority.Sign(t, certificateXml("00")), authority, document, certificates.CheckSignedDocumentHash},
+	}
+	for _, tt := range tests {
+		cert, err := certificates.Parse(deliveryCertificate(tt.xml, documentHash()))
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", tt.name, err)
+			continue
+		}
+		report, err := (&certificates.Verifier{Roots: tt.roots.Roots()}).Verify(cert, tt.document)
+		if err != nil {
+			t.Errorf("%s: Verify failed: %v", tt.name, err)
+			continue
+		}
+		if report.Valid || checkResults(report)[tt.failed] != certificates.CheckFailed {
+			t.Errorf("%s: expected %s to fail, got %+v", tt.name, tt.failed, report.Checks)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package certificates parses and verifies delivery certificates offline.
+//
+// content.GetDeliveryCertificate returns the certificate as XML sealed with an
+// advanced electronic seal (type "aes"). [Parse] reads it into a
+// [Certificate]; a [Verifier] then checks, without network access, that
+//
+//   - the sealing certificate chains up to one of the configured trust anchors,
+//   - the XML signature is valid,
+//   - the SHA-256 hash of the document that was sent matches the document ID
+//     of the certificate and, if present, the document hash in the sealed
+//     content.
+//
+// The result is a [Report] that can be archived as JSON next to the
+// certificate and the document:
+//
+//	res, _, err := content.GetDeliveryCertificate(client, ctx, &documentId)
+//	cert, err := certificates.Parse(res)
+//	roots, err := certificates.LoadTrustAnchorsFile("trust-anchors.pem")
+//	report, err := (&certificates.Verifier{Roots: roots}).Verify(cert, pdfBytes)
+//	if report.Valid {
+//		json.NewEncoder(archive).Encode(report)
+//	}
+//
+// See docs/certificates.md for details.
+package certificates
@@ -0,0 +1,238 @@
+package certificates
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/internal/xmlsig"
+)
+
+// Names of the checks of a Report.
+const (
+	// CheckCertificateChain verifies the chain of the sealing certificate up
+	// to one of the trust anchors.
+	CheckCertificateChain = "certificate_chain"
+	// CheckSignature verifies the XML signature with the sealing
+	// certificate.
+	CheckSignature = "xml_signature"
+	// CheckDocumentId compares the SHA-256 hash of the document with the
+	// document ID of the certificate metadata.
+	CheckDocumentId = "document_id"
+	// CheckSignedDocumentHash compares the SHA-256 hash of the document with
+	// the document hash in the sealed content of the certificate.
+	CheckSignedDocumentHash = "signed_document_hash"
+	// CheckDocumentBinding requires CheckDocumentId or
+	// CheckSignedDocumentHash to pass when a document is given, so that a
+	// valid report always binds the certificate to the document.
+	CheckDocumentBinding = "document_binding"
+)
+
+// Results of a check.
+const (
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+)
+
+// Check is the result of a single verification step.
+type Check struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CertificateInfo identifies an X.509 certificate in a report.
+type CertificateInfo struct {
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	Sha256Fingerprint string    `json:"sha256_fingerprint"`
+}
+
+// Report is the outcome of a verification. It is meant to be archived
+// together with the certificate and the document, e.g. as JSON.
+type Report struct {
+	// Valid is set when no check failed and the seal and chain were
+	// verified. With a document, the document must also match the
+	// certificate, see CheckDocumentBinding.
+	Valid         bool   `json:"valid"`
+	CertificateId string `json:"certificate_id,omitempty"`
+	DocumentId    string `json:"document_id,omitempty"`
+	// DocumentSha256 is the hex encoded hash of the document passed to
+	// Verify.
+	DocumentSha256 string `json:"document_sha256,omitempty"`
+	// VerifiedAt is the time of the verification; ValidAt is the time at
+	// which the certificates had to be valid.
+	VerifiedAt      time.Time         `json:"verified_at"`
+	ValidAt         time.Time         `json:"valid_at"`
+	SignatureMethod string            `json:"signature_method,omitempty"`
+	DigestMethod    string            `json:"digest_method,omitempty"`
+	Signer          *CertificateInfo  `json:"signer,omitempty"`
+	Chain           []CertificateInfo `json:"chain,omitempty"`
+	// SignedFields are the values covered by the seal, and SignedContent
+	// their typed view. They are empty if the signature is invalid.
+	SignedFields  []Field  `json:"signed_fields,omitempty"`
+	SignedContent *Content `json:"signed_content,omitempty"`
+	Checks       []Check `json:"checks"`
+}
+
+// Check returns the check with the given name.
+func (r *Report) Check(name string) (Check, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+// Verifier verifies delivery certificates offline against a fixed set of
+// trust anchors.
+type Verifier struct {
+	// Roots are the trust anchors. Required.
+	Roots *x509.CertPool
+	// Intermediates complete the chain if the certificate does not embed
+	// it. Optional.
+	Intermediates []*x509.Certificate
+	// Time at which the certificates must be valid. Defaults to the current
+	// time. The XAdES signing time is not used: it is read from the XML
+	// without checking that the seal covers it.
+	Time time.Time
+}
+
+// Verify checks the seal of cert and, if document is not nil, that the
+// document matches the certificate. document is the content that was sent,
+// e.g. the PDF. Failed checks are reported in the Report; an error is only
+// returned if the verification could not be run at all.
+//
+//	verifier := &certificates.Verifier{Roots: roots}
+//	report, err := verifier.Verify(cert, pdfBytes)
+//	if err == nil && report.Valid {
+//		json.NewEncoder(archive).Encode(report)
+//	}
+func (v *Verifier) Verify(cert *Certificate, document []byte) (*Report, error) {
+	if cert == nil {
+		return nil, errors.New("certificate is required")
+	}
+	if v.Roots == nil {
+		return nil, errors.New("trust anchors are required")
+	}
+
+	report := &Report{
+		CertificateId: cert.Id,
+		DocumentId:    cert.DocumentId,
+		VerifiedAt:    time.Now().UTC(),
+	}
+	check := func(name, result, format string, args ...any) {
+		report.Checks = append(report.Checks, Check{Name: name, Result: result, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	validAt := v.Time
+	if validAt.IsZero() {
+		validAt = report.VerifiedAt
+	}
+	report.ValidAt = validAt
+
+	var signed []Field
+	if cert.Signature == nil {
+		check(CheckCertificateChain, CheckFailed, "certificate is not signed")
+		check(CheckSignature, CheckFailed, "certificate is not signed")
+	} else {
+		report.SignatureMethod = cert.Signature.SignatureMethod
+		report.DigestMethod = cert.Signature.DigestMethod
+		if len(cert.Signature.Certificates) > 0 {
+			info := NewCertificateInfo(cert.Signature.Certificates[0])
+			report.Signer = &info
+		}
+		content, chain, err := xmlsig.Verify(cert.XML, &xmlsig.Signature{Certificates: cert.Signature.Certificates}, xmlsig.Options{
+			Roots:         v.Roots,
+			Intermediates: v.Intermediates,
+			Time:          validAt,
+		})
+		for _, c := range chain {
+			report.Chain = append(report.Chain, NewCertificateInfo(c))
+		}
+		switch {
+		case chain == nil:
+			check(CheckCertificateChain, CheckFailed, "%v", err)
+			check(CheckSignature, CheckSkipped, "the sealing certificate is not trusted")
+		case err != nil:
+			check(CheckCertificateChain, CheckPassed, "trusted at %s", validAt.Format(time.RFC3339))
+			check(CheckSignature, CheckFailed, "%v", err)
+		default:
+			check(CheckCertificateChain, CheckPassed, "trusted at %s", validAt.Format(time.RFC3339))
+			check(CheckSignature, CheckPassed, "sealed by %s", report.Signer.Subject)
+			signed = fields(xmlsig.Fields(content))
+			report.SignedFields = signed
+			typed := newContent(signed)
+			report.SignedContent = &typed
+		}
+	}
+
+	if document == nil {
+		check(CheckDocumentId, CheckSkipped, "no document given")
+		check(CheckSignedDocumentHash, CheckSkipped, "no document given")
+	} else {
+		sum := sha256.Sum256(document)
+		report.DocumentSha256 = hex.EncodeToString(sum[:])
+		switch {
+		case cert.DocumentId == "":
+			check(CheckDocumentId, CheckSkipped, "certificate has no document id")
+		case strings.EqualFold(cert.DocumentId, report.DocumentSha256):
+			check(CheckDocumentId, CheckPassed, "document id matches the document")
+		default:
+			check(CheckDocumentId, CheckFailed, "document id %s does not match the document hash %s", cert.DocumentId, report.DocumentSha256)
+		}
+
+		var hash string
+		if report.SignedContent != nil {
+			hash = report.SignedContent.DocumentHash
+		}
+		switch {
+		case signed == nil:
+			check(CheckSignedDocumentHash, CheckSkipped, "the seal was not verified")
+		case hash == "":
+			check(CheckSignedDocumentHash, CheckSkipped, "the sealed content has no document hash")
+		case strings.EqualFold(hash, report.DocumentSha256):
+			check(CheckSignedDocumentHash, CheckPassed, "sealed document hash matches the document")
+		default:
+			check(CheckSignedDocumentHash, CheckFailed, "sealed document hash %s does not match the document hash %s", hash, report.DocumentSha256)
+		}
+
+		idCheck, _ := report.Check(CheckDocumentId)
+		hashCheck, _ := report.Check(CheckSignedDocumentHash)
+		if idCheck.Result == CheckPassed || hashCheck.Result == CheckPassed {
+			check(CheckDocumentBinding, CheckPassed, "the certificate refers to the document")
+		} else {
+			check(CheckDocumentBinding, CheckFailed, "neither the document id nor a sealed document hash matches the document")
+		}
+	}
+
+	report.Valid = signed != nil
+	for _, c := range report.Checks {
+		if c.Result == CheckFailed {
+			report.Valid = false
+		}
+	}
+	return report, nil
+}
+
+// NewCertificateInfo describes c for a report.
+func NewCertificateInfo(c *x509.Certificate) CertificateInfo {
+	sum := sha256.Sum256(c.Raw)
+	return CertificateInfo{
+		Subject:           c.Subject.String(),
+		Issuer:            c.Issuer.String(),
+		SerialNumber:      c.SerialNumber.String(),
+		NotBefore:         c.NotBefore,
+		NotAfter:          c.NotAfter,
+		Sha256Fingerprint: hex.EncodeToString(sum[:]),
+	}
+}
@@ -0,0 +1,19 @@
+// Package einvoice reads the payment information of electronic invoices:
+// hybrid ZUGFeRD and Factur-X PDFs with embedded CII XML, and standalone
+// XRechnung files in CII or UBL syntax.
+//
+// The invoice can be turned into the payment details of a send request, or
+// into a complete Invoice-typed request, instead of entering amount, IBAN,
+// due date and reference by hand:
+//
+//	req, mismatches, err := einvoice.NewSendContentRequest(pdfBytes, nil)
+//	if err != nil {
+//		return err
+//	}
+//	res, status, err := content.SendContent(client, ctx, &tenant, req)
+//
+// Values given by hand are compared with the XML and reported as
+// [Mismatch]es.
+//
+// See docs/einvoice.md for more examples.
+package einvoice
@@ -0,0 +1,371 @@
+package einvoice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// Syntax is the XML syntax of an e-invoice.
+type Syntax string
+
+const (
+	// SyntaxCII is the UN/CEFACT Cross Industry Invoice, used by ZUGFeRD,
+	// Factur-X and XRechnung.
+	SyntaxCII Syntax = "CII"
+	// SyntaxUBL is the OASIS Universal Business Language invoice, used by
+	// XRechnung and Peppol.
+	SyntaxUBL Syntax = "UBL"
+)
+
+// PaymentMeansSepaCreditTransfer is the UNTDID 4461 code of SEPA credit
+// transfers.
+const PaymentMeansSepaCreditTransfer = "58"
+
+// ErrNoInvoice is returned when a document contains no e-invoice XML.
+var ErrNoInvoice = errors.New("no e-invoice found")
+
+// embeddedNames are the file names of the invoice XML in hybrid PDFs, in
+// order of preference.
+var embeddedNames = []string{"factur-x.xml", "zugferd-invoice.xml", "xrechnung.xml"}
+
+// Party is the seller or buyer of an invoice.
+type Party struct {
+	Name  string
+	Email string
+	// Address is the postal address, if given.
+	Address *content.Recipient
+}
+
+// Invoice holds the fields of an e-invoice relevant for sending it. The
+// names of the EN 16931 business terms are given in brackets.
+type Invoice struct {
+	Syntax Syntax
+	// Profile is the specification identifier (BT-24), e.g.
+	// "urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0".
+	Profile string
+	// Number is the invoice number (BT-1).
+	Number string
+	// TypeCode is the UNTDID 1001 document type (BT-3), e.g. "380".
+	TypeCode string
+	// IssueDate (BT-2) and DueDate (BT-9) in the format YYYY-MM-DD.
+	IssueDate string
+	DueDate   string
+	Seller    Party
+	Buyer     Party
+	// Total is the amount including VAT (BT-112).
+	Total *payments.Money
+	// DuePayable is the amount to pay (BT-115), after prepaid amounts.
+	DuePayable *payments.Money
+	// PaymentMeansCode is the UNTDID 4461 code (BT-81), e.g. "58".
+	PaymentMeansCode string
+	// Iban (BT-84), AccountName (BT-85) and Bic (BT-86) of the payee.
+	Iban        string
+	AccountName string
+	Bic         string
+	// Reference is the remittance information (BT-83).
+	Reference string
+	// PaymentTerms is the text of the payment terms (BT-20).
+	PaymentTerms string
+}
+
+// Read reads an e-invoice from a hybrid PDF (ZUGFeRD, Factur-X) or a
+// standalone CII or UBL XML file.
+func Read(data []byte) (*Invoice, error) {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		xml, _, err := ExtractXML(data)
+		if err != nil {
+			return nil, err
+		}
+		data = xml
+	}
+	return ParseXML(data)
+}
+
+// ExtractXML returns the invoice XML embedded in a hybrid PDF and its file
+// name.
+func ExtractXML(pdfBytes []byte) ([]byte, string, error) {
+	r, err := pdf.Open(pdfBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	files := r.EmbeddedFiles()
+	for _, name := range embeddedNames {
+		for _, f := range files {
+			if strings.EqualFold(path.Base(f.Name), name) {
+				return f.Data, f.Name, nil
+			}
+		}
+	}
+	// other names, e.g. of XRechnung attachments
+	for _, f := range files {
+		if _, err := syntaxOf(f.Data); err == nil {
+			return f.Data, f.Name, nil
+		}
+	}
+	return nil, "", ErrNoInvoice
+}
+
+// ParseXML parses a CII or UBL invoice.
+func ParseXML(data []byte) (*Invoice, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("invoice xml: %w", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, ErrNoInvoice
+	}
+	var inv *Invoice
+	var err error
+	switch root.Tag {
+	case "CrossIndustryInvoice":
+		inv, err = parseCII(root)
+	case "Invoice":
+		inv, err = parseUBL(root)
+	case "CreditNote":
+		return nil, errors.New("credit notes carry no payment and are not supported")
+	default:
+		return nil, fmt.Errorf("%w: unexpected root element %s", ErrNoInvoice, root.Tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inv.TypeCode == "381" {
+		return nil, errors.New("credit notes carry no payment and are not supported")
+	}
+	return inv, nil
+}
+
+func syntaxOf(data []byte) (Syntax, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil || doc.Root() == nil {
+		return "", ErrNoInvoice
+	}
+	switch doc.Root().Tag {
+	case "CrossIndustryInvoice":
+		return SyntaxCII, nil
+	case "Invoice":
+		return SyntaxUBL, nil
+	}
+	return "", ErrNoInvoice
+}
+
+func parseCII(root *etree.Element) (*Invoice, error) {
+	inv := &Invoice{
+		Syntax:    SyntaxCII,
+		Profile:   text(root, "ExchangedDocumentContext", "GuidelineSpecifiedDocumentContextParameter", "ID"),
+		Number:    text(root, "ExchangedDocument", "ID"),
+		TypeCode:  text(root, "ExchangedDocument", "TypeCode"),
+		IssueDate: ciiDate(child(root, "ExchangedDocument", "IssueDateTime", "DateTimeString")),
+	}
+	trade := child(root, "SupplyChainTradeTransaction")
+	agreement := child(trade, "ApplicableHeaderTradeAgreement")
+	inv.Seller = ciiParty(child(agreement, "SellerTradeParty"))
+	inv.Buyer = ciiParty(child(agreement, "BuyerTradeParty"))
+
+	settlement := child(trade, "ApplicableHeaderTradeSettlement")
+	currency := text(settlement, "InvoiceCurrencyCode")
+	inv.Reference = text(settlement, "PaymentReference")
+	for _, means := range children(settlement, "SpecifiedTradeSettlementPaymentMeans") {
+		if inv.PaymentMeansCode == "" || (inv.Iban == "" && text(means, "PayeePartyCreditorFinancialAccount", "IBANID") != "") {
+			inv.PaymentMeansCode = text(means, "TypeCode")
+			inv.Iban = text(means, "PayeePartyCreditorFinancialAccount", "IBANID")
+			inv.AccountName = text(means, "PayeePartyCreditorFinancialAccount", "AccountName")
+			inv.Bic = text(means, "PayeeSpecifiedCreditorFinancialInstitution", "BICID")
+		}
+	}
+	terms := child(settlement, "SpecifiedTradePaymentTerms")
+	inv.PaymentTerms = text(terms, "Description")
+	inv.DueDate = ciiDate(child(terms, "DueDateDateTime", "DateTimeString"))
+
+	summation := child(settlement, "SpecifiedTradeSettlementHeaderMonetarySummation")
+	var err error
+	if inv.Total, err = amount(summation, "GrandTotalAmount", currency); err != nil {
+		return nil, err
+	}
+	if inv.DuePayable, err = amount(summation, "DuePayableAmount", currency); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func ciiParty(el *etree.Element) Party {
+	if el == nil {
+		return Party{}
+	}
+	p := Party{
+		Name:  text(el, "Name"),
+		Email: text(el, "URIUniversalCommunication", "URIID"),
+	}
+	if addr := child(el, "PostalTradeAddress"); addr != nil {
+		p.Address = recipient(
+			[]string{text(addr, "LineOne"), text(addr, "LineTwo"), text(addr, "LineThree")},
+			text(addr, "PostcodeCode"), text(addr, "CityName"), text(addr, "CountryID"))
+	}
+	return p
+}
+
+// ciiDate converts a date of format 102 (YYYYMMDD) to YYYY-MM-DD.
+func ciiDate(el *etree.Element) string {
+	s := strings.TrimSpace(elementText(el))
+	if len(s) != 8 || (el.SelectAttrValue("format", "102") != "102") {
+		return s
+	}
+	return s[:4] + "-" + s[4:6] + "-" + s[6:]
+}
+
+func parseUBL(root *etree.Element) (*Invoice, error) {
+	inv := &Invoice{
+		Syntax:    SyntaxUBL,
+		Profile:   text(root, "CustomizationID"),
+		Number:    text(root, "ID"),
+		TypeCode:  text(root, "InvoiceTypeCode"),
+		IssueDate: text(root, "IssueDate"),
+		DueDate:   text(root, "DueDate"),
+		Seller:    ublParty(child(root, "AccountingSupplierParty", "Party")),
+		Buyer:     ublParty(child(root, "AccountingCustomerParty", "Party")),
+	}
+	currency := text(root, "DocumentCurrencyCode")
+	for _, means := range children(root, "PaymentMeans") {
+		if inv.PaymentMeansCode == "" || (inv.Iban == "" && text(means, "PayeeFinancialAccount", "ID") != "") {
+			inv.PaymentMeansCode = text(means, "PaymentMeansCode")
+			inv.Reference = text(means, "PaymentID")
+			inv.Iban = text(means, "PayeeFinancialAccount", "ID")
+			inv.AccountName = text(means, "PayeeFinancialAccount", "Name")
+			inv.Bic = text(means, "PayeeFinancialAccount", "FinancialInstitutionBranch", "ID")
+		}
+	}
+	inv.PaymentTerms = text(root, "PaymentTerms", "Note")
+
+	total := child(root, "LegalMonetaryTotal")
+	var err error
+	if inv.Total, err = amount(total, "TaxInclusiveAmount", currency); err != nil {
+		return nil, err
+	}
+	if inv.DuePayable, err = amount(total, "PayableAmount", currency); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func ublParty(el *etree.Element) Party {
+	if el == nil {
+		return Party{}
+	}
+	p := Party{
+		Name:  text(el, "PartyName", "Name"),
+		Email: text(el, "Contact", "ElectronicMail"),
+	}
+	if p.Name == "" {
+		p.Name = text(el, "PartyLegalEntity", "RegistrationName")
+	}
+	if endpoint := child(el, "EndpointID"); p.Email == "" && endpoint != nil && endpoint.SelectAttrValue("schemeID", "") == "EM" {
+		p.Email = strings.TrimSpace(endpoint.Text())
+	}
+	if addr := child(el, "PostalAddress"); addr != nil {
+		p.Address = recipient(
+			[]string{text(addr, "StreetName"), text(addr, "AdditionalStreetName"), text(addr, "AddressLine", "Line")},
+			text(addr, "PostalZone"), text(addr, "CityName"), text(addr, "Country", "IdentificationCode"))
+	}
+	return p
+}
+
+func recipient(lines []string, postalCode, city, country string) *content.Recipient {
+	r := &content.Recipient{PostalCode: str(postalCode), City: str(city), Country: str(country)}
+	var set []string
+	for _, l := range lines {
+		if l != "" {
+			set = append(set, l)
+		}
+	}
+	for i, target := range []**string{&r.AddressLine1, &r.AddressLine2, &r.AddressLine3} {
+		if i < len(set) {
+			*target = str(set[i])
+		}
+	}
+	return r
+}
+
+// amount reads an amount element in the given currency. CII repeats the
+// tax total in the accounting currency; elements in another currency than
+// the invoice are skipped.
+func amount(el *etree.Element, tag, currency string) (*payments.Money, error) {
+	for _, e := range children(el, tag) {
+		c := e.SelectAttrValue("currencyID", currency)
+		if c != currency && currency != "" {
+			continue
+		}
+		m, err := payments.ParseDecimal(elementText(e), c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		return &m, nil
+	}
+	return nil, nil
+}
+
+// child follows a path of local element names, ignoring namespaces, and
+// returns the first match.
+func child(el *etree.Element, tags ...string) *etree.Element {
+	for _, tag := range tags {
+		if el == nil {
+			return nil
+		}
+		var next *etree.Element
+		for _, c := range el.ChildElements() {
+			if c.Tag == tag {
+				next = c
+				break
+			}
+		}
+		el = next
+	}
+	return el
+}
+
+// children returns the child elements of el with the local name tag.
+func children(el *etree.Element, tag string) []*etree.Element {
+	if el == nil {
+		return nil
+	}
+	var found []*etree.Element
+	for _, c := range el.ChildElements() {
+		if c.Tag == tag {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func text(el *etree.Element, tags ...string) string {
+	return strings.TrimSpace(elementText(child(el, tags...)))
+}
+
+func elementText(el *etree.Element) string {
+	if el == nil {
+		return ""
+	}
+	return el.Text()
+}
+
+func str(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,172 @@
+package einvoice_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/einvoice"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// hybridPdf builds a PDF/A-3 style document with xml attached as
+// factur-x.xml, referenced by the EmbeddedFiles name tree and the AF array.
+func hybridPdf(xml []byte) []byte {
+	return hybridPdfWith(xml, "")
+}
+
+// hybridPdfWith is hybridPdf with extra entries in the dictionary of the
+// embedded file stream, e.g. DecodeParms.
+func hybridPdfWith(xml []byte, extra string) []byte {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(xml)
+	zw.Close()
+
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Names << /EmbeddedFiles << /Names [(factur-x.xml) 5 0 R] >> >> /AF [5 0 R] >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R >>",
+		"<< /Length 0 >>\nstream\n\nendstream",
+		"<< /Type /Filespec /F (factur-x.xml) /UF <FEFF006600610063007400750072002D0078002E0078006D006C> /EF << /F 6 0 R >> /AFRelationship /Alternative >>",
+		fmt.Sprintf("<< /Type /EmbeddedFile /Subtype /text#2Fxml /Filter /FlateDecode%s /Length %d >>\nstream\n%s\nendstream", extra, z.Len(), z.Bytes()),
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func readFile(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("test/" + name)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return data
+}
+
+func TestReadCII(t *testing.T) {
+	xml := readFile(t, "factur-x.xml")
+	extracted, name, err := einvoice.ExtractXML(hybridPdf(xml))
+	if err != nil || !bytes.Equal(extracted, xml) {
+		t.Errorf("ExtractXML failed: %v", err)
+		return
+	}
+	if name != "factur-x.xml" {
+		t.Errorf("Unexpected file name %q", name)
+	}
+
+	inv, err := einvoice.Read(hybridPdf(xml))
+	if err != nil {
+		t.Errorf("Read failed: %v", err)
+		return
+	}
+	if inv.Syntax != einvoice.SyntaxCII || inv.Number != "R-2025-17" || inv.IssueDate != "2025-03-01" || inv.DueDate != "2025-03-31" {
+		t.Errorf("Unexpected invoice %+v", inv)
+	}
+	if *inv.DuePayable != payments.Cents(123455) || *inv.Total != payments.Cents(123455) {
+		t.Errorf("Unexpected amounts %v %v", inv.Total, inv.DuePayable)
+	}
+	if inv.Iban != "DE89370400440532013000" || inv.Bic != "COBADEFFXXX" || inv.Reference != "RF18539007547034" || inv.PaymentMeansCode != einvoice.PaymentMeansSepaCreditTransfer {
+		t.Errorf("Unexpected payment means %+v", inv)
+	}
+	if inv.Buyer.Email != "max@example.com" || *inv.Buyer.Address.AddressLine1 != "Hauptstraße 5" || *inv.Buyer.Address.City != "Berlin" {
+		t.Errorf("Unexpected buyer %+v", inv.Buyer)
+	}
+}
+
+func TestReadUBL(t *testing.T) {
+	inv, err := einvoice.Read(readFile(t, "xrechnung-ubl.xml"))
+	if err != nil {
+		t.Errorf("Read failed: %v", err)
+		return
+	}
+	if inv.Syntax != einvoice.SyntaxUBL || inv.Number != "2025-0042" || inv.DueDate != "2025-03-15" || inv.Seller.Name != "Muster GmbH" {
+		t.Errorf("Unexpected invoice %+v", inv)
+	}
+	if *inv.DuePayable != payments.Cents(10000) || *inv.Total != payments.Cents(11900) || inv.Buyer.Email != "einkauf@stadt.example" {
+		t.Errorf("Unexpected invoice %+v", inv)
+	}
+	details := inv.PaymentDetails()
+	if *details.Iban != "DE89370400440532013000" || *details.Reference != "2025-0042" || *details.Description != "Zahlbar bis 15.03.2025" {
+		t.Errorf("Unexpected payment details %+v", details)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := einvoice.Read(hybridPdf([]byte("<note/>"))); !errors.Is(err, einvoice.ErrNoInvoice) {
+		t.Errorf("Expected ErrNoInvoice, got %v", err)
+	}
+	credit := bytes.Replace(readFile(t, "factur-x.xml"), []byte("<ram:TypeCode>380"), []byte("<ram:TypeCode>381"), 1)
+	if _, err := einvoice.Read(credit); err == nil {
+		t.Error("Expected an error for a credit note")
+	}
+}
+
+func TestReadInvalidPredictor(t *testing.T) {
+	xml := readFile(t, "factur-x.xml")
+	for _, params := range []string{
+		"<< /Predictor 12 /Columns -8 >>",
+		"<< /Predictor 12 /Columns 1 /Colors 0 >>",
+		"<< /Predictor 12 /Columns 1 /BitsPerComponent 3 >>",
+		"<< /Predictor 12 /Columns 9223372036854775807 >>",
+		// the data is no multiple of 2 + 1 bytes
+		"<< /Predictor 12 /Columns 2 >>",
+	} {
+		data := xml
+		if len(data)%3 == 0 {
+			data = append(data, ' ')
+		}
+		if _, err := einvoice.Read(hybridPdfWith(data, " /DecodeParms "+params)); !errors.Is(err, einvoice.ErrNoInvoice) {
+			t.Errorf("Expected the attachment with %s to be skipped, got %v", params, err)
+		}
+	}
+}
+
+func TestReadOversizedAttachment(t *testing.T) {
+	// inflates to more than the 64 MiB limit of decoded streams
+	xml := append(readFile(t, "factur-x.xml"), bytes.Repeat([]byte(" "), 65<<20)...)
+	if _, err := einvoice.Read(hybridPdf(xml)); !errors.Is(err, einvoice.ErrNoInvoice) {
+		t.Errorf("Expected the oversized attachment to be skipped, got %v", err)
+	}
+}
+
+func TestNewSendContentRequest(t *testing.T) {
+	pdfBytes := hybridPdf(readFile(t, "factur-x.xml"))
+	req, mismatches, err := einvoice.NewSendContentRequest(pdfBytes, nil)
+	if err != nil || len(mismatches) > 0 {
+		t.Errorf("NewSendContentRequest failed: %v %v", err, mismatches)
+		return
+	}
+	if *req.Type != content.Invoice || *req.Subject != "Rechnung R-2025-17" || *req.To.Email.Email != "max@example.com" || !*req.PaymentInfo.Payable {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if err := req.PaymentInfo.Validate(); err != nil {
+		t.Errorf("Expected valid payment info: %v", err)
+	}
+
+	amount := payments.Cents(123456)
+	_, mismatches, _ = einvoice.NewSendContentRequest(pdfBytes, &content.PaymentDetails{
+		Amount:  &amount,
+		Iban:    sdk.String("DE89 3704 0044 0532 0130 00"),
+		DueDate: sdk.String("2025-04-01"),
+	})
+	if len(mismatches) != 2 || mismatches[0].Field != "amount" || mismatches[1].Field != "due_date" {
+		t.Errorf("Expected amount and due date mismatches, got %v", mismatches)
+	}
+}
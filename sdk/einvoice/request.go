@@ -0,0 +1,132 @@
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// Mismatch is a payment field whose value in the invoice XML differs from
+// the value given by hand.
+type Mismatch struct {
+	// Field is the JSON name of the field in content.PaymentDetails, e.g.
+	// "iban".
+	Field string
+	Xml   string
+	Given string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: invoice has %q, given %q", m.Field, m.Xml, m.Given)
+}
+
+// PaymentDetails maps the invoice to the payment details of a send request:
+// the amount due, the payee IBAN, the due date and the remittance
+// information, or the invoice number if there is none.
+func (inv *Invoice) PaymentDetails() *content.PaymentDetails {
+	details := &content.PaymentDetails{
+		Amount:      inv.DuePayable,
+		Iban:        str(payments.NormalizeIban(inv.Iban)),
+		DueDate:     str(inv.DueDate),
+		Reference:   str(inv.Reference),
+		Description: str(inv.PaymentTerms),
+	}
+	if details.Reference == nil {
+		details.Reference = str(inv.Number)
+	}
+	return details
+}
+
+// Compare reports the fields of given that differ from the invoice. Fields
+// that are not set in given are not compared, nor is the reference of an
+// invoice without remittance information.
+func (inv *Invoice) Compare(given *content.PaymentDetails) []Mismatch {
+	if given == nil {
+		return nil
+	}
+	xml := inv.PaymentDetails()
+	var mismatches []Mismatch
+	if given.Amount != nil && (xml.Amount == nil || *given.Amount != *xml.Amount) {
+		mismatches = append(mismatches, Mismatch{Field: "amount", Xml: moneyText(xml.Amount), Given: given.Amount.String()})
+	}
+	compare := func(field string, x, g *string, normalize func(string) string) {
+		if g == nil || *g == "" {
+			return
+		}
+		if normalize(strVal(x)) != normalize(*g) {
+			mismatches = append(mismatches, Mismatch{Field: field, Xml: strVal(x), Given: *g})
+		}
+	}
+	compare("iban", xml.Iban, given.Iban, payments.NormalizeIban)
+	compare("due_date", xml.DueDate, given.DueDate, strings.TrimSpace)
+	if inv.Reference != "" {
+		// the invoice number is only a fallback for the reference
+		compare("reference", xml.Reference, given.Reference, strings.TrimSpace)
+	}
+	return mismatches
+}
+
+// SendContentRequest builds an Invoice-typed request with the payment
+// details of the invoice and body as content. The subject is "Rechnung"
+// followed by the invoice number and the receiver is the buyer's email
+// address, if the invoice has one; both can be changed before sending.
+//
+// The values of the invoice take precedence over given, because they are
+// part of the document the recipient sees; differences are returned as
+// mismatches, so that the caller can stop before sending inconsistent
+// payment information.
+func (inv *Invoice) SendContentRequest(body []content.ContentItem, given *content.PaymentDetails) (*content.SendContentRequest, []Mismatch) {
+	details := inv.PaymentDetails()
+	if given != nil {
+		// fill what the invoice leaves open
+		if details.Amount == nil {
+			details.Amount = given.Amount
+		}
+		if details.Iban == nil {
+			details.Iban = given.Iban
+		}
+		if details.DueDate == nil {
+			details.DueDate = given.DueDate
+		}
+		if inv.Reference == "" && given.Reference != nil {
+			details.Reference = given.Reference
+		}
+		if details.Description == nil {
+			details.Description = given.Description
+		}
+	}
+	payable := details.Amount != nil && details.Amount.Amount > 0 && details.Iban != nil
+	req := &content.SendContentRequest{
+		Type:        str(content.Invoice),
+		Body:        &body,
+		PaymentInfo: &content.PaymentInfo{Details: details, Payable: &payable},
+	}
+	if inv.Number != "" {
+		req.Subject = str("Rechnung " + inv.Number)
+	}
+	if inv.Buyer.Email != "" {
+		req.To = &content.ReceiverData{Email: &content.EmailReceiver{Email: str(inv.Buyer.Email), Name: str(inv.Buyer.Name)}}
+	}
+	return req, inv.Compare(given)
+}
+
+// NewSendContentRequest reads the e-invoice embedded in a hybrid PDF and
+// builds a request that sends the PDF with its payment details, see
+// Invoice.SendContentRequest.
+func NewSendContentRequest(pdfBytes []byte, given *content.PaymentDetails) (*content.SendContentRequest, []Mismatch, error) {
+	inv, err := Read(pdfBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, mismatches := inv.SendContentRequest([]content.ContentItem{content.PdfContentItem(pdfBytes)}, given)
+	return req, mismatches, nil
+}
+
+func moneyText(m *payments.Money) string {
+	if m == nil {
+		return ""
+	}
+	return m.String()
+}
@@ -0,0 +1,329 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/ratelimit"
+)
+
+var commaSpacing = regexp.MustCompile(`\s*,\s*`)
+
+// ParseCache stores parsed addresses by normalized input across runs of a
+// BatchParser, e.g. in a database. Implementations must be safe for
+// concurrent use and handle their own failures; a failed Get is a miss.
+type ParseCache interface {
+	// Get returns the address stored for key, or false.
+	Get(key string) (*ParsedAddress, bool)
+	Put(key string, address *ParsedAddress)
+}
+
+// MemoryParseCache is a ParseCache kept in memory.
+type MemoryParseCache struct {
+	mu        sync.RWMutex
+	addresses map[string]api.ParseAddressResponse
+}
+
+// NewMemoryParseCache returns an empty MemoryParseCache.
+func NewMemoryParseCache() *MemoryParseCache {
+	return &MemoryParseCache{addresses: map[string]api.ParseAddressResponse{}}
+}
+
+// Get returns a copy of the address stored for key.
+func (m *MemoryParseCache) Get(key string) (*ParsedAddress, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res, ok := m.addresses[key]
+	if !ok {
+		return nil, false
+	}
+	return &ParsedAddress{ParseAddressResponse: &res}, true
+}
+
+// Put stores a copy of address.
+func (m *MemoryParseCache) Put(key string, address *ParsedAddress) {
+	if address == nil || address.ParseAddressResponse == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addresses[key] = *address.ParseAddressResponse
+}
+
+// Len returns the number of cached addresses.
+func (m *MemoryParseCache) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.addresses)
+}
+
+// NormalizeInput trims an address, collapses white space and writes commas
+// as ", ". The input is sent to the API in this form.
+func NormalizeInput(input string) string {
+	s := strings.Join(strings.Fields(input), " ")
+	return strings.Trim(commaSpacing.ReplaceAllString(s, ", "), ", ")
+}
+
+// cacheKey is the key of a normalized input; case does not matter for
+// parsing.
+func cacheKey(normalized string) string {
+	return strings.ToLower(normalized)
+}
+
+// ParseResult is the outcome of parsing a single input.
+type ParseResult struct {
+	// Index is the position of the input in the input stream.
+	Index int
+	Input string
+	// Normalized is the input as sent to the API, see NormalizeInput.
+	Normalized string
+	Address    *ParsedAddress
+	HttpStatus int
+	ErrorCode  int
+	// Cached is set when the address was taken from the cache.
+	Cached bool
+	// Duplicate is set when the same normalized input occurred before in
+	// this run; it shares the result of the first occurrence.
+	Duplicate bool
+	Attempts  int
+	Err       error
+}
+
+// BatchParser parses many addresses with bounded concurrency, rate limiting
+// and retries of transient failures. Inputs are normalized and parsed once
+// per run; with a Cache, also once across runs.
+type BatchParser struct {
+	Client *sdkClient.BrifleClient
+	// Concurrency is the number of parallel requests. Defaults to 4.
+	Concurrency int
+	// RatePerSecond limits the number of requests per second. 0 means
+	// unlimited; rates above one per nanosecond are not limited further.
+	RatePerSecond float64
+	// MaxAttempts per input including the first one. Defaults to 3.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// further attempt. Defaults to one second.
+	Backoff time.Duration
+	// Cache stores successful results by normalized input. Optional.
+	Cache ParseCache
+}
+
+// parseCall is the parse of a normalized input shared by its duplicates.
+type parseCall struct {
+	normalized string
+	done       chan struct{}
+	address    *ParsedAddress
+	status     *api.ResponseStatus
+	attempts   int
+	cached     bool
+	err        error
+}
+
+// Parse parses every input received from inputs until the channel is
+// closed, and streams the results in input order. The returned channel is
+// closed after the last result and must be drained. When ctx ends, inputs not
+// yet parsed, including those still received until inputs is closed, are
+// returned with the context's error; the sender of inputs must still close
+// it.
+//
+//	inputs := make(chan string)
+//	go func() {
+//		defer close(inputs)
+//		for _, c := range customers {
+//			inputs <- c.Address
+//		}
+//	}()
+//	for res := range parser.Parse(ctx, inputs) {
+//		...
+//	}
+func (p *BatchParser) Parse(ctx context.Context, inputs <-chan string) <-chan ParseResult {
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	out := make(chan ParseResult)
+
+	type pendingInput struct {
+		index     int
+		input     string
+		duplicate bool
+		call      *parseCall
+	}
+	// pending bounds the number of inputs read ahead of the oldest result
+	pending := make(chan pendingInput, concurrency*16)
+	work := make(chan *parseCall)
+
+	limiter, stopLimiter := ratelimit.Start(p.RatePerSecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for call := range work {
+				p.parse(ctx, call, limiter)
+				close(call.done)
+			}
+		}()
+	}
+
+	// emit results in input order
+	go func() {
+		defer close(out)
+		for e := range pending {
+			<-e.call.done
+			res := ParseResult{
+				Index:      e.index,
+				Input:      e.input,
+				Normalized: e.call.normalized,
+				Address:    e.call.address,
+				Cached:     e.call.cached,
+				Duplicate:  e.duplicate,
+				Attempts:   e.call.attempts,
+				Err:        e.call.err,
+			}
+			if e.call.status != nil {
+				res.HttpStatus = e.call.status.HttpStatus
+				res.ErrorCode = e.call.status.ErrorCode
+			}
+			out <- res
+		}
+	}()
+
+	go func() {
+		defer func() {
+			close(work)
+			wg.Wait()
+			close(pending)
+			stopLimiter()
+		}()
+		calls := map[string]*parseCall{}
+		resolved := func(call *parseCall, err error) *parseCall {
+			call.err = err
+			close(call.done)
+			return call
+		}
+		// inputs are read until the channel is closed, also after ctx ended,
+		// so that the sender does not block
+		index := 0
+		for input := range inputs {
+			normalized := NormalizeInput(input)
+			key := cacheKey(normalized)
+			call, duplicate := calls[key]
+			switch {
+			case normalized == "":
+				call = resolved(&parseCall{done: make(chan struct{})}, errors.New("address is required"))
+			case duplicate:
+			case ctx.Err() != nil:
+				call = resolved(&parseCall{normalized: normalized, done: make(chan struct{})}, ctx.Err())
+				calls[key] = call
+			default:
+				call = &parseCall{normalized: normalized, done: make(chan struct{})}
+				calls[key] = call
+			}
+			pending <- pendingInput{index: index, input: input, duplicate: duplicate, call: call}
+			index++
+			if duplicate || call.err != nil {
+				continue
+			}
+			select {
+			case work <- call:
+			case <-ctx.Done():
+				resolved(call, ctx.Err())
+			}
+		}
+	}()
+	return out
+}
+
+// ParseAll parses inputs and returns the results in input order, see Parse.
+// There is a result for every input, also when ctx ends.
+func (p *BatchParser) ParseAll(ctx context.Context, inputs []string) ([]ParseResult, error) {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, input := range inputs {
+			ch <- input
+		}
+	}()
+	results := make([]ParseResult, 0, len(inputs))
+	for res := range p.Parse(ctx, ch) {
+		results = append(results, res)
+	}
+	return results, ctx.Err()
+}
+
+// parse runs a single call with retries.
+func (p *BatchParser) parse(ctx context.Context, call *parseCall, limiter <-chan time.Time) {
+	key := cacheKey(call.normalized)
+	if p.Cache != nil {
+		if cached, ok := p.Cache.Get(key); ok && cached != nil && cached.ParseAddressResponse != nil {
+			call.address, call.cached = cached, true
+			return
+		}
+	}
+
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if limiter != nil {
+			select {
+			case <-limiter:
+			case <-ctx.Done():
+				call.err = ctx.Err()
+				return
+			}
+		}
+
+		call.attempts = attempt
+		normalized := call.normalized
+		res, status, err := ParseAddress(p.Client, ctx, &normalized)
+		call.status, call.err = status, err
+		if err == nil && status != nil && status.HttpStatus >= 200 && status.HttpStatus < 300 && res != nil && res.ParseAddressResponse != nil {
+			call.address = res
+			if p.Cache != nil {
+				p.Cache.Put(key, res)
+			}
+			return
+		}
+		if err == nil {
+			call.err = fmt.Errorf("brifle error %d (http %d)", statusCode(status), httpStatus(status))
+		}
+		if !api.IsTransientFailure(status, err) || attempt == maxAttempts {
+			return
+		}
+		select {
+		case <-time.After(backoff << (attempt - 1)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func statusCode(status *api.ResponseStatus) int {
+	if status == nil {
+		return 0
+	}
+	return status.ErrorCode
+}
+
+func httpStatus(status *api.ResponseStatus) int {
+	if status == nil {
+		return 0
+	}
+	return status.HttpStatus
+}
@@ -0,0 +1,176 @@
+package address_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+)
+
+// parseServer parses "<street> <number>, <postcode> <city>". Inputs
+// containing "flaky" fail once with 503, "invalid" always with 400.
+func parseServer(t *testing.T, calls map[string]int) *client.BrifleClient {
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.ParseAddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Invalid request: %v", err)
+		}
+		mu.Lock()
+		calls[req.Address]++
+		n := calls[req.Address]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Address, "invalid"):
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(api.ResponseError{Code: 40000, Message: "cannot parse"})
+			return
+		case strings.Contains(req.Address, "flaky") && n == 1:
+			w.WriteHeader(503)
+			_ = json.NewEncoder(w).Encode(api.ResponseError{Code: 50300})
+			return
+		}
+		street, city, _ := strings.Cut(req.Address, ", ")
+		i := strings.LastIndex(street, " ")
+		postcode, town, _ := strings.Cut(city, " ")
+		_ = json.NewEncoder(w).Encode(api.ParseAddressResponse{Street: street[:i], HouseNumber: street[i+1:], Postcode: postcode, City: town})
+	}))
+	t.Cleanup(server.Close)
+	apiClient, err := api.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client.BrifleClient{ApiClient: apiClient}
+}
+
+func TestNormalizeInput(t *testing.T) {
+	if got := address.NormalizeInput("  Hauptstraße  5 ,12345   Berlin , "); got != "Hauptstraße 5, 12345 Berlin" {
+		t.Errorf("Unexpected normalized input %q", got)
+	}
+}
+
+func TestBatchParser(t *testing.T) {
+	calls := map[string]int{}
+	cache := address.NewMemoryParseCache()
+	cache.Put("bahnhofstraße 1, 54321 neustadt", &address.ParsedAddress{ParseAddressResponse: &api.ParseAddressResponse{Street: "Bahnhofstraße", HouseNumber: "1"}})
+	parser := &address.BatchParser{
+		Client:      parseServer(t, calls),
+		Concurrency: 3,
+		Backoff:     time.Millisecond,
+		Cache:       cache,
+	}
+	inputs := []string{
+		"Hauptstraße 5, 12345 Berlin",
+		"hauptstraße 5 ,12345  Berlin",
+		"Bahnhofstraße 1, 54321 Neustadt",
+		"flaky 7, 11111 Dorf",
+		"invalid",
+		"",
+	}
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, "Weg "+strings.Repeat("1", i+1)+", 22222 Stadt")
+	}
+
+	results, err := parser.ParseAll(context.Background(), inputs)
+	if err != nil {
+		t.Fatalf("ParseAll failed: %v", err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("Expected %d results, got %d", len(inputs), len(results))
+	}
+	for i, res := range results {
+		if res.Index != i || res.Input != inputs[i] {
+			t.Errorf("Result %d out of order: %+v", i, res)
+		}
+	}
+
+	if r := results[0]; r.Err != nil || r.Address.Street != "Hauptstraße" || r.Address.HouseNumber != "5" {
+		t.Errorf("Unexpected first result %+v", r)
+	}
+	if r := results[1]; !r.Duplicate || r.Address != results[0].Address {
+		t.Errorf("Expected the second input to share the first result, got %+v", r)
+	}
+	if calls["Hauptstraße 5, 12345 Berlin"] != 1 || calls["hauptstraße 5, 12345 Berlin"] != 0 {
+		t.Errorf("Expected one request for both spellings, got %v", calls)
+	}
+	if r := results[2]; !r.Cached || r.Address.Street != "Bahnhofstraße" || calls["Bahnhofstraße 1, 54321 Neustadt"] != 0 {
+		t.Errorf("Expected a cache hit, got %+v", r)
+	}
+	if r := results[3]; r.Err != nil || r.Attempts != 2 {
+		t.Errorf("Expected the flaky input to succeed on retry, got %+v", r)
+	}
+	if r := results[4]; r.Err == nil || r.HttpStatus != 400 || r.Attempts != 1 {
+		t.Errorf("Expected a permanent failure, got %+v", r)
+	}
+	if r := results[5]; r.Err == nil {
+		t.Errorf("Expected an error for empty input, got %+v", r)
+	}
+	if cache.Len() != 23 {
+		t.Errorf("Expected 23 cached addresses, got %d", cache.Len())
+	}
+}
+
+func TestBatchParserRateLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	// rates beyond one per nanosecond must not panic
+	for _, rate := range []float64{2e9, math.Inf(1)} {
+		parser := &address.BatchParser{Client: parseServer(t, map[string]int{}), RatePerSecond: rate}
+		results, err := parser.ParseAll(ctx, []string{"Hauptstraße 1, 12345 Berlin", "Hauptstraße 2, 12345 Berlin"})
+		if err != nil || len(results) != 2 || results[0].Err != nil || results[1].Err != nil {
+			t.Errorf("%g: expected 2 parsed addresses, got %+v: %v", rate, results, err)
+		}
+	}
+}
+
+func TestBatchParserCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	parser := &address.BatchParser{Client: parseServer(t, map[string]int{})}
+	inputs := make(chan string, 1)
+	inputs <- "Hauptstraße 5, 12345 Berlin"
+	close(inputs)
+	for res := range parser.Parse(ctx, inputs) {
+		if res.Err == nil {
+			t.Errorf("Expected the canceled context to fail the input, got %+v", res)
+		}
+	}
+
+	// inputs sent after ctx ended are drained and failed as well, so that an
+	// unbuffered sender does not block
+	unbuffered := make(chan string)
+	go func() {
+		defer close(unbuffered)
+		for i := 0; i < 5; i++ {
+			unbuffered <- fmt.Sprintf("Hauptstraße %d, 12345 Berlin", i)
+		}
+	}()
+	n := 0
+	for res := range parser.Parse(ctx, unbuffered) {
+		if res.Index != n || !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("Expected input %d to fail with the context's error, got %+v", n, res)
+		}
+		n++
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 results, got %d", n)
+	}
+
+	results, err := parser.ParseAll(ctx, []string{"Hauptstraße 1, 12345 Berlin", "Hauptstraße 2, 12345 Berlin"})
+	if !errors.Is(err, context.Canceled) || len(results) != 2 {
+		t.Errorf("Expected a result per input, got %d, %v", len(results), err)
+	}
+}
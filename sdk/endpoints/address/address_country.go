@@ -0,0 +1,428 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+)
+
+var (
+	// ErrUnknownCountry is returned when a country name has no ISO 3166-1
+	// alpha-2 code.
+	ErrUnknownCountry = errors.New("unknown country")
+	// ErrIncompleteAddress is returned when a parsed address lacks a
+	// component required for postal delivery.
+	ErrIncompleteAddress = errors.New("incomplete address")
+)
+
+// countryCodes maps normalized country names and aliases, English and
+// German, to alpha-2 codes. The English short names and alpha-3 codes of
+// all other countries are added from countries.
+var countryCodes = map[string]string{
+	"germany": "DE", "deutschland": "DE", "federal republic of germany": "DE", "bundesrepublik deutschland": "DE",
+	"austria": "AT", "österreich": "AT", "oesterreich": "AT",
+	"switzerland": "CH", "schweiz": "CH", "suisse": "CH", "svizzera": "CH",
+	"liechtenstein": "LI",
+	"luxembourg":    "LU", "luxemburg": "LU",
+	"belgium": "BE", "belgien": "BE", "belgique": "BE", "belgië": "BE",
+	"netherlands": "NL", "the netherlands": "NL", "niederlande": "NL", "holland": "NL", "nederland": "NL",
+	"france": "FR", "frankreich": "FR",
+	"italy": "IT", "italien": "IT", "italia": "IT",
+	"spain": "ES", "spanien": "ES", "españa": "ES", "espana": "ES",
+	"portugal": "PT",
+	"denmark":  "DK", "dänemark": "DK", "daenemark": "DK", "danmark": "DK",
+	"sweden": "SE", "schweden": "SE", "sverige": "SE",
+	"norway": "NO", "norwegen": "NO", "norge": "NO",
+	"finland": "FI", "finnland": "FI", "suomi": "FI",
+	"iceland": "IS", "island": "IS",
+	"ireland": "IE", "irland": "IE",
+	"united kingdom": "GB", "great britain": "GB", "uk": "GB", "england": "GB", "vereinigtes königreich": "GB", "großbritannien": "GB", "grossbritannien": "GB",
+	"poland": "PL", "polen": "PL", "polska": "PL",
+	"czech republic": "CZ", "czechia": "CZ", "tschechien": "CZ", "tschechische republik": "CZ",
+	"slovakia": "SK", "slowakei": "SK",
+	"hungary": "HU", "ungarn": "HU",
+	"slovenia": "SI", "slowenien": "SI",
+	"croatia": "HR", "kroatien": "HR", "hrvatska": "HR",
+	"romania": "RO", "rumänien": "RO", "rumaenien": "RO",
+	"bulgaria": "BG", "bulgarien": "BG",
+	"greece": "GR", "griechenland": "GR",
+	"cyprus": "CY", "zypern": "CY",
+	"malta":   "MT",
+	"estonia": "EE", "estland": "EE",
+	"latvia": "LV", "lettland": "LV",
+	"lithuania": "LT", "litauen": "LT",
+	"monaco": "MC",
+	"turkey": "TR", "türkei": "TR", "tuerkei": "TR", "türkiye": "TR",
+	"united states": "US", "united states of america": "US", "vereinigte staaten": "US",
+	"canada": "CA", "kanada": "CA",
+}
+
+// countries are the officially assigned ISO 3166-1 codes: alpha-2,
+// alpha-3 and the English short name.
+var countries = [][3]string{
+	{"AD", "AND", "Andorra"},
+	{"AE", "ARE", "United Arab Emirates"},
+	{"AF", "AFG", "Afghanistan"},
+	{"AG", "ATG", "Antigua and Barbuda"},
+	{"AI", "AIA", "Anguilla"},
+	{"AL", "ALB", "Albania"},
+	{"AM", "ARM", "Armenia"},
+	{"AO", "AGO", "Angola"},
+	{"AQ", "ATA", "Antarctica"},
+	{"AR", "ARG", "Argentina"},
+	{"AS", "ASM", "American Samoa"},
+	{"AT", "AUT", "Austria"},
+	{"AU", "AUS", "Australia"},
+	{"AW", "ABW", "Aruba"},
+	{"AX", "ALA", "Åland Islands"},
+	{"AZ", "AZE", "Azerbaijan"},
+	{"BA", "BIH", "Bosnia and Herzegovina"},
+	{"BB", "BRB", "Barbados"},
+	{"BD", "BGD", "Bangladesh"},
+	{"BE", "BEL", "Belgium"},
+	{"BF", "BFA", "Burkina Faso"},
+	{"BG", "BGR", "Bulgaria"},
+	{"BH", "BHR", "Bahrain"},
+	{"BI", "BDI", "Burundi"},
+	{"BJ", "BEN", "Benin"},
+	{"BL", "BLM", "Saint Barthélemy"},
+	{"BM", "BMU", "Bermuda"},
+	{"BN", "BRN", "Brunei Darussalam"},
+	{"BO", "BOL", "Bolivia"},
+	{"BQ", "BES", "Bonaire, Sint Eustatius and Saba"},
+	{"BR", "BRA", "Brazil"},
+	{"BS", "BHS", "Bahamas"},
+	{"BT", "BTN", "Bhutan"},
+	{"BV", "BVT", "Bouvet Island"},
+	{"BW", "BWA", "Botswana"},
+	{"BY", "BLR", "Belarus"},
+	{"BZ", "BLZ", "Belize"},
+	{"CA", "CAN", "Canada"},
+	{"CC", "CCK", "Cocos (Keeling) Islands"},
+	{"CD", "COD", "Democratic Republic of the Congo"},
+	{"CF", "CAF", "Central African Republic"},
+	{"CG", "COG", "Congo"},
+	{"CH", "CHE", "Switzerland"},
+	{"CI", "CIV", "Côte d'Ivoire"},
+	{"CK", "COK", "Cook Islands"},
+	{"CL", "CHL", "Chile"},
+	{"CM", "CMR", "Cameroon"},
+	{"CN", "CHN", "China"},
+	{"CO", "COL", "Colombia"},
+	{"CR", "CRI", "Costa Rica"},
+	{"CU", "CUB", "Cuba"},
+	{"CV", "CPV", "Cabo Verde"},
+	{"CW", "CUW", "Curaçao"},
+	{"CX", "CXR", "Christmas Island"},
+	{"CY", "CYP", "Cyprus"},
+	{"CZ", "CZE", "Czech Republic"},
+	{"DE", "DEU", "Germany"},
+	{"DJ", "DJI", "Djibouti"},
+	{"DK", "DNK", "Denmark"},
+	{"DM", "DMA", "Dominica"},
+	{"DO", "DOM", "Dominican Republic"},
+	{"DZ", "DZA", "Algeria"},
+	{"EC", "ECU", "Ecuador"},
+	{"EE", "EST", "Estonia"},
+	{"EG", "EGY", "Egypt"},
+	{"EH", "ESH", "Western Sahara"},
+	{"ER", "ERI", "Eritrea"},
+	{"ES", "ESP", "Spain"},
+	{"ET", "ETH", "Ethiopia"},
+	{"FI", "FIN", "Finland"},
+	{"FJ", "FJI", "Fiji"},
+	{"FK", "FLK", "Falkland Islands"},
+	{"FM", "FSM", "Micronesia"},
+	{"FO", "FRO", "Faroe Islands"},
+	{"FR", "FRA", "France"},
+	{"GA", "GAB", "Gabon"},
+	{"GB", "GBR", "United Kingdom"},
+	{"GD", "GRD", "Grenada"},
+	{"GE", "GEO", "Georgia"},
+	{"GF", "GUF", "French Guiana"},
+	{"GG", "GGY", "Guernsey"},
+	{"GH", "GHA", "Ghana"},
+	{"GI", "GIB", "Gibraltar"},
+	{"GL", "GRL", "Greenland"},
+	{"GM", "GMB", "Gambia"},
+	{"GN", "GIN", "Guinea"},
+	{"GP", "GLP", "Guadeloupe"},
+	{"GQ", "GNQ", "Equatorial Guinea"},
+	{"GR", "GRC", "Greece"},
+	{"GS", "SGS", "South Georgia and the South Sandwich Islands"},
+	{"GT", "GTM", "Guatemala"},
+	{"GU", "GUM", "Guam"},
+	{"GW", "GNB", "Guinea-Bissau"},
+	{"GY", "GUY", "Guyana"},
+	{"HK", "HKG", "Hong Kong"},
+	{"HM", "HMD", "Heard Island and McDonald Islands"},
+	{"HN", "HND", "Honduras"},
+	{"HR", "HRV", "Croatia"},
+	{"HT", "HTI", "Haiti"},
+	{"HU", "HUN", "Hungary"},
+	{"ID", "IDN", "Indonesia"},
+	{"IE", "IRL", "Ireland"},
+	{"IL", "ISR", "Israel"},
+	{"IM", "IMN", "Isle of Man"},
+	{"IN", "IND", "India"},
+	{"IO", "IOT", "British Indian Ocean Territory"},
+	{"IQ", "IRQ", "Iraq"},
+	{"IR", "IRN", "Iran"},
+	{"IS", "ISL", "Iceland"},
+	{"IT", "ITA", "Italy"},
+	{"JE", "JEY", "Jersey"},
+	{"JM", "JAM", "Jamaica"},
+	{"JO", "JOR", "Jordan"},
+	{"JP", "JPN", "Japan"},
+	{"KE", "KEN", "Kenya"},
+	{"KG", "KGZ", "Kyrgyzstan"},
+	{"KH", "KHM", "Cambodia"},
+	{"KI", "KIR", "Kiribati"},
+	{"KM", "COM", "Comoros"},
+	{"KN", "KNA", "Saint Kitts and Nevis"},
+	{"KP", "PRK", "North Korea"},
+	{"KR", "KOR", "South Korea"},
+	{"KW", "KWT", "Kuwait"},
+	{"KY", "CYM", "Cayman Islands"},
+	{"KZ", "KAZ", "Kazakhstan"},
+	{"LA", "LAO", "Laos"},
+	{"LB", "LBN", "Lebanon"},
+	{"LC", "LCA", "Saint Lucia"},
+	{"LI", "LIE", "Liechtenstein"},
+	{"LK", "LKA", "Sri Lanka"},
+	{"LR", "LBR", "Liberia"},
+	{"LS", "LSO", "Lesotho"},
+	{"LT", "LTU", "Lithuania"},
+	{"LU", "LUX", "Luxembourg"},
+	{"LV", "LVA", "Latvia"},
+	{"LY", "LBY", "Libya"},
+	{"MA", "MAR", "Morocco"},
+	{"MC", "MCO", "Monaco"},
+	{"MD", "MDA", "Moldova"},
+	{"ME", "MNE", "Montenegro"},
+	{"MF", "MAF", "Saint Martin"},
+	{"MG", "MDG", "Madagascar"},
+	{"MH", "MHL", "Marshall Islands"},
+	{"MK", "MKD", "North Macedonia"},
+	{"ML", "MLI", "Mali"},
+	{"MM", "MMR", "Myanmar"},
+	{"MN", "MNG", "Mongolia"},
+	{"MO", "MAC", "Macao"},
+	{"MP", "MNP", "Northern Mariana Islands"},
+	{"MQ", "MTQ", "Martinique"},
+	{"MR", "MRT", "Mauritania"},
+	{"MS", "MSR", "Montserrat"},
+	{"MT", "MLT", "Malta"},
+	{"MU", "MUS", "Mauritius"},
+	{"MV", "MDV", "Maldives"},
+	{"MW", "MWI", "Malawi"},
+	{"MX", "MEX", "Mexico"},
+	{"MY", "MYS", "Malaysia"},
+	{"MZ", "MOZ", "Mozambique"},
+	{"NA", "NAM", "Namibia"},
+	{"NC", "NCL", "New Caledonia"},
+	{"NE", "NER", "Niger"},
+	{"NF", "NFK", "Norfolk Island"},
+	{"NG", "NGA", "Nigeria"},
+	{"NI", "NIC", "Nicaragua"},
+	{"NL", "NLD", "Netherlands"},
+	{"NO", "NOR", "Norway"},
+	{"NP", "NPL", "Nepal"},
+	{"NR", "NRU", "Nauru"},
+	{"NU", "NIU", "Niue"},
+	{"NZ", "NZL", "New Zealand"},
+	{"OM", "OMN", "Oman"},
+	{"PA", "PAN", "Panama"},
+	{"PE", "PER", "Peru"},
+	{"PF", "PYF", "French Polynesia"},
+	{"PG", "PNG", "Papua New Guinea"},
+	{"PH", "PHL", "Philippines"},
+	{"PK", "PAK", "Pakistan"},
+	{"PL", "POL", "Poland"},
+	{"PM", "SPM", "Saint Pierre and Miquelon"},
+	{"PN", "PCN", "Pitcairn"},
+	{"PR", "PRI", "Puerto Rico"},
+	{"PS", "PSE", "Palestine"},
+	{"PT", "PRT", "Portugal"},
+	{"PW", "PLW", "Palau"},
+	{"PY", "PRY", "Paraguay"},
+	{"QA", "QAT", "Qatar"},
+	{"RE", "REU", "Réunion"},
+	{"RO", "ROU", "Romania"},
+	{"RS", "SRB", "Serbia"},
+	{"RU", "RUS", "Russia"},
+	{"RW", "RWA", "Rwanda"},
+	{"SA", "SAU", "Saudi Arabia"},
+	{"SB", "SLB", "Solomon Islands"},
+	{"SC", "SYC", "Seychelles"},
+	{"SD", "SDN", "Sudan"},
+	{"SE", "SWE", "Sweden"},
+	{"SG", "SGP", "Singapore"},
+	{"SH", "SHN", "Saint Helena, Ascension and Tristan da Cunha"},
+	{"SI", "SVN", "Slovenia"},
+	{"SJ", "SJM", "Svalbard and Jan Mayen"},
+	{"SK", "SVK", "Slovakia"},
+	{"SL", "SLE", "Sierra Leone"},
+	{"SM", "SMR", "San Marino"},
+	{"SN", "SEN", "Senegal"},
+	{"SO", "SOM", "Somalia"},
+	{"SR", "SUR", "Suriname"},
+	{"SS", "SSD", "South Sudan"},
+	{"ST", "STP", "Sao Tome and Principe"},
+	{"SV", "SLV", "El Salvador"},
+	{"SX", "SXM", "Sint Maarten"},
+	{"SY", "SYR", "Syria"},
+	{"SZ", "SWZ", "Eswatini"},
+	{"TC", "TCA", "Turks and Caicos Islands"},
+	{"TD", "TCD", "Chad"},
+	{"TF", "ATF", "French Southern Territories"},
+	{"TG", "TGO", "Togo"},
+	{"TH", "THA", "Thailand"},
+	{"TJ", "TJK", "Tajikistan"},
+	{"TK", "TKL", "Tokelau"},
+	{"TL", "TLS", "Timor-Leste"},
+	{"TM", "TKM", "Turkmenistan"},
+	{"TN", "TUN", "Tunisia"},
+	{"TO", "TON", "Tonga"},
+	{"TR", "TUR", "Turkey"},
+	{"TT", "TTO", "Trinidad and Tobago"},
+	{"TV", "TUV", "Tuvalu"},
+	{"TW", "TWN", "Taiwan"},
+	{"TZ", "TZA", "Tanzania"},
+	{"UA", "UKR", "Ukraine"},
+	{"UG", "UGA", "Uganda"},
+	{"UM", "UMI", "United States Minor Outlying Islands"},
+	{"US", "USA", "United States"},
+	{"UY", "URY", "Uruguay"},
+	{"UZ", "UZB", "Uzbekistan"},
+	{"VA", "VAT", "Holy See"},
+	{"VC", "VCT", "Saint Vincent and the Grenadines"},
+	{"VE", "VEN", "Venezuela"},
+	{"VG", "VGB", "British Virgin Islands"},
+	{"VI", "VIR", "United States Virgin Islands"},
+	{"VN", "VNM", "Viet Nam"},
+	{"VU", "VUT", "Vanuatu"},
+	{"WF", "WLF", "Wallis and Futuna"},
+	{"WS", "WSM", "Samoa"},
+	{"YE", "YEM", "Yemen"},
+	{"YT", "MYT", "Mayotte"},
+	{"ZA", "ZAF", "South Africa"},
+	{"ZM", "ZMB", "Zambia"},
+	{"ZW", "ZWE", "Zimbabwe"},
+}
+
+// countryNames are the English names of all alpha-2 codes.
+var countryNames = map[string]string{}
+
+func init() {
+	for _, c := range countries {
+		countryNames[c[0]] = c[2]
+		for _, key := range []string{strings.ToLower(c[1]), strings.ToLower(c[2])} {
+			if _, ok := countryCodes[key]; !ok {
+				countryCodes[key] = c[0]
+			}
+		}
+	}
+}
+
+// houseNumberFirst are the countries where the house number precedes the
+// street name.
+var houseNumberFirst = map[string]bool{"FR": true, "LU": true, "GB": true, "IE": true, "US": true, "CA": true}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of a country name in
+// English or German, an alpha-3 code or an alpha-2 code, ignoring case.
+func CountryCode(name string) (string, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(name, ".", "")), " "))
+	if key == "" {
+		return "", fmt.Errorf("%w: empty name", ErrUnknownCountry)
+	}
+	if _, ok := countryNames[strings.ToUpper(key)]; ok {
+		return strings.ToUpper(key), nil
+	}
+	if code, ok := countryCodes[key]; ok {
+		return code, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownCountry, name)
+}
+
+// CountryName returns the English name of an alpha-2 code, or the code
+// itself if it is not assigned.
+func CountryName(code string) string {
+	if name, ok := countryNames[strings.ToUpper(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// CountryCode returns the alpha-2 code of the parsed country. An address
+// without country is taken to be in Germany.
+func (a *ParsedAddress) CountryCode() (string, error) {
+	if a == nil || a.ParseAddressResponse == nil {
+		return "", ErrIncompleteAddress
+	}
+	if strings.TrimSpace(a.Country) == "" {
+		return "DE", nil
+	}
+	return CountryCode(a.Country)
+}
+
+// StreetLine joins street and house number in the order of the country,
+// e.g. "Hauptstraße 5A" in Germany and "10 Downing Street" in the United
+// Kingdom.
+func (a *ParsedAddress) StreetLine() string {
+	if a == nil || a.ParseAddressResponse == nil {
+		return ""
+	}
+	street, number := strings.TrimSpace(a.Street), strings.TrimSpace(a.HouseNumber)
+	if number == "" {
+		return street
+	}
+	if street == "" {
+		return number
+	}
+	if code, _ := a.CountryCode(); houseNumberFirst[code] {
+		return number + " " + street
+	}
+	return street + " " + number
+}
+
+// Validate checks that street, postcode and city are set and the country
+// is known. Errors wrap ErrIncompleteAddress or ErrUnknownCountry.
+func (a *ParsedAddress) Validate() error {
+	if a == nil || a.ParseAddressResponse == nil {
+		return ErrIncompleteAddress
+	}
+	switch {
+	case strings.TrimSpace(a.Street) == "":
+		return fmt.Errorf("%w: no street", ErrIncompleteAddress)
+	case strings.TrimSpace(a.Postcode) == "":
+		return fmt.Errorf("%w: no postcode", ErrIncompleteAddress)
+	case strings.TrimSpace(a.City) == "":
+		return fmt.Errorf("%w: no city", ErrIncompleteAddress)
+	}
+	_, err := a.CountryCode()
+	return err
+}
+
+// PostalAddress converts the parsed address into the postal address of a
+// receiver, used to match a receiver by name and address.
+func (a *ParsedAddress) PostalAddress(firstName, lastName string) (*api.ApiSendContentReceiverPostalAddress, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if a.HouseNumber == "" {
+		return nil, fmt.Errorf("%w: no house number", ErrIncompleteAddress)
+	}
+	country, _ := a.CountryCode()
+	return &api.ApiSendContentReceiverPostalAddress{
+		FirstName:   strings.TrimSpace(firstName),
+		LastName:    strings.TrimSpace(lastName),
+		Street:      strings.TrimSpace(a.Street),
+		HouseNumber: strings.TrimSpace(a.HouseNumber),
+		Postcode:    strings.TrimSpace(a.Postcode),
+		City:        strings.TrimSpace(a.City),
+		Country:     country,
+	}, nil
+}
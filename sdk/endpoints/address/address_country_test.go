@@ -0,0 +1,70 @@
+package address_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+)
+
+func TestCountryCode(t *testing.T) {
+	tests := map[string]string{
+		"Germany":        "DE",
+		"deutschland":    "DE",
+		" Österreich ":   "AT",
+		"de":             "DE",
+		"CHE":            "CH",
+		"U.K.":           "GB",
+		"United  States": "US",
+		"JP":             "JP",
+		"jpn":            "JP",
+		"Australia":      "AU",
+		"New Zealand":    "NZ",
+	}
+	for name, want := range tests {
+		got, err := address.CountryCode(name)
+		if err != nil || got != want {
+			t.Errorf("CountryCode(%q) = %q, %v; expected %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"Atlantis", "XX", "ZZZ"} {
+		if _, err := address.CountryCode(name); !errors.Is(err, address.ErrUnknownCountry) {
+			t.Errorf("Expected ErrUnknownCountry for %q, got %v", name, err)
+		}
+	}
+	if name := address.CountryName("at"); name != "Austria" {
+		t.Errorf("Expected Austria, got %q", name)
+	}
+	if name := address.CountryName("JP"); name != "Japan" {
+		t.Errorf("Expected Japan, got %q", name)
+	}
+}
+
+func TestParsedAddressConversion(t *testing.T) {
+	parsed := &address.ParsedAddress{ParseAddressResponse: &api.ParseAddressResponse{
+		Street: "Hauptstraße", HouseNumber: "5A", Postcode: "12345", City: "Berlin", Country: "Germany",
+	}}
+	if line := parsed.StreetLine(); line != "Hauptstraße 5A" {
+		t.Errorf("Unexpected street line %q", line)
+	}
+	postal, err := parsed.PostalAddress("Max", "Mustermann")
+	if err != nil {
+		t.Fatalf("PostalAddress failed: %v", err)
+	}
+	if postal.Country != "DE" || postal.Street != "Hauptstraße" || postal.HouseNumber != "5A" || postal.LastName != "Mustermann" {
+		t.Errorf("Unexpected postal address %+v", postal)
+	}
+
+	uk := &address.ParsedAddress{ParseAddressResponse: &api.ParseAddressResponse{
+		Street: "Downing Street", HouseNumber: "10", Postcode: "SW1A 2AA", City: "London", Country: "United Kingdom",
+	}}
+	if line := uk.StreetLine(); line != "10 Downing Street" {
+		t.Errorf("Unexpected street line %q", line)
+	}
+
+	noCity := &address.ParsedAddress{ParseAddressResponse: &api.ParseAddressResponse{Street: "Hauptstraße", Postcode: "12345"}}
+	if err := noCity.Validate(); !errors.Is(err, address.ErrIncompleteAddress) {
+		t.Errorf("Expected ErrIncompleteAddress, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package address
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+)
+
+// AmbiguityMargin is the score difference below which the two best
+// candidates of a ranking are considered ambiguous.
+const AmbiguityMargin = 0.05
+
+// Weights of the score components. They add up to 1.
+const (
+	weightStreet      = 0.35
+	weightCity        = 0.2
+	weightPostcode    = 0.2
+	weightHouseNumber = 0.1
+	weightConsistency = 0.15
+)
+
+var germanPostcode = regexp.MustCompile(`^[0-9]{5}$`)
+
+// Candidate is a parsed address with its score against the input.
+type Candidate struct {
+	Address *ParsedAddress
+	// Score between 0 and 1; higher is better.
+	Score float64
+}
+
+// Ranking orders the interpretations of an address by how well they match
+// the input.
+type Ranking struct {
+	// Candidates ordered by descending score; ties keep the order of the API.
+	Candidates []Candidate
+	// Best is the first candidate, or nil if there is none.
+	Best *ParsedAddress
+	// Confidence is the score of the best candidate.
+	Confidence float64
+	// Ambiguous is set when the second candidate scores within
+	// AmbiguityMargin of the best one, so the user should choose.
+	Ambiguous bool
+}
+
+// Rank scores the candidates of ParseAndExpandAddress against the input:
+// how closely street and city resemble words of the input, whether postcode
+// and house number appear in it, and whether postcode and city are
+// consistent, i.e. the postcode has the format of the country and is written
+// next to the city.
+func (l *ParsedAddressList) Rank(input string) *Ranking {
+	if l == nil {
+		return &Ranking{}
+	}
+	addresses := make([]*ParsedAddress, 0, len(l.Addresses))
+	for _, a := range l.Addresses {
+		res := api.ParseAddressResponse(a)
+		addresses = append(addresses, &ParsedAddress{ParseAddressResponse: &res})
+	}
+	return Rank(input, addresses)
+}
+
+// Rank scores addresses against the input, see ParsedAddressList.Rank.
+func Rank(input string, addresses []*ParsedAddress) *Ranking {
+	words := tokens(input)
+	ranking := &Ranking{}
+	for _, a := range addresses {
+		if a == nil || a.ParseAddressResponse == nil {
+			continue
+		}
+		ranking.Candidates = append(ranking.Candidates, Candidate{Address: a, Score: score(a, words)})
+	}
+	sort.SliceStable(ranking.Candidates, func(i, j int) bool {
+		return ranking.Candidates[i].Score > ranking.Candidates[j].Score
+	})
+	if len(ranking.Candidates) == 0 {
+		return ranking
+	}
+	ranking.Best = ranking.Candidates[0].Address
+	ranking.Confidence = ranking.Candidates[0].Score
+	if len(ranking.Candidates) > 1 {
+		ranking.Ambiguous = ranking.Candidates[0].Score-ranking.Candidates[1].Score < AmbiguityMargin
+	}
+	return ranking
+}
+
+func score(a *ParsedAddress, words []string) float64 {
+	s := weightStreet*similarity(tokens(a.Street), words) +
+		weightCity*similarity(tokens(a.City), words)
+
+	postcode := tokens(a.Postcode)
+	at := indexOf(words, postcode)
+	if at >= 0 {
+		s += weightPostcode
+	}
+
+	if number := strings.Join(tokens(a.HouseNumber), ""); number != "" && indexOf(words, []string{number}) >= 0 {
+		s += weightHouseNumber
+	}
+
+	if consistent(a, postcode, at, words) {
+		s += weightConsistency
+	}
+	return s
+}
+
+// consistent reports whether the postcode has the format of the country and
+// the city is written right before or after it in words, where the postcode
+// starts at index at.
+func consistent(a *ParsedAddress, postcode []string, at int, words []string) bool {
+	city := tokens(a.City)
+	if at < 0 || len(city) == 0 {
+		return false
+	}
+	if code, err := a.CountryCode(); err == nil && code == "DE" && !germanPostcode.MatchString(strings.Join(postcode, "")) {
+		return false
+	}
+	if next := at + len(postcode); next < len(words) && wordSimilarity(city[0], words[next]) >= 0.8 {
+		return true
+	}
+	return at > 0 && wordSimilarity(city[len(city)-1], words[at-1]) >= 0.8
+}
+
+// indexOf returns the index of the first occurrence of seq in words, or -1.
+func indexOf(words, seq []string) int {
+	if len(seq) == 0 {
+		return -1
+	}
+	for i := 0; i+len(seq) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(seq)], seq) {
+			return i
+		}
+	}
+	return -1
+}
+
+// similarity is the mean of the best match in words of each token of want.
+func similarity(want, words []string) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, w := range want {
+		best := 0.0
+		for _, word := range words {
+			best = max(best, wordSimilarity(w, word))
+		}
+		sum += best
+	}
+	return sum / float64(len(want))
+}
+
+// wordSimilarity is 1 minus the Levenshtein distance relative to the longer
+// word.
+func wordSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+	if longer == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longer)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// normalizer transliterates German umlauts and expands the abbreviation
+// "str." of "Straße".
+var normalizer = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "str.", "strasse ")
+
+// normalize lowercases s and applies normalizer.
+func normalize(s string) string {
+	return strings.TrimSpace(normalizer.Replace(strings.ToLower(s)))
+}
+
+// tokens splits s into normalized words of letters and digits. A house
+// number suffix written apart, e.g. "5 a", is joined to its number.
+func tokens(s string) []string {
+	fields := strings.FieldsFunc(normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var words []string
+	for _, f := range fields {
+		if n := len(words); n > 0 && len([]rune(f)) == 1 && unicode.IsLetter([]rune(f)[0]) && isNumber(words[n-1]) {
+			words[n-1] += f
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+func isNumber(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
@@ -0,0 +1,60 @@
+package address_test
+
+import (
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+)
+
+func candidates(addresses ...api.ParseAddressResponse) *address.ParsedAddressList {
+	list := &address.ParsedAddressList{}
+	for _, a := range addresses {
+		list.Addresses = append(list.Addresses, struct {
+			City        string `json:"city"`
+			Country     string `json:"country"`
+			HouseNumber string `json:"house_number"`
+			Postcode    string `json:"postcode"`
+			Street      string `json:"street"`
+		}(a))
+	}
+	return list
+}
+
+func TestRank(t *testing.T) {
+	list := candidates(
+		api.ParseAddressResponse{Street: "Hauptstraße", City: "Berlin", Postcode: "12345"},
+		api.ParseAddressResponse{Street: "Hauptstraße", HouseNumber: "5A", City: "Berlin", Postcode: "12345", Country: "Germany"},
+		api.ParseAddressResponse{Street: "Hauptstraße 5A", City: "12345 Berlin"},
+	)
+	ranking := list.Rank("Hauptstr. 5 A, 12345 Berlin")
+	if ranking.Best == nil || ranking.Best.HouseNumber != "5A" {
+		t.Fatalf("Expected the candidate with house number to rank first, got %+v", ranking.Best)
+	}
+	if ranking.Confidence < 0.95 || ranking.Ambiguous {
+		t.Errorf("Expected a confident match, got confidence %.2f, ambiguous %v", ranking.Confidence, ranking.Ambiguous)
+	}
+	if len(ranking.Candidates) != 3 || ranking.Candidates[1].Score < ranking.Candidates[2].Score {
+		t.Errorf("Expected candidates ordered by score, got %+v", ranking.Candidates)
+	}
+}
+
+func TestRankAmbiguous(t *testing.T) {
+	list := candidates(
+		api.ParseAddressResponse{Street: "Bahnhofstraße", HouseNumber: "1", City: "Neustadt", Postcode: "67433"},
+		api.ParseAddressResponse{Street: "Bahnhofstraße", HouseNumber: "1", City: "Neustadt", Postcode: "67434"},
+	)
+	ranking := list.Rank("Bahnhofstrasse 1, Neustadt")
+	if !ranking.Ambiguous {
+		t.Errorf("Expected an ambiguous ranking, got %+v", ranking.Candidates)
+	}
+
+	ranking = list.Rank("Bahnhofstrasse 1, 67434 Neustadt")
+	if ranking.Ambiguous || ranking.Best.Postcode != "67434" {
+		t.Errorf("Expected the postcode to decide, got %+v", ranking.Best)
+	}
+
+	if empty := candidates().Rank("anything"); empty.Best != nil || empty.Ambiguous {
+		t.Errorf("Expected an empty ranking, got %+v", empty)
+	}
+}
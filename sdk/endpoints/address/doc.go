@@ -3,7 +3,10 @@
 //
 // [ParseAddress] returns the single best interpretation, while
 // [ParseAndExpandAddress] returns every plausible interpretation (useful when
-// spellings vary).
+// spellings vary); [ParsedAddressList.Rank] picks the best of them.
+// [CountryCode] maps country names to ISO 3166-1 alpha-2 codes, and
+// [ParsedAddress.PostalAddress] builds the postal address of a receiver.
+// [BatchParser] parses large numbers of addresses concurrently with caching.
 //
 //	res, respStatus, err := address.ParseAddress(client, ctx, sdk.String("Hauptstraße 5A, 12345 Berlin, Germany"))
 //	if err == nil && respStatus.HttpStatus == 200 {
@@ -0,0 +1,64 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+)
+
+// RecipientFromParsedAddress converts a parsed address into a paper mail
+// recipient: street and house number become the first address line and the
+// country its ISO 3166-1 alpha-2 code. Errors wrap
+// address.ErrIncompleteAddress or address.ErrUnknownCountry.
+func RecipientFromParsedAddress(a *address.ParsedAddress) (*Recipient, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	country, _ := a.CountryCode()
+	line1 := a.StreetLine()
+	postalCode := strings.TrimSpace(a.Postcode)
+	city := strings.TrimSpace(a.City)
+	return &Recipient{
+		AddressLine1: &line1,
+		PostalCode:   &postalCode,
+		City:         &city,
+		Country:      &country,
+	}, nil
+}
+
+// RecipientFromAddress parses a free-form address with
+// address.ParseAddress and converts it with RecipientFromParsedAddress. A
+// parse that the API answers with an error status is returned as
+// StatusError.
+func RecipientFromAddress(client *sdkClient.BrifleClient, ctx context.Context, freeText string) (*Recipient, *api.ResponseStatus, error) {
+	if strings.TrimSpace(freeText) == "" {
+		return nil, nil, errors.New("address is required")
+	}
+	parsed, status, err := address.ParseAddress(client, ctx, &freeText)
+	if err != nil {
+		return nil, status, err
+	}
+	if !statusOk(status) {
+		return nil, status, &StatusError{Operation: "parse address", Status: status}
+	}
+	recipient, err := RecipientFromParsedAddress(parsed)
+	return recipient, status, err
+}
+
+// PreviewReceiver returns the recipient as receiver of PreviewPaperMail.
+func (r *Recipient) PreviewReceiver() *PreviewReceiver {
+	if r == nil {
+		return nil
+	}
+	return &PreviewReceiver{
+		AddressLine1: r.AddressLine1,
+		AddressLine2: r.AddressLine2,
+		City:         r.City,
+		Country:      r.Country,
+		PostalCode:   r.PostalCode,
+	}
+}
@@ -0,0 +1,227 @@
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+)
+
+// Limits of the address field of a DIN 5008 letter.
+const (
+	// MaxAddressLineLength is the number of characters that fit a line of
+	// the address window.
+	MaxAddressLineLength = 35
+	// MaxAddressBlockLines is the number of lines of the address zone.
+	MaxAddressBlockLines = 6
+)
+
+// Fields reported in an AddressProblem.
+const (
+	AddressFieldStreet     = "street"
+	AddressFieldPostalCode = "postal_code"
+	AddressFieldCity       = "city"
+	AddressFieldCountry    = "country"
+	AddressFieldBlock      = "block"
+)
+
+// cityLineStyle is how postcode and city are printed.
+type cityLineStyle int
+
+const (
+	// postcodeCity prints "12345 Berlin".
+	postcodeCity cityLineStyle = iota
+	// cityPostcode prints "Toronto ON M5V 2T6".
+	cityPostcode
+	// cityThenPostcode prints the city and the postcode on lines of their
+	// own, as in the United Kingdom.
+	cityThenPostcode
+)
+
+// addressRule is the format of addresses in a country.
+type addressRule struct {
+	postcode *regexp.Regexp
+	style    cityLineStyle
+}
+
+var addressRules = map[string]addressRule{
+	"DE": {postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"AT": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"CH": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"LI": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"LU": {postcode: regexp.MustCompile(`^(L-)?[0-9]{4}$`)},
+	"BE": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"DK": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"NO": {postcode: regexp.MustCompile(`^[0-9]{4}$`)},
+	"NL": {postcode: regexp.MustCompile(`^[0-9]{4} ?[A-Z]{2}$`)},
+	"FR": {postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"IT": {postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"ES": {postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"FI": {postcode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"SE": {postcode: regexp.MustCompile(`^[0-9]{3} ?[0-9]{2}$`)},
+	"CZ": {postcode: regexp.MustCompile(`^[0-9]{3} ?[0-9]{2}$`)},
+	"SK": {postcode: regexp.MustCompile(`^[0-9]{3} ?[0-9]{2}$`)},
+	"PL": {postcode: regexp.MustCompile(`^[0-9]{2}-[0-9]{3}$`)},
+	"PT": {postcode: regexp.MustCompile(`^[0-9]{4}-[0-9]{3}$`)},
+	"GB": {postcode: regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`), style: cityThenPostcode},
+	"IE": {style: cityThenPostcode},
+	"US": {postcode: regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`), style: cityPostcode},
+	"CA": {postcode: regexp.MustCompile(`^[A-Z][0-9][A-Z] ?[0-9][A-Z][0-9]$`), style: cityPostcode},
+}
+
+// AddressProblem is a reason why an address may not be deliverable.
+type AddressProblem struct {
+	// Field is one of the AddressField constants.
+	Field   string
+	Message string
+}
+
+func (p AddressProblem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// AddressBlock is the address printed in the window of a letter.
+type AddressBlock struct {
+	Lines []string
+	// Country is the ISO 3166-1 alpha-2 code of the destination.
+	Country string
+	// Problems found while formatting. The lines are formatted anyway.
+	Problems []AddressProblem
+}
+
+// Valid reports whether no problems were found.
+func (b *AddressBlock) Valid() bool {
+	return len(b.Problems) == 0
+}
+
+func (b *AddressBlock) String() string {
+	return strings.Join(b.Lines, "\n")
+}
+
+// FormatAddressBlock formats the address block of a letter sent from
+// Germany following DIN 5008: the name lines, additional lines such as
+// "c/o", the street, postcode and city, and for mail abroad the city in
+// capitals and the country name in capitals on the last line. Postcode and
+// city are arranged as customary in the destination country.
+//
+// The block is checked for a postcode in the format of the country, a
+// house number, lines longer than MaxAddressLineLength and more lines than
+// MaxAddressBlockLines.
+func FormatAddressBlock(name []string, r *Recipient) *AddressBlock {
+	block := &AddressBlock{}
+	problem := func(field, format string, args ...any) {
+		block.Problems = append(block.Problems, AddressProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, n := range name {
+		if n = strings.Join(strings.Fields(n), " "); n != "" {
+			block.Lines = append(block.Lines, n)
+		}
+	}
+	if r == nil {
+		problem(AddressFieldStreet, "no postal address")
+		return block
+	}
+
+	block.Country = "DE"
+	if c := strings.TrimSpace(strVal(r.Country)); c != "" {
+		code, err := address.CountryCode(c)
+		if err != nil {
+			problem(AddressFieldCountry, "%v", err)
+			code = strings.ToUpper(c)
+		}
+		block.Country = code
+	}
+	abroad := block.Country != "DE"
+	rule := addressRules[block.Country]
+
+	// additional lines precede the street, which is the line with the house
+	// number
+	var lines []string
+	for _, v := range []*string{r.AddressLine1, r.AddressLine2, r.AddressLine3} {
+		if s := strings.Join(strings.Fields(strVal(v)), " "); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	street := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if hasHouseNumber(lines[i]) {
+			street = i
+			break
+		}
+	}
+	switch {
+	case len(lines) == 0:
+		problem(AddressFieldStreet, "no street")
+	case street < 0:
+		problem(AddressFieldStreet, "no house number in %q", lines[len(lines)-1])
+	default:
+		lines = append(append(lines[:street:street], lines[street+1:]...), lines[street])
+	}
+	block.Lines = append(block.Lines, lines...)
+
+	postcode := strings.ToUpper(strings.TrimSpace(strVal(r.PostalCode)))
+	city := strings.Join(strings.Fields(strVal(r.City)), " ")
+	switch {
+	case postcode == "" && rule.postcode != nil:
+		problem(AddressFieldPostalCode, "no postal code")
+	case postcode != "" && rule.postcode != nil && !rule.postcode.MatchString(postcode):
+		problem(AddressFieldPostalCode, "%q is not a valid postal code in %s", postcode, address.CountryName(block.Country))
+	}
+	if city == "" {
+		problem(AddressFieldCity, "no city")
+	}
+	if abroad {
+		city = strings.ToUpper(city)
+	}
+	switch rule.style {
+	case cityPostcode:
+		block.Lines = append(block.Lines, joinNonEmpty(city, postcode))
+	case cityThenPostcode:
+		block.Lines = append(block.Lines, city)
+		if postcode != "" {
+			block.Lines = append(block.Lines, postcode)
+		}
+	default:
+		block.Lines = append(block.Lines, joinNonEmpty(postcode, city))
+	}
+	if abroad {
+		block.Lines = append(block.Lines, strings.ToUpper(address.CountryName(block.Country)))
+	}
+
+	for _, line := range block.Lines {
+		if n := utf8.RuneCountInString(line); n > MaxAddressLineLength {
+			problem(AddressFieldBlock, "line %q has %d characters, at most %d fit the window", line, n, MaxAddressLineLength)
+		}
+	}
+	if len(block.Lines) > MaxAddressBlockLines {
+		problem(AddressFieldBlock, "%d lines, at most %d fit the address zone", len(block.Lines), MaxAddressBlockLines)
+	}
+	return block
+}
+
+// AddressBlock formats the preview receiver, see FormatAddressBlock.
+func (p *PreviewReceiver) AddressBlock(name []string) *AddressBlock {
+	if p == nil {
+		return FormatAddressBlock(name, nil)
+	}
+	return FormatAddressBlock(name, &Recipient{
+		AddressLine1: p.AddressLine1,
+		AddressLine2: p.AddressLine2,
+		City:         p.City,
+		Country:      p.Country,
+		PostalCode:   p.PostalCode,
+	})
+}
+
+// hasHouseNumber reports whether a line contains a number, e.g. a house
+// number or a PO box.
+func hasHouseNumber(line string) bool {
+	return strings.IndexFunc(line, unicode.IsDigit) >= 0
+}
+
+func joinNonEmpty(a, b string) string {
+	return strings.TrimSpace(a + " " + b)
+}
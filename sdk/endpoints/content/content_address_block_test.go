@@ -0,0 +1,78 @@
+package content_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestFormatAddressBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		recipient *content.Recipient
+		want      []string
+	}{
+		{
+			"germany",
+			&content.Recipient{AddressLine1: sdk.String("Hauptstraße 5A"), AddressLine2: sdk.String("c/o Müller"), PostalCode: sdk.String("12345"), City: sdk.String("Berlin"), Country: sdk.String("DE")},
+			[]string{"Max Mustermann", "c/o Müller", "Hauptstraße 5A", "12345 Berlin"},
+		},
+		{
+			"france",
+			&content.Recipient{AddressLine1: sdk.String("10 rue de Rivoli"), PostalCode: sdk.String("75001"), City: sdk.String("Paris"), Country: sdk.String("France")},
+			[]string{"Max Mustermann", "10 rue de Rivoli", "75001 PARIS", "FRANCE"},
+		},
+		{
+			"united kingdom",
+			&content.Recipient{AddressLine1: sdk.String("10 Downing Street"), PostalCode: sdk.String("SW1A 2AA"), City: sdk.String("London"), Country: sdk.String("GB")},
+			[]string{"Max Mustermann", "10 Downing Street", "LONDON", "SW1A 2AA", "UNITED KINGDOM"},
+		},
+		{
+			"japan",
+			&content.Recipient{AddressLine1: sdk.String("1-1 Chiyoda"), PostalCode: sdk.String("100-8111"), City: sdk.String("Tokyo"), Country: sdk.String("JP")},
+			[]string{"Max Mustermann", "1-1 Chiyoda", "100-8111 TOKYO", "JAPAN"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := content.FormatAddressBlock([]string{"Max  Mustermann"}, tt.recipient)
+			if !slices.Equal(block.Lines, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, block.Lines)
+			}
+			if !block.Valid() {
+				t.Errorf("Unexpected problems %v", block.Problems)
+			}
+		})
+	}
+}
+
+func TestFormatAddressBlockProblems(t *testing.T) {
+	block := content.FormatAddressBlock([]string{"Max Mustermann"}, &content.Recipient{
+		AddressLine1: sdk.String("Am Marktplatz"),
+		PostalCode:   sdk.String("1234"),
+		City:         sdk.String("Berlin"),
+	})
+	fields := map[string]bool{}
+	for _, p := range block.Problems {
+		fields[p.Field] = true
+	}
+	if !fields[content.AddressFieldStreet] || !fields[content.AddressFieldPostalCode] || len(block.Problems) != 2 {
+		t.Errorf("Expected a missing house number and an invalid postcode, got %v", block.Problems)
+	}
+
+	long := content.FormatAddressBlock([]string{"Gesellschaft für sehr lange Firmennamen mbH", "Abteilung 1", "Zentrale", "Empfang", "Frau Erika Mustermann"}, &content.Recipient{
+		AddressLine1: sdk.String("Hauptstraße 5"),
+		PostalCode:   sdk.String("12345"),
+		City:         sdk.String("Berlin"),
+	})
+	if len(long.Problems) != 2 || long.Problems[0].Field != content.AddressFieldBlock || long.Problems[1].Field != content.AddressFieldBlock {
+		t.Errorf("Expected a line and a block length problem, got %v", long.Problems)
+	}
+
+	preview := (&content.PreviewReceiver{AddressLine1: sdk.String("Hauptstraße 5"), PostalCode: sdk.String("12345"), City: sdk.String("Berlin"), Country: sdk.String("Atlantis")}).AddressBlock(nil)
+	if preview.Valid() || preview.Problems[0].Field != content.AddressFieldCountry {
+		t.Errorf("Expected an unknown country, got %v", preview.Problems)
+	}
+}
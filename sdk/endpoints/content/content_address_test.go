@@ -0,0 +1,49 @@
+package content_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/address"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestRecipientFromAddress(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/address/parse" {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+		writeJson(w, 200, map[string]any{"street": "Hauptstraße", "house_number": "5A", "postcode": "12345", "city": "Berlin", "country": "Deutschland"})
+	})
+	recipient, status, err := content.RecipientFromAddress(client, context.Background(), "Hauptstraße 5A, 12345 Berlin, Deutschland")
+	if err != nil || status.HttpStatus != 200 {
+		t.Fatalf("RecipientFromAddress failed: %v", err)
+	}
+	if *recipient.AddressLine1 != "Hauptstraße 5A" || *recipient.PostalCode != "12345" || *recipient.City != "Berlin" || *recipient.Country != "DE" {
+		t.Errorf("Unexpected recipient %+v", recipient)
+	}
+	preview := recipient.PreviewReceiver()
+	if preview.AddressLine1 != recipient.AddressLine1 || *preview.Country != "DE" {
+		t.Errorf("Unexpected preview receiver %+v", preview)
+	}
+}
+
+func TestRecipientFromAddressErrors(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 200, map[string]any{"street": "Hauptstraße", "postcode": "12345", "city": "Berlin", "country": "Atlantis"})
+	})
+	if _, _, err := content.RecipientFromAddress(client, context.Background(), "Hauptstraße, 12345 Berlin, Atlantis"); !errors.Is(err, address.ErrUnknownCountry) {
+		t.Errorf("Expected ErrUnknownCountry, got %v", err)
+	}
+
+	client = mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 400, api.ResponseError{Code: 40000, Message: "cannot parse"})
+	})
+	var statusErr *content.StatusError
+	if _, _, err := content.RecipientFromAddress(client, context.Background(), "???"); !errors.As(err, &statusErr) {
+		t.Errorf("Expected a StatusError, got %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/jsonl"
+)
+
+// States of an address book entry.
+const (
+	// ReceiverUnchecked means the receiver was not checked yet, or changed
+	// since the last check.
+	ReceiverUnchecked = "unchecked"
+	// ReceiverFound means the receiver is on Brifle.
+	ReceiverFound = "found"
+	// ReceiverNotFound means the receiver is not on Brifle.
+	ReceiverNotFound = "not_found"
+)
+
+// ErrUnknownCustomer is returned for customer ids without an address book
+// entry.
+var ErrUnknownCustomer = errors.New("customer is not in the address book")
+
+// AddressBookEntry is the receiver of a customer and the result of its last
+// check.
+type AddressBookEntry struct {
+	CustomerId string       `json:"customer_id"`
+	Receiver   ReceiverData `json:"receiver"`
+	// State is ReceiverUnchecked, ReceiverFound or ReceiverNotFound.
+	State string `json:"state"`
+	// ReceiverType is the type under which the receiver was found, e.g.
+	// "email" or "birth_info".
+	ReceiverType string    `json:"receiver_type,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Found reports whether the receiver was on Brifle at the last check.
+func (e *AddressBookEntry) Found() bool {
+	return e.State == ReceiverFound
+}
+
+// AddressBookStore persists address book entries. Implementations must be
+// safe for concurrent use.
+type AddressBookStore interface {
+	// Get returns the entry of customerId, or nil if there is none.
+	Get(customerId string) (*AddressBookEntry, error)
+	// Put stores entry, replacing an existing entry of the same customer.
+	Put(entry AddressBookEntry) error
+	// Update calls update with the current entry of customerId, or nil if
+	// there is none, and stores the entry it returns. No other change of
+	// the entry can happen in between. Nothing is stored if update returns
+	// nil. Update returns the entry of customerId afterwards.
+	Update(customerId string, update func(current *AddressBookEntry) *AddressBookEntry) (*AddressBookEntry, error)
+	// Delete removes the entry of customerId.
+	Delete(customerId string) error
+	// List returns all entries ordered by customer id.
+	List() ([]AddressBookEntry, error)
+}
+
+// AddressBook keeps the receivers of customers together with the result of
+// their last receiver check, so that a document can be sent without
+// checking the receiver every time. Results are revalidated after TTL,
+// receivers not found after NegativeTTL.
+//
+//	book := &content.AddressBook{Client: client, Store: store}
+//	_ = book.Add("customer-4711", receiver)
+//	entry, err := book.Check(ctx, "customer-4711")
+//	if err == nil && entry.Found() {
+//		res, respStatus, err := content.SendContent(client, ctx, &tenant, &req)
+//	}
+type AddressBook struct {
+	Client *sdkClient.BrifleClient
+	Store  AddressBookStore
+	// TTL is how long a found receiver is trusted. Defaults to 24 hours.
+	TTL time.Duration
+	// NegativeTTL is how long a receiver not found is trusted. Defaults to
+	// one hour, as customers may register in the meantime.
+	NegativeTTL time.Duration
+	// BatchSize is the number of receivers per CheckReceiverBulk request
+	// in Refresh. Defaults to 100.
+	BatchSize int
+}
+
+// Add stores the receiver of customerId. The result of the last check is
+// kept if the receiver did not change.
+func (b *AddressBook) Add(customerId string, receiver ReceiverData) error {
+	if customerId == "" {
+		return errors.New("customer id is required")
+	}
+	if buildReceiver(&receiver) == nil {
+		return errors.New("receiver data is invalid")
+	}
+	_, err := b.Store.Update(customerId, func(current *AddressBookEntry) *AddressBookEntry {
+		if current != nil && reflect.DeepEqual(current.Receiver, receiver) {
+			return nil
+		}
+		return &AddressBookEntry{CustomerId: customerId, Receiver: receiver, State: ReceiverUnchecked}
+	})
+	return err
+}
+
+// Remove deletes the entry of customerId.
+func (b *AddressBook) Remove(customerId string) error {
+	return b.Store.Delete(customerId)
+}
+
+// Fresh reports whether the last check of entry can be trusted at now.
+func (b *AddressBook) Fresh(entry *AddressBookEntry, now time.Time) bool {
+	var ttl time.Duration
+	switch entry.State {
+	case ReceiverFound:
+		ttl = b.TTL
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+	case ReceiverNotFound:
+		ttl = b.NegativeTTL
+		if ttl <= 0 {
+			ttl = time.Hour
+		}
+	default:
+		return false
+	}
+	return now.Before(entry.CheckedAt.Add(ttl))
+}
+
+// Check returns the entry of customerId and checks its receiver with
+// CheckReceiver unless the last check is fresh. If the check fails, the
+// stale entry is returned together with the error; errors of the API are
+// returned as *StatusError. If the receiver was changed during the check,
+// the result is discarded and the changed entry is returned.
+func (b *AddressBook) Check(ctx context.Context, customerId string) (*AddressBookEntry, error) {
+	entry, err := b.Store.Get(customerId)
+	if err != nil {
+		return nil, fmt.Errorf("reading address book: %w", err)
+	}
+	if entry == nil {
+		return nil, ErrUnknownCustomer
+	}
+	if b.Fresh(entry, time.Now()) {
+		return entry, nil
+	}
+
+	res, status, err := CheckReceiver(b.Client, ctx, &entry.Receiver)
+	if err != nil {
+		return entry, err
+	}
+	var state, receiverType string
+	switch {
+	case statusOk(status) && res != nil && res.ReceiverExistResponse != nil && res.Receiver != nil && res.Receiver.Type != nil:
+		state, receiverType = ReceiverFound, string(*res.Receiver.Type)
+	case receiverNotFound(status):
+		state = ReceiverNotFound
+	default:
+		return entry, &StatusError{Operation: "check receiver", Status: status}
+	}
+	updated, err := b.Store.Update(customerId, checkedEntry(entry.Receiver, state, receiverType, time.Now().UTC()))
+	if err != nil {
+		return entry, fmt.Errorf("writing address book: %w", err)
+	}
+	if updated == nil {
+		return nil, ErrUnknownCustomer
+	}
+	return updated, nil
+}
+
+// checkedEntry returns an update that stores the result of a check of
+// receiver, unless the entry was removed or its receiver changed in the
+// meantime.
+func checkedEntry(receiver ReceiverData, state, receiverType string, checkedAt time.Time) func(*AddressBookEntry) *AddressBookEntry {
+	return func(current *AddressBookEntry) *AddressBookEntry {
+		if current == nil || !reflect.DeepEqual(current.Receiver, receiver) {
+			return nil
+		}
+		checked := *current
+		checked.State, checked.ReceiverType, checked.CheckedAt = state, receiverType, checkedAt
+		return &checked
+	}
+}
+
+// Refresh checks the receivers of all entries whose last check is not
+// fresh with a ReceiverChecker, BatchSize receivers per request. It returns
+// the number of entries checked; entries of failed requests are left as
+// they are and their errors are returned. Entries changed or removed during
+// the check are left as they are and not counted.
+func (b *AddressBook) Refresh(ctx context.Context) (int, error) {
+	entries, err := b.Store.List()
+	if err != nil {
+		return 0, fmt.Errorf("reading address book: %w", err)
+	}
+	now := time.Now()
+	stale := entries[:0]
+	for _, entry := range entries {
+		if !b.Fresh(&entry, now) {
+			stale = append(stale, entry)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	receivers := make([]ReceiverData, len(stale))
+	for i, entry := range stale {
+		receivers[i] = entry.Receiver
+	}
+	checker := &ReceiverChecker{Client: b.Client, ChunkSize: b.BatchSize}
+	results, checkErr := checker.Check(ctx, receivers)
+	if results == nil {
+		// the check could not run at all, e.g. without a client
+		return 0, checkErr
+	}
+	checkedAt := time.Now().UTC()
+	checked := 0
+	for i, entry := range stale {
+		res := results[i]
+		if res.Err != nil {
+			continue
+		}
+		state, receiverType := ReceiverNotFound, ""
+		if res.Found {
+			state, receiverType = ReceiverFound, res.ReceiverType
+		}
+		stored := false
+		update := checkedEntry(entry.Receiver, state, receiverType, checkedAt)
+		_, err := b.Store.Update(entry.CustomerId, func(current *AddressBookEntry) *AddressBookEntry {
+			next := update(current)
+			stored = next != nil
+			return next
+		})
+		if err != nil {
+			return checked, errors.Join(checkErr, fmt.Errorf("writing address book: %w", err))
+		}
+		if stored {
+			checked++
+		}
+	}
+	return checked, checkErr
+}
+
+// MemoryAddressBookStore keeps address book entries in memory.
+type MemoryAddressBookStore struct {
+	mu      sync.Mutex
+	entries map[string]AddressBookEntry
+}
+
+// NewMemoryAddressBookStore creates an empty in-memory store.
+func NewMemoryAddressBookStore() *MemoryAddressBookStore {
+	return &MemoryAddressBookStore{entries: map[string]AddressBookEntry{}}
+}
+
+// Get returns the entry of customerId, or nil if there is none.
+func (m *MemoryAddressBookStore) Get(customerId string) (*AddressBookEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[customerId]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Put stores entry.
+func (m *MemoryAddressBookStore) Put(entry AddressBookEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[entry.CustomerId] = entry
+	return nil
+}
+
+// Update replaces the entry of customerId with the result of update.
+func (m *MemoryAddressBookStore) Update(customerId string, update func(current *AddressBookEntry) *AddressBookEntry) (*AddressBookEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var current *AddressBookEntry
+	if entry, ok := m.entries[customerId]; ok {
+		current = &entry
+	}
+	next := update(current)
+	if next == nil {
+		return current, nil
+	}
+	next.CustomerId = customerId
+	m.entries[customerId] = *next
+	return next, nil
+}
+
+// Delete removes the entry of customerId.
+func (m *MemoryAddressBookStore) Delete(customerId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, customerId)
+	return nil
+}
+
+// List returns all entries ordered by customer id.
+func (m *MemoryAddressBookStore) List() ([]AddressBookEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]AddressBookEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CustomerId < entries[j].CustomerId })
+	return entries, nil
+}
+
+// FileAddressBookStore is an AddressBookStore kept in memory and persisted
+// as JSON lines in a local file. Every change is synced to disk before it
+// returns. The file is compacted when it holds mostly replaced entries.
+type FileAddressBookStore struct {
+	memory MemoryAddressBookStore
+	file   *jsonl.File
+}
+
+// OpenFileAddressBookStore opens or creates the store file at path.
+func OpenFileAddressBookStore(path string) (*FileAddressBookStore, error) {
+	store := &FileAddressBookStore{memory: MemoryAddressBookStore{entries: map[string]AddressBookEntry{}}}
+	file, err := jsonl.Open(path, func(_ int, data []byte) error {
+		var entry AddressBookEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		// an entry without state marks a deleted customer
+		if entry.State == "" {
+			delete(store.memory.entries, entry.CustomerId)
+		} else {
+			store.memory.entries[entry.CustomerId] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("address book: %w", err)
+	}
+	store.file = file
+	return store, nil
+}
+
+// Get returns the entry of customerId, or nil if there is none.
+func (f *FileAddressBookStore) Get(customerId string) (*AddressBookEntry, error) {
+	return f.memory.Get(customerId)
+}
+
+// List returns all entries ordered by customer id.
+func (f *FileAddressBookStore) List() ([]AddressBookEntry, error) {
+	return f.memory.List()
+}
+
+// Put appends entry to the file and syncs it to disk.
+func (f *FileAddressBookStore) Put(entry AddressBookEntry) error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.put(entry)
+}
+
+// Update replaces the entry of customerId with the result of update and
+// syncs it to disk.
+func (f *FileAddressBookStore) Update(customerId string, update func(current *AddressBookEntry) *AddressBookEntry) (*AddressBookEntry, error) {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	var current *AddressBookEntry
+	if entry, ok := f.memory.entries[customerId]; ok {
+		current = &entry
+	}
+	next := update(current)
+	if next == nil {
+		return current, nil
+	}
+	next.CustomerId = customerId
+	if err := f.put(*next); err != nil {
+		return current, err
+	}
+	return next, nil
+}
+
+// Delete appends a deletion marker for customerId to the file and syncs it
+// to disk.
+func (f *FileAddressBookStore) Delete(customerId string) error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	if _, ok := f.memory.entries[customerId]; !ok {
+		return nil
+	}
+	if err := f.file.Append(AddressBookEntry{CustomerId: customerId}); err != nil {
+		return err
+	}
+	delete(f.memory.entries, customerId)
+	_ = f.compact(false)
+	return nil
+}
+
+// Compact rewrites the file with the current entries only.
+func (f *FileAddressBookStore) Compact() error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.compact(true)
+}
+
+// Close closes the store file.
+func (f *FileAddressBookStore) Close() error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.file.Close()
+}
+
+func (f *FileAddressBookStore) put(entry AddressBookEntry) error {
+	if entry.State == "" {
+		return errors.New("entry state is required")
+	}
+	if err := f.file.Append(entry); err != nil {
+		return err
+	}
+	f.memory.entries[entry.CustomerId] = entry
+	// the entry is stored; a failed compaction is retried with the next change
+	_ = f.compact(false)
+	return nil
+}
+
+func (f *FileAddressBookStore) compact(force bool) error {
+	if !force && !f.file.NeedsCompaction(len(f.memory.entries)) {
+		return nil
+	}
+	keys := make([]string, 0, len(f.memory.entries))
+	for key := range f.memory.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]any, len(keys))
+	for i, key := range keys {
+		entries[i] = f.memory.entries[key]
+	}
+	return f.file.Rewrite(entries)
+}
@@ -0,0 +1,221 @@
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func emailReceiver(email string) content.ReceiverData {
+	return content.ReceiverData{Email: &content.EmailReceiver{Email: sdk.String(email)}}
+}
+
+// countingReceiverServer is a receiverServer counting requests by path.
+func countingReceiverServer(t *testing.T) (http.HandlerFunc, func(path string) int) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	handler := receiverServer(t)
+	return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls[r.URL.Path]++
+			mu.Unlock()
+			handler(w, r)
+		}, func(path string) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return calls[path]
+		}
+}
+
+func TestAddressBookCheck(t *testing.T) {
+	handler, calls := countingReceiverServer(t)
+	store := content.NewMemoryAddressBookStore()
+	book := &content.AddressBook{Client: mockClient(t, handler), Store: store}
+	ctx := context.Background()
+	for id, email := range map[string]string{"1": "on@example.com", "2": "off@example.com", "3": "down@example.com"} {
+		if err := book.Add(id, emailReceiver(email)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		entry, err := book.Check(ctx, "1")
+		if err != nil || !entry.Found() || entry.ReceiverType != "email" {
+			t.Fatalf("Expected a found receiver, got %+v, %v", entry, err)
+		}
+		entry, err = book.Check(ctx, "2")
+		if err != nil || entry.State != content.ReceiverNotFound {
+			t.Fatalf("Expected a receiver not found, got %+v, %v", entry, err)
+		}
+	}
+	if n := calls("/v1/content/receiver/check"); n != 2 {
+		t.Errorf("Expected cached results to be reused, got %d checks", n)
+	}
+
+	entry, err := book.Check(ctx, "3")
+	var statusErr *content.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status.HttpStatus != 503 || entry.State != content.ReceiverUnchecked {
+		t.Errorf("Expected a failed check, got %+v, %v", entry, err)
+	}
+	if _, err := book.Check(ctx, "4"); !errors.Is(err, content.ErrUnknownCustomer) {
+		t.Errorf("Expected ErrUnknownCustomer, got %v", err)
+	}
+
+	// a not found result expires after NegativeTTL
+	stale, _ := store.Get("2")
+	stale.CheckedAt = time.Now().Add(-2 * time.Hour)
+	_ = store.Put(*stale)
+	if _, err := book.Check(ctx, "2"); err != nil || calls("/v1/content/receiver/check") != 4 {
+		t.Errorf("Expected the expired entry to be checked again, got %d checks, %v", calls("/v1/content/receiver/check"), err)
+	}
+
+	// a changed receiver is checked again
+	_ = book.Add("1", emailReceiver("on@example.com"))
+	if entry, _ := store.Get("1"); !entry.Found() {
+		t.Errorf("Expected an unchanged receiver to keep its result, got %+v", entry)
+	}
+	_ = book.Add("1", emailReceiver("off@example.com"))
+	if entry, _ := store.Get("1"); entry.State != content.ReceiverUnchecked {
+		t.Errorf("Expected a changed receiver to be unchecked, got %+v", entry)
+	}
+}
+
+func TestAddressBookRefresh(t *testing.T) {
+	handler, calls := countingReceiverServer(t)
+	store := content.NewMemoryAddressBookStore()
+	book := &content.AddressBook{Client: mockClient(t, handler), Store: store, BatchSize: 2}
+	for id, email := range map[string]string{"a": "on-a@example.com", "b": "off@example.com", "c": "on-c@example.com", "d": "off@example.com"} {
+		_ = book.Add(id, emailReceiver(email))
+	}
+	_ = store.Put(content.AddressBookEntry{CustomerId: "e", Receiver: emailReceiver("on-e@example.com"), State: content.ReceiverFound, ReceiverType: "email", CheckedAt: time.Now()})
+
+	n, err := book.Refresh(context.Background())
+	if err != nil || n != 4 {
+		t.Fatalf("Expected 4 refreshed entries, got %d, %v", n, err)
+	}
+	if c := calls("/v1/content/receiver/check/bulk"); c != 2 {
+		t.Errorf("Expected 2 bulk requests, got %d", c)
+	}
+	entries, _ := store.List()
+	want := map[string]string{"a": content.ReceiverFound, "b": content.ReceiverNotFound, "c": content.ReceiverFound, "d": content.ReceiverNotFound, "e": content.ReceiverFound}
+	for _, entry := range entries {
+		if entry.State != want[entry.CustomerId] {
+			t.Errorf("Unexpected state of %s: %+v", entry.CustomerId, entry)
+		}
+	}
+
+	if n, err := book.Refresh(context.Background()); err != nil || n != 0 {
+		t.Errorf("Expected nothing to refresh, got %d, %v", n, err)
+	}
+
+	// without a client the check fails as a whole
+	_ = book.Add("f", emailReceiver("on-f@example.com"))
+	book.Client = nil
+	if n, err := book.Refresh(context.Background()); err == nil || n != 0 {
+		t.Errorf("Expected an error and nothing refreshed, got %d, %v", n, err)
+	}
+	if entry, _ := store.Get("f"); entry.State != content.ReceiverUnchecked {
+		t.Errorf("Expected f to stay unchecked, got %+v", entry)
+	}
+}
+
+func TestAddressBookConcurrentAdd(t *testing.T) {
+	store := content.NewMemoryAddressBookStore()
+	book := &content.AddressBook{Store: store}
+	handler := receiverServer(t)
+	changes := 0
+	book.Client = mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// the customer changes the email address while the old one is checked
+		changes++
+		if err := book.Add("1", emailReceiver(fmt.Sprintf("on-%d@example.com", changes))); err != nil {
+			t.Error(err)
+		}
+		handler(w, r)
+	})
+	if err := book.Add("1", emailReceiver("on@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := book.Check(context.Background(), "1")
+	if err != nil || entry.State != content.ReceiverUnchecked || *entry.Receiver.Email.Email != "on-1@example.com" {
+		t.Errorf("Expected the changed entry, got %+v, %v", entry, err)
+	}
+	n, err := book.Refresh(context.Background())
+	if err != nil || n != 0 {
+		t.Errorf("Expected the changed entry not to be refreshed, got %d, %v", n, err)
+	}
+	if stored, _ := store.Get("1"); stored.State != content.ReceiverUnchecked || *stored.Receiver.Email.Email != "on-2@example.com" {
+		t.Errorf("Expected the added receiver to be kept, got %+v", stored)
+	}
+}
+
+func lines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileAddressBookStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addressbook.jsonl")
+	store, err := content.OpenFileAddressBookStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkedAt := time.Date(2024, 8, 15, 10, 0, 0, 0, time.UTC)
+	_ = store.Put(content.AddressBookEntry{CustomerId: "1", Receiver: emailReceiver("on@example.com"), State: content.ReceiverUnchecked})
+	_ = store.Put(content.AddressBookEntry{CustomerId: "1", Receiver: emailReceiver("on@example.com"), State: content.ReceiverFound, ReceiverType: "email", CheckedAt: checkedAt})
+	_ = store.Put(content.AddressBookEntry{CustomerId: "2", Receiver: emailReceiver("off@example.com"), State: content.ReceiverNotFound, CheckedAt: checkedAt})
+	_ = store.Delete("2")
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = content.OpenFileAddressBookStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	entries, _ := store.List()
+	if len(entries) != 1 || entries[0].State != content.ReceiverFound || !entries[0].CheckedAt.Equal(checkedAt) || *entries[0].Receiver.Email.Email != "on@example.com" {
+		t.Errorf("Unexpected entries after reopening: %+v", entries)
+	}
+
+	// replaced entries are compacted away
+	for i := 0; i < 1500; i++ {
+		if err := store.Put(content.AddressBookEntry{CustomerId: "3", Receiver: emailReceiver("on@example.com"), State: content.ReceiverFound, CheckedAt: checkedAt.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := lines(t, path); n >= 1000 {
+		t.Errorf("Expected the file to be compacted, got %d lines", n)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Delete("1")
+	if n := lines(t, path); n != 3 {
+		t.Errorf("Expected 2 entries and a deletion marker, got %d lines", n)
+	}
+	_ = store.Close()
+
+	store, err = content.OpenFileAddressBookStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = store.List()
+	if len(entries) != 1 || entries[0].CustomerId != "3" || !entries[0].CheckedAt.Equal(checkedAt.Add(1499*time.Second)) {
+		t.Errorf("Unexpected entries after compaction: %+v", entries)
+	}
+}
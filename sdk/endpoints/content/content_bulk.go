@@ -0,0 +1,362 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/ratelimit"
+)
+
+// Outcomes of a bulk send job.
+const (
+	// BulkSent means the document was accepted in this run.
+	BulkSent = "sent"
+	// BulkSkipped means the journal shows the job was sent in an earlier run.
+	BulkSkipped = "skipped"
+	// BulkFailed means the job failed permanently or ran out of attempts.
+	BulkFailed = "failed"
+	// BulkUnknown means the job may or may not have been delivered: the
+	// request failed after it may have reached the server, or an earlier run
+	// was interrupted while sending it. It is not resent automatically;
+	// check the outbox before sending it again.
+	BulkUnknown = "unknown"
+)
+
+// BulkJob is a single document to send with a BulkSender.
+type BulkJob struct {
+	// Key identifies the job across runs, e.g. an invoice number. It is
+	// required when the sender has a journal and must be unique per run.
+	Key string
+	// Request is the document to send.
+	Request *SendContentRequest
+}
+
+// BulkResult is the outcome of a single job.
+type BulkResult struct {
+	// Index is the position of the job in the input stream.
+	Index int
+	Key   string
+	// Status is BulkSent, BulkSkipped, BulkFailed or BulkUnknown.
+	Status     string
+	DocumentId string
+	HttpStatus int
+	ErrorCode  int
+	// ErrorMessage is the message of the last Brifle error response.
+	ErrorMessage string
+	// Retryable reports whether the last failure was transient, i.e. the job
+	// may succeed when sent again later. It is always false for BulkUnknown:
+	// the document may have been sent, so sending it again could deliver it
+	// twice. Look it up, e.g. with an IdempotentSender, before sending again.
+	Retryable bool
+	Attempts  int
+	Err       error
+}
+
+// BulkReport lists the results of a run ordered by input index.
+type BulkReport struct {
+	Results []BulkResult
+	Sent    int
+	Skipped int
+	Failed  int
+	Unknown int
+}
+
+// BulkSender sends many documents with bounded concurrency, rate limiting and
+// retries of failures that provably did not reach the server. With a Journal,
+// an interrupted run can be started again with the same jobs: documents that
+// were already sent are skipped instead of being sent twice.
+type BulkSender struct {
+	Client *sdkClient.BrifleClient
+	Tenant string
+	// Concurrency is the number of parallel requests. Defaults to 4.
+	Concurrency int
+	// RatePerSecond limits the number of requests per second. 0 means
+	// unlimited; rates above one per nanosecond are not limited further.
+	RatePerSecond float64
+	// MaxAttempts per job including the first one. Defaults to 3.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// further attempt. Defaults to one second.
+	Backoff time.Duration
+	// Journal records the progress of every job. Optional.
+	Journal Journal
+	// OnResult is called for every finished job. It is called from multiple
+	// goroutines. Optional.
+	OnResult func(BulkResult)
+}
+
+// IsRetryableSendFailure classifies the outcome of SendContent. Transport
+// errors, timeouts, rate limiting (429) and server errors (5xx) are
+// transient; invalid requests and Brifle errors such as receiver not found
+// (40401) or invalid IBAN (42203) are permanent.
+//
+// Sending a document again after a transient failure may deliver it twice,
+// unless the failure is one of api.IsUnsentFailure.
+func IsRetryableSendFailure(status *api.ResponseStatus, err error) bool {
+	if err == nil && status != nil {
+		switch status.ErrorCode {
+		case api.ErrorCodeReceiverNotFound, api.ErrorCodeInvalidIban:
+			return false
+		}
+	}
+	return api.IsTransientFailure(status, err)
+}
+
+// sendOutcomeUnknown reports whether a failed SendContent of a valid request
+// may have been accepted by the server.
+func sendOutcomeUnknown(status *api.ResponseStatus, err error) bool {
+	if api.IsUnsentFailure(status, err) {
+		return false
+	}
+	// errors of valid requests occur after the request was made, e.g. a
+	// timeout or an unreadable response
+	return err != nil || status == nil ||
+		status.HttpStatus == http.StatusRequestTimeout || status.HttpStatus >= 500
+}
+
+// Run sends every job received from jobs until the channel is closed or ctx
+// ends, and returns the report of all jobs handled. When ctx ends, jobs that
+// have not been started are not part of the report and Run returns the
+// context's error.
+//
+//	jobs := make(chan content.BulkJob)
+//	go func() {
+//		defer close(jobs)
+//		for _, inv := range invoices {
+//			jobs <- content.BulkJob{Key: inv.Number, Request: inv.Request()}
+//		}
+//	}()
+//	report, err := sender.Run(ctx, jobs)
+func (s *BulkSender) Run(ctx context.Context, jobs <-chan BulkJob) (*BulkReport, error) {
+	if s.Client == nil {
+		return nil, errors.New("client is required")
+	}
+	if s.Tenant == "" {
+		return nil, errors.New("tenant is required")
+	}
+
+	previous := map[string]JournalEntry{}
+	if s.Journal != nil {
+		loaded, err := s.Journal.Load()
+		if err != nil {
+			return nil, fmt.Errorf("loading journal: %w", err)
+		}
+		previous = loaded
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	limiter, stop := ratelimit.Start(s.RatePerSecond)
+	defer stop()
+
+	type indexedJob struct {
+		index int
+		job   BulkJob
+	}
+	work := make(chan indexedJob)
+	var (
+		mu      sync.Mutex
+		results []BulkResult
+		wg      sync.WaitGroup
+	)
+	record := func(r BulkResult) {
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+		if s.OnResult != nil {
+			s.OnResult(r)
+		}
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range work {
+				record(s.send(ctx, w.index, w.job, limiter))
+			}
+		}()
+	}
+
+	seen := map[string]bool{}
+	index := 0
+feed:
+	for {
+		select {
+		case <-ctx.Done():
+			break feed
+		case job, ok := <-jobs:
+			if !ok {
+				break feed
+			}
+			i := index
+			index++
+			if job.Key == "" && s.Journal == nil {
+				job.Key = strconv.Itoa(i)
+			}
+
+			switch {
+			case job.Key == "":
+				record(BulkResult{Index: i, Status: BulkFailed, Err: errors.New("job key is required with a journal")})
+				continue
+			case seen[job.Key]:
+				record(BulkResult{Index: i, Key: job.Key, Status: BulkFailed, Err: fmt.Errorf("duplicate job key %q", job.Key)})
+				continue
+			}
+			seen[job.Key] = true
+
+			if entry, ok := previous[job.Key]; ok {
+				switch entry.State {
+				case JournalSent:
+					record(BulkResult{Index: i, Key: job.Key, Status: BulkSkipped, DocumentId: entry.DocumentId})
+					continue
+				case JournalStarted:
+					record(BulkResult{Index: i, Key: job.Key, Status: BulkUnknown,
+						Err: errors.New("an earlier run was interrupted while sending this document")})
+					continue
+				case JournalUnknown:
+					record(BulkResult{Index: i, Key: job.Key, Status: BulkUnknown,
+						Err: fmt.Errorf("an earlier run may have sent this document: %s", entry.Error)})
+					continue
+				}
+			}
+
+			select {
+			case work <- indexedJob{index: i, job: job}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Index < results[b].Index })
+	report := &BulkReport{Results: results}
+	for _, r := range results {
+		switch r.Status {
+		case BulkSent:
+			report.Sent++
+		case BulkSkipped:
+			report.Skipped++
+		case BulkFailed:
+			report.Failed++
+		case BulkUnknown:
+			report.Unknown++
+		}
+	}
+	return report, ctx.Err()
+}
+
+// send runs a single job. Only failures that provably did not reach the
+// server are retried; failures after which the document may have been
+// accepted end the job as BulkUnknown.
+func (s *BulkSender) send(ctx context.Context, index int, job BulkJob, limiter <-chan time.Time) BulkResult {
+	result := BulkResult{Index: index, Key: job.Key, Status: BulkFailed}
+	if err := validateSendRequest(job.Request); err != nil {
+		result.Err = err
+		return result
+	}
+
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		if limiter != nil {
+			select {
+			case <-limiter:
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return s.failed(result)
+			}
+		}
+
+		result.Attempts = attempt
+		if err := s.journal(JournalEntry{Key: job.Key, State: JournalStarted, Attempt: attempt}); err != nil {
+			result.Err = err
+			return result
+		}
+
+		tenant := s.Tenant
+		res, status, err := SendContent(s.Client, ctx, &tenant, job.Request)
+		result.Err = err
+		result.Retryable = IsRetryableSendFailure(status, err)
+		if status != nil {
+			result.HttpStatus = status.HttpStatus
+			result.ErrorCode = status.ErrorCode
+			result.ErrorMessage = status.Message()
+		}
+
+		if err == nil && status != nil && status.HttpStatus >= 200 && status.HttpStatus < 300 {
+			result.Status = BulkSent
+			if res != nil && res.ContentCreateResponse != nil && res.Id != nil {
+				result.DocumentId = *res.Id
+			}
+			result.Err = s.journal(JournalEntry{Key: job.Key, State: JournalSent, DocumentId: result.DocumentId, Attempt: attempt})
+			return result
+		}
+		if err == nil {
+			result.Err = fmt.Errorf("brifle error %d (http %d)", result.ErrorCode, result.HttpStatus)
+		}
+
+		if sendOutcomeUnknown(status, err) {
+			result.Status = BulkUnknown
+			result.Retryable = false
+			if jErr := s.journal(s.entry(result, JournalUnknown)); jErr != nil {
+				result.Err = errors.Join(result.Err, jErr)
+			}
+			return result
+		}
+		if !api.IsUnsentFailure(status, err) || attempt == maxAttempts {
+			return s.failed(result)
+		}
+		select {
+		case <-time.After(backoff << (attempt - 1)):
+		case <-ctx.Done():
+			return s.failed(result)
+		}
+	}
+}
+
+// failed journals a job that was not delivered.
+func (s *BulkSender) failed(result BulkResult) BulkResult {
+	if err := s.journal(s.entry(result, JournalFailed)); err != nil {
+		result.Err = errors.Join(result.Err, err)
+	}
+	return result
+}
+
+func (s *BulkSender) entry(result BulkResult, state string) JournalEntry {
+	entry := JournalEntry{Key: result.Key, State: state, HttpStatus: result.HttpStatus, ErrorCode: result.ErrorCode, Attempt: result.Attempts}
+	if result.Err != nil {
+		entry.Error = result.Err.Error()
+	}
+	return entry
+}
+
+func (s *BulkSender) journal(entry JournalEntry) error {
+	if s.Journal == nil {
+		return nil
+	}
+	entry.Time = time.Now().UTC()
+	if err := s.Journal.Record(entry); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package content_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+// mockClient returns a client talking to an in-memory server.
+func mockClient(t *testing.T, handler http.HandlerFunc) *client.BrifleClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	apiClient, err := api.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client.BrifleClient{ApiClient: apiClient}
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// sendServer answers send requests depending on the subject: "unknown"
+// receivers do not exist, "flaky" is rate limited once, "busy" fails with
+// 503, all others succeed with the subject as document id.
+func sendServer(t *testing.T, calls map[string]int) *client.BrifleClient {
+	var mu sync.Mutex
+	return mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req api.ApiSendContentSendContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJson(w, 400, api.ResponseError{Code: 40000})
+			return
+		}
+		mu.Lock()
+		calls[req.Subject]++
+		n := calls[req.Subject]
+		mu.Unlock()
+		switch {
+		case req.Subject == "unknown":
+			writeJson(w, 404, api.ResponseError{Code: api.ErrorCodeReceiverNotFound, Message: "receiver not found"})
+		case req.Subject == "flaky" && n == 1:
+			writeJson(w, 429, api.ResponseError{Code: 42900})
+		case req.Subject == "busy":
+			writeJson(w, 503, api.ResponseError{Code: 50000})
+		default:
+			writeJson(w, 200, api.ContentCreateResponse{Id: sdk.String("doc-" + req.Subject)})
+		}
+	})
+}
+
+func bulkJob(key, subject string) content.BulkJob {
+	return content.BulkJob{
+		Key: key,
+		Request: &content.SendContentRequest{
+			To:      &content.ReceiverData{Email: &content.EmailReceiver{Email: sdk.String("max@example.com")}},
+			Type:    sdk.String(content.Letter),
+			Subject: sdk.String(subject),
+			Body:    &[]content.ContentItem{{Content: sdk.String("JVBERi0="), Type: sdk.String("application/pdf")}},
+		},
+	}
+}
+
+func feed(jobs ...content.BulkJob) <-chan content.BulkJob {
+	ch := make(chan content.BulkJob, len(jobs))
+	for _, j := range jobs {
+		ch <- j
+	}
+	close(ch)
+	return ch
+}
+
+func TestBulkSenderRun(t *testing.T) {
+	calls := map[string]int{}
+	sender := content.BulkSender{
+		Client:  sendServer(t, calls),
+		Tenant:  "tenant",
+		Backoff: time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	report, err := sender.Run(ctx, feed(bulkJob("1", "a"), bulkJob("2", "unknown"), bulkJob("3", "flaky"), bulkJob("3", "dup"), bulkJob("4", "busy")))
+	if err != nil {
+		t.Errorf("Run failed: %v", err)
+		return
+	}
+	if report.Sent != 2 || report.Failed != 2 || report.Unknown != 1 {
+		t.Errorf("Expected 2 sent, 2 failed and 1 unknown, got %+v", report)
+		return
+	}
+
+	unknown := report.Results[1]
+	if unknown.Retryable || unknown.ErrorCode != api.ErrorCodeReceiverNotFound || unknown.Attempts != 1 {
+		t.Errorf("Expected a permanent receiver not found failure, got %+v", unknown)
+	}
+	flaky := report.Results[2]
+	if flaky.Status != content.BulkSent || flaky.Attempts != 2 || flaky.DocumentId != "doc-flaky" {
+		t.Errorf("Expected the flaky job to succeed on retry, got %+v", flaky)
+	}
+	if report.Results[3].Status != content.BulkFailed {
+		t.Error("Expected duplicate keys to be rejected")
+	}
+	// the server may have stored the document before failing
+	busy := report.Results[4]
+	if busy.Status != content.BulkUnknown || busy.Attempts != 1 || busy.Retryable || calls["busy"] != 1 {
+		t.Errorf("Expected the busy job to be unknown without a retry, got %+v", busy)
+	}
+}
+
+func TestBulkSenderRateLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	// rates beyond one per nanosecond must not panic
+	for _, rate := range []float64{2e9, math.Inf(1), 1e-30} {
+		calls := map[string]int{}
+		sender := content.BulkSender{Client: sendServer(t, calls), Tenant: "tenant", RatePerSecond: rate}
+		if rate < 1 {
+			// the first tick never arrives
+			short, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+			report, err := sender.Run(short, feed(bulkJob("1", "a")))
+			stop()
+			if !errors.Is(err, context.DeadlineExceeded) || report == nil || report.Sent != 0 {
+				t.Errorf("%g: expected nothing to be sent, got %+v: %v", rate, report, err)
+			}
+			continue
+		}
+		report, err := sender.Run(ctx, feed(bulkJob("1", "a"), bulkJob("2", "b")))
+		if err != nil || report.Sent != 2 {
+			t.Errorf("%g: expected 2 sent, got %+v: %v", rate, report, err)
+		}
+	}
+}
+
+func TestBulkSenderUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	apiClient, err := api.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	journal, err := content.OpenFileJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	sender := content.BulkSender{Client: &client.BrifleClient{ApiClient: apiClient}, Tenant: "tenant", Backoff: time.Millisecond, Journal: journal}
+	report, err := sender.Run(context.Background(), feed(bulkJob("1", "a")))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// the connection was refused, so nothing can have been delivered
+	if r := report.Results[0]; r.Status != content.BulkFailed || r.Attempts != 3 || !r.Retryable {
+		t.Errorf("Expected a retried connection failure, got %+v", r)
+	}
+	if entries, _ := journal.Load(); entries["1"].State != content.JournalFailed {
+		t.Errorf("Expected the job to be journaled as failed, got %+v", entries["1"])
+	}
+}
+
+func TestBulkSenderResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := content.OpenFileJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a previous run sent job 1 and was interrupted while sending job 2
+	_ = journal.Record(content.JournalEntry{Key: "1", State: content.JournalSent, DocumentId: "doc-a"})
+	_ = journal.Record(content.JournalEntry{Key: "2", State: content.JournalStarted})
+	_ = journal.Record(content.JournalEntry{Key: "4", State: content.JournalUnknown, HttpStatus: 504})
+	_ = journal.Close()
+
+	journal, err = content.OpenFileJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	calls := map[string]int{}
+	sender := content.BulkSender{Client: sendServer(t, calls), Tenant: "tenant", Journal: journal}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	report, err := sender.Run(ctx, feed(bulkJob("1", "a"), bulkJob("2", "b"), bulkJob("3", "c"), bulkJob("4", "d")))
+	if err != nil {
+		t.Errorf("Run failed: %v", err)
+		return
+	}
+	if report.Skipped != 1 || report.Unknown != 2 || report.Sent != 1 {
+		t.Errorf("Expected 1 skipped, 2 unknown and 1 sent, got %+v", report)
+	}
+	if calls["a"] != 0 || calls["b"] != 0 || calls["c"] != 1 || calls["d"] != 0 {
+		t.Errorf("Expected only job 3 to be sent, got %v", calls)
+	}
+
+	entries, err := journal.Load()
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+	if entries["3"].State != content.JournalSent || entries["3"].DocumentId != "doc-c" {
+		t.Errorf("Expected job 3 to be journaled as sent, got %+v", entries["3"])
+	}
+
+	if err := journal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	compacted, err := journal.Load()
+	if err != nil || len(compacted) != 4 || compacted["3"] != entries["3"] || lines(t, path) != 4 {
+		t.Errorf("Expected the latest entry of 4 jobs, got %+v, %v", compacted, err)
+	}
+	_ = journal.Record(content.JournalEntry{Key: "5", State: content.JournalStarted})
+	if entries, _ := journal.Load(); entries["5"].State != content.JournalStarted {
+		t.Errorf("Expected to record after compaction, got %+v", entries)
+	}
+}
@@ -0,0 +1,429 @@
+package content
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+	"golang.org/x/image/tiff"
+)
+
+// MIME types accepted by ConvertToPdf. Only MimeTypePdf can be sent to the
+// Brifle API directly.
+const (
+	MimeTypePdf  = "application/pdf"
+	MimeTypeJpeg = "image/jpeg"
+	MimeTypePng  = "image/png"
+	MimeTypeGif  = "image/gif"
+	MimeTypeTiff = "image/tiff"
+	MimeTypeText = "text/plain"
+)
+
+// Page orientations used by ConvertOptions.
+const (
+	OrientationAuto      = "auto"
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+)
+
+// ConvertOptions controls the page layout of ImagesToPdf and TextToPdf. All
+// fields are optional.
+type ConvertOptions struct {
+	// Orientation of the A4 pages. OrientationAuto (default) picks landscape
+	// pages for images that are wider than high and portrait otherwise.
+	// Text is always laid out in portrait unless OrientationLandscape is set.
+	Orientation string
+	// MarginMm is the blank border around the content in millimetres.
+	// Defaults to 10 for images and 20 for text.
+	MarginMm float64
+	// FontSize of converted text in points. Defaults to 10.
+	FontSize float64
+	// Title stored in the PDF document information. Optional.
+	Title string
+	// CreationDate stored in the PDF document information. Optional; without
+	// it, the same input always converts to the same PDF.
+	CreationDate time.Time
+}
+
+// ConvertToPdf converts a JPEG, PNG, GIF or TIFF image or UTF-8 plain text
+// into a PDF that can be used as the content of a [ContentItem]. PDFs are
+// returned unchanged. When mimeType is empty the type is detected from the
+// data.
+//
+//	scan, _ := os.ReadFile("scan.tiff")
+//	pdfBytes, err := content.ConvertToPdf(scan, "", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	req.Body = &[]content.ContentItem{content.PdfContentItem(pdfBytes)}
+func ConvertToPdf(data []byte, mimeType string, opts *ConvertOptions) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data is empty")
+	}
+	if mimeType == "" {
+		mimeType = detectMimeType(data)
+	}
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	switch strings.ToLower(strings.TrimSpace(mimeType)) {
+	case MimeTypePdf:
+		return data, nil
+	case MimeTypeText:
+		return TextToPdf(string(data), opts)
+	case MimeTypeJpeg, MimeTypePng, MimeTypeGif, MimeTypeTiff:
+		return ImagesToPdf([][]byte{data}, opts)
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", mimeType)
+	}
+}
+
+// PdfContentItem wraps a PDF into a ContentItem for SendContentRequest.Body.
+func PdfContentItem(pdfBytes []byte) ContentItem {
+	encoded := base64.StdEncoding.EncodeToString(pdfBytes)
+	t := MimeTypePdf
+	return ContentItem{
+		Content: &encoded,
+		Type:    &t,
+	}
+}
+
+// ImagesToPdf combines one or more JPEG, PNG, GIF or TIFF images into a
+// single PDF with one A4 page per image (multi-page TIFFs produce one page per
+// frame). Each image is rotated according to its EXIF or TIFF orientation tag
+// and scaled to fit the page inside the margins, keeping its aspect ratio.
+// JPEGs are embedded without re-encoding.
+func ImagesToPdf(images [][]byte, opts *ConvertOptions) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, errors.New("no images given")
+	}
+	if opts == nil {
+		opts = &ConvertOptions{}
+	}
+	margin := opts.MarginMm
+	if margin <= 0 {
+		margin = 10
+	}
+
+	doc := pdf.New()
+	doc.Title = opts.Title
+	doc.CreationDate = opts.CreationDate
+	for i, data := range images {
+		frames, err := decodeFrames(data)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+		for _, f := range frames {
+			placeImage(doc, f, opts.Orientation, pdf.Mm(margin))
+		}
+	}
+	return doc.Bytes()
+}
+
+// TextToPdf lays out UTF-8 text on A4 pages in a monospaced font so that
+// column-aligned notices from legacy systems keep their layout. Long lines
+// are wrapped, tabs are expanded and form feeds start a new page. Characters
+// outside the Latin-1 range (plus €, quotes and dashes) are printed as '?'.
+func TextToPdf(text string, opts *ConvertOptions) ([]byte, error) {
+	if !utf8.ValidString(text) {
+		return nil, errors.New("text is not valid UTF-8")
+	}
+	if opts == nil {
+		opts = &ConvertOptions{}
+	}
+	margin := opts.MarginMm
+	if margin <= 0 {
+		margin = 20
+	}
+	size := opts.FontSize
+	if size <= 0 {
+		size = 10
+	}
+
+	width, height := pdf.A4Width, pdf.A4Height
+	if opts.Orientation == OrientationLandscape {
+		width, height = height, width
+	}
+	m := pdf.Mm(margin)
+	lineHeight := size * 1.2
+	charWidth := pdf.Courier.Width("M", size)
+	perLine := int((width - 2*m) / charWidth)
+	perPage := int((height - 2*m) / lineHeight)
+	if perLine < 1 || perPage < 1 {
+		return nil, errors.New("margins leave no room for text")
+	}
+
+	doc := pdf.New()
+	doc.Title = opts.Title
+	doc.CreationDate = opts.CreationDate
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	for _, sheet := range strings.Split(text, "\f") {
+		page := doc.AddPage(width, height)
+		row := 0
+		for _, line := range strings.Split(sheet, "\n") {
+			for _, part := range wrapLine(expandTabs(line), perLine) {
+				if row == perPage {
+					page = doc.AddPage(width, height)
+					row = 0
+				}
+				page.Text(pdf.Courier, size, m, height-m-size-float64(row)*lineHeight, part)
+				row++
+			}
+		}
+	}
+	return doc.Bytes()
+}
+
+// frame is a single decoded page of an image file.
+type frame struct {
+	img         *pdf.Image
+	orientation int
+}
+
+func decodeFrames(data []byte) ([]frame, error) {
+	switch detectMimeType(data) {
+	case MimeTypeJpeg:
+		img, err := pdf.NewJPEGImage(data)
+		if err != nil {
+			return nil, err
+		}
+		return []frame{{img: img, orientation: jpegOrientation(data)}}, nil
+	case MimeTypeTiff:
+		return decodeTiffFrames(data)
+	case MimeTypePng, MimeTypeGif:
+		src, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		img, err := pdf.NewImage(src)
+		if err != nil {
+			return nil, err
+		}
+		return []frame{{img: img, orientation: 1}}, nil
+	default:
+		return nil, errors.New("unsupported image format")
+	}
+}
+
+// decodeTiffFrames decodes every page of a (multi-page) TIFF. The TIFF
+// decoder only reads the first directory, so each further page is decoded
+// from a copy whose header points to that page's directory.
+func decodeTiffFrames(data []byte) ([]frame, error) {
+	order, offsets, err := tiffDirectories(data)
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]frame, 0, len(offsets))
+	for _, off := range offsets {
+		page := data
+		if off != offsets[0] {
+			page = append([]byte(nil), data...)
+			order.PutUint32(page[4:8], off)
+		}
+		src, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			return nil, err
+		}
+		img, err := pdf.NewImage(src)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame{img: img, orientation: tiffOrientation(data, order, off)})
+	}
+	return frames, nil
+}
+
+// placeImage adds a page for f and draws it centred inside the margins.
+func placeImage(doc *pdf.Document, f frame, orientation string, margin float64) {
+	// orientations 5-8 swap width and height
+	w, h := float64(f.img.Width), float64(f.img.Height)
+	if f.orientation >= 5 && f.orientation <= 8 {
+		w, h = h, w
+	}
+
+	pageW, pageH := pdf.A4Width, pdf.A4Height
+	if orientation == OrientationLandscape || (orientation != OrientationPortrait && w > h) {
+		pageW, pageH = pageH, pageW
+	}
+	page := doc.AddPage(pageW, pageH)
+
+	scale := min((pageW-2*margin)/w, (pageH-2*margin)/h)
+	w, h = w*scale, h*scale
+	x, y := (pageW-w)/2, (pageH-h)/2
+	page.DrawImageMatrix(f.img, orientationMatrix(f.orientation, x, y, w, h))
+}
+
+// orientationMatrix maps the unit square of a stored image onto the box
+// (x, y, w, h) so that the image appears upright for the given EXIF
+// orientation (1-8).
+func orientationMatrix(orientation int, x, y, w, h float64) pdf.Matrix {
+	switch orientation {
+	case 2: // mirrored horizontally
+		return pdf.Matrix{-w, 0, 0, h, x + w, y}
+	case 3: // rotated 180°
+		return pdf.Matrix{-w, 0, 0, -h, x + w, y + h}
+	case 4: // mirrored vertically
+		return pdf.Matrix{w, 0, 0, -h, x, y + h}
+	case 5: // transposed
+		return pdf.Matrix{0, -h, -w, 0, x + w, y + h}
+	case 6: // needs 90° clockwise rotation
+		return pdf.Matrix{0, -h, w, 0, x, y + h}
+	case 7: // transversed
+		return pdf.Matrix{0, h, w, 0, x, y}
+	case 8: // needs 90° counter-clockwise rotation
+		return pdf.Matrix{0, h, -w, 0, x + w, y}
+	default:
+		return pdf.Matrix{w, 0, 0, h, x, y}
+	}
+}
+
+func detectMimeType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return MimeTypePdf
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return MimeTypeTiff
+	}
+	mimeType := http.DetectContentType(data)
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 if it has
+// none.
+func jpegOrientation(data []byte) int {
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if marker == 0xda || length < 2 || pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			exif := segment[6:]
+			if order, offsets, err := tiffDirectories(exif); err == nil {
+				return tiffOrientation(exif, order, offsets[0])
+			}
+			return 1
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// tiffDirectories returns the byte order and the offsets of all image file
+// directories of a TIFF structure (a TIFF file or an EXIF block).
+func tiffDirectories(data []byte) (binary.ByteOrder, []uint32, error) {
+	if len(data) < 8 {
+		return nil, nil, errors.New("invalid TIFF header")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, nil, errors.New("invalid TIFF header")
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	for off := order.Uint32(data[4:8]); off != 0; {
+		if seen[off] || int(off)+2 > len(data) {
+			break
+		}
+		seen[off] = true
+		offsets = append(offsets, off)
+		entries := int(order.Uint16(data[off : off+2]))
+		next := int(off) + 2 + entries*12
+		if next+4 > len(data) {
+			break
+		}
+		off = order.Uint32(data[next : next+4])
+	}
+	if len(offsets) == 0 {
+		return nil, nil, errors.New("TIFF has no image directory")
+	}
+	return order, offsets, nil
+}
+
+// tiffOrientation reads the Orientation tag (274) of the directory at off.
+func tiffOrientation(data []byte, order binary.ByteOrder, off uint32) int {
+	entries := int(order.Uint16(data[off : off+2]))
+	for i := 0; i < entries; i++ {
+		e := int(off) + 2 + i*12
+		if e+12 > len(data) {
+			break
+		}
+		if order.Uint16(data[e:e+2]) == 274 {
+			v := int(order.Uint16(data[e+8 : e+10]))
+			if v >= 1 && v <= 8 {
+				return v
+			}
+			break
+		}
+	}
+	return 1
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := 8 - col%8
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(r)
+		col++
+	}
+	return sb.String()
+}
+
+// wrapLine splits line into parts of at most width characters, breaking at
+// the last space where possible.
+func wrapLine(line string, width int) []string {
+	runes := []rune(strings.TrimRight(line, " "))
+	if len(runes) <= width {
+		return []string{string(runes)}
+	}
+	var parts []string
+	for len(runes) > width {
+		cut := width
+		for i := width; i > width/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, strings.TrimRight(string(runes[:cut]), " "))
+		runes = runes[cut:]
+		for len(runes) > 0 && runes[0] == ' ' {
+			runes = runes[1:]
+		}
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
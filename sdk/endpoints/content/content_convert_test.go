@@ -0,0 +1,137 @@
+package content_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"golang.org/x/image/tiff"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func pageCount(pdf []byte) int {
+	return bytes.Count(pdf, []byte("/Type /Page /Parent"))
+}
+
+// withExifOrientation inserts an EXIF APP1 segment carrying the orientation
+// tag right after the SOI marker of a JPEG.
+func withExifOrientation(jpg []byte, orientation byte) []byte {
+	exif := []byte("Exif\x00\x00" +
+		"MM\x00\x2a\x00\x00\x00\x08" + // big endian TIFF header, IFD at 8
+		"\x00\x01" + // one entry
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string([]byte{orientation}) + "\x00\x00" +
+		"\x00\x00\x00\x00") // no next IFD
+	segment := append([]byte{0xff, 0xe1, 0, byte(len(exif) + 2)}, exif...)
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestImagesToPdfPng(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(40, 20)); err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := content.ImagesToPdf([][]byte{buf.Bytes(), buf.Bytes()}, nil)
+	if err != nil {
+		t.Errorf("ImagesToPdf failed: %v", err)
+		return
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+	if n := pageCount(pdf); n != 2 {
+		t.Errorf("Expected 2 pages, got %d", n)
+	}
+	// wide images are placed on landscape pages by default
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 841.89 595.28]")) {
+		t.Error("Expected a landscape page")
+	}
+}
+
+func TestImagesToPdfJpegOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := content.ImagesToPdf([][]byte{withExifOrientation(buf.Bytes(), 6)}, nil)
+	if err != nil {
+		t.Errorf("ImagesToPdf failed: %v", err)
+		return
+	}
+	if !bytes.Contains(pdf, []byte("/Filter /DCTDecode")) {
+		t.Error("Expected the JPEG to be embedded without re-encoding")
+	}
+	// rotated by 90°, the wide image becomes a portrait page
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 595.28 841.89]")) {
+		t.Error("Expected a portrait page for a rotated image")
+	}
+}
+
+func TestConvertToPdfTiff(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tiff.Encode(&buf, testImage(20, 40), nil); err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := content.ConvertToPdf(buf.Bytes(), "", nil)
+	if err != nil {
+		t.Errorf("ConvertToPdf failed: %v", err)
+		return
+	}
+	if n := pageCount(pdf); n != 1 {
+		t.Errorf("Expected 1 page, got %d", n)
+	}
+}
+
+func TestTextToPdf(t *testing.T) {
+	text := "Sehr geehrte Damen und Herren,\n\tIhre Rechnung über 12,50 €.\n" +
+		strings.Repeat("lang ", 60) + "\fSeite zwei"
+	pdf, err := content.TextToPdf(text, nil)
+	if err != nil {
+		t.Errorf("TextToPdf failed: %v", err)
+		return
+	}
+	if n := pageCount(pdf); n != 2 {
+		t.Errorf("Expected 2 pages, got %d", n)
+	}
+
+	// without a creation date the output only depends on the input
+	again, err := content.TextToPdf(text, nil)
+	if err != nil || !bytes.Equal(again, pdf) || bytes.Contains(pdf, []byte("/CreationDate")) {
+		t.Error("Expected the same PDF without creation date for the same text")
+	}
+	dated, err := content.TextToPdf(text, &content.ConvertOptions{CreationDate: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)})
+	if err != nil || !bytes.Contains(dated, []byte("/CreationDate (D:20250301120000Z)")) {
+		t.Errorf("Expected the creation date to be stored, got %v", err)
+	}
+
+	if _, err := content.TextToPdf("\xff\xfe", nil); err == nil {
+		t.Error("Expected an error for invalid UTF-8")
+	}
+}
+
+func TestConvertToPdfUnsupported(t *testing.T) {
+	if _, err := content.ConvertToPdf([]byte("<html></html>"), "text/html", nil); err == nil {
+		t.Error("Expected an error for an unsupported content type")
+	}
+	pdf := []byte("%PDF-1.4 minimal")
+	out, err := content.ConvertToPdf(pdf, "", nil)
+	if err != nil || !bytes.Equal(out, pdf) {
+		t.Error("Expected PDFs to be returned unchanged")
+	}
+}
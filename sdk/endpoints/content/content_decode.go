@@ -0,0 +1,257 @@
+package content
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+// ReceiverState is the state of a document in the mailbox of its receiver.
+// It is only returned to the receiver.
+type ReceiverState string
+
+// Receiver states.
+const (
+	ReceiverStateUnread      ReceiverState = "unread"
+	ReceiverStateRead        ReceiverState = "read"
+	ReceiverStateArchived    ReceiverState = "archived"
+	ReceiverStateTrashed     ReceiverState = "trashed"
+	ReceiverStateRegistering ReceiverState = "registering"
+	ReceiverStateDeleted     ReceiverState = "deleted"
+	ReceiverStatePending     ReceiverState = "pending"
+)
+
+// SenderState is the state of a document in the outbox of its sender. It is
+// only returned to the sender.
+type SenderState string
+
+// Sender states.
+const (
+	SenderStateActive   SenderState = "active"
+	SenderStateArchived SenderState = "archived"
+	SenderStateTrashed  SenderState = "trashed"
+	SenderStateDeleted  SenderState = "deleted"
+	SenderStatePending  SenderState = "pending"
+)
+
+// DocumentMeta is the metadata of a document with typed fields and parsed
+// dates. Dates are zero if the API did not return them.
+type DocumentMeta struct {
+	Subject  string
+	Type     string
+	Sender   string
+	Receiver string
+	// Size is the size of the document in bytes.
+	Size        int64
+	SentAt      time.Time
+	Delivered   bool
+	DeliveredAt time.Time
+	Read        bool
+	ReadAt      time.Time
+	// ReceiverState is only set for the receiver, SenderState only for the
+	// sender.
+	ReceiverState ReceiverState
+	SenderState   SenderState
+}
+
+// DecodedContent is a decoded part of a document.
+type DecodedContent struct {
+	Data []byte
+	// MimeType is detected from Data. The type declared by the API is used
+	// if the data is not recognized.
+	MimeType string
+	// DeclaredType is the content type returned by the API.
+	DeclaredType string
+}
+
+// Metadata returns the metadata of the document with typed fields. It fails
+// if a date can not be parsed.
+func (d *DocumentResponse) Metadata() (*DocumentMeta, error) {
+	if d == nil || d.ContentGetResponse == nil {
+		return nil, errors.New("document is nil")
+	}
+	meta := &DocumentMeta{}
+	m := d.Meta
+	if m == nil {
+		return meta, nil
+	}
+	meta.Subject = strVal(m.Subject)
+	meta.Type = strVal(m.Type)
+	meta.Sender = strVal(m.Sender)
+	meta.Receiver = strVal(m.Receiver)
+	meta.Size = int64(f32Val(m.Size))
+	meta.Delivered = m.Delivered != nil && *m.Delivered
+	meta.Read = m.Read != nil && *m.Read
+	if m.ReceiverState != nil {
+		meta.ReceiverState = ReceiverState(*m.ReceiverState)
+	}
+	if m.SenderState != nil {
+		meta.SenderState = SenderState(*m.SenderState)
+	}
+	for _, date := range []struct {
+		name  string
+		value *string
+		dst   *time.Time
+	}{
+		{"sent_date", m.SentDate, &meta.SentAt},
+		{"delivered_date", m.DeliveredDate, &meta.DeliveredAt},
+		{"read_date", m.ReadDate, &meta.ReadAt},
+	} {
+		t, err := parseTimestamp(strVal(date.value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", date.name, err)
+		}
+		*date.dst = t
+	}
+	return meta, nil
+}
+
+// Decode returns the decoded parts of the document in order.
+func (d *DocumentResponse) Decode() ([]DecodedContent, error) {
+	if d == nil || d.ContentGetResponse == nil {
+		return nil, errors.New("document is nil")
+	}
+	if d.Content == nil {
+		return nil, nil
+	}
+	parts := make([]DecodedContent, 0, len(*d.Content))
+	for i, c := range *d.Content {
+		data, err := base64.StdEncoding.DecodeString(c.Content)
+		if err != nil {
+			return nil, fmt.Errorf("decoding content %d: %w", i+1, err)
+		}
+		declared := strVal(c.ContentType)
+		mimeType := detectMimeType(data)
+		if declared != "" && (mimeType == "application/octet-stream" || mimeType == MimeTypeText) {
+			mimeType = declared
+		}
+		parts = append(parts, DecodedContent{Data: data, MimeType: mimeType, DeclaredType: declared})
+	}
+	return parts, nil
+}
+
+// SaveOptions configure SaveDocument.
+type SaveOptions struct {
+	// MarkRead marks the document as read when it is retrieved. By default
+	// its read state is left unchanged.
+	MarkRead bool
+	// SkipMeta does not write the metadata file.
+	SkipMeta bool
+}
+
+// SaveDocument retrieves a document and saves it to dir, see
+// DocumentResponse.Save. It returns the paths of the written files. The
+// document is only marked as read if opts.MarkRead is set.
+//
+//	paths, respStatus, err := content.SaveDocument(client, ctx, &documentId, "archive", nil)
+func SaveDocument(client *sdkClient.BrifleClient, ctx context.Context, documentId *string, dir string, opts *SaveOptions) ([]string, *api.ResponseStatus, error) {
+	if documentId == nil || *documentId == "" {
+		return nil, nil, errors.New("document ID is required")
+	}
+	if opts == nil {
+		opts = &SaveOptions{}
+	}
+	markRead := opts.MarkRead
+	doc, status, err := GetContent(client, ctx, documentId, &markRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !statusOk(status) {
+		return nil, status, nil
+	}
+	paths, err := doc.save(*documentId, dir, !opts.SkipMeta)
+	if err != nil {
+		return nil, status, err
+	}
+	return paths, status, nil
+}
+
+// Save writes the decoded parts of the document and its metadata to dir,
+// which is created if needed. Files are named after the hex encoded
+// document ID and the 1-based index of the part, with an extension for the
+// detected MIME type; e.g. for the document ID "doc-1":
+//
+//	646f632d31_1.pdf
+//	646f632d31_2.png
+//	646f632d31.meta.json
+//
+// Existing files are overwritten. It returns the paths of the written
+// files.
+func (d *DocumentResponse) Save(documentId, dir string) ([]string, error) {
+	return d.save(documentId, dir, true)
+}
+
+func (d *DocumentResponse) save(documentId, dir string, withMeta bool) ([]string, error) {
+	if documentId == "" {
+		return nil, errors.New("document ID is required")
+	}
+	parts, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	name := safeFileName(documentId)
+	var paths []string
+	for i, part := range parts {
+		p := filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, i+1, fileExtension(part.MimeType)))
+		if err := os.WriteFile(p, part.Data, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	if withMeta {
+		data, err := json.MarshalIndent(d.Meta, "", "  ")
+		if err != nil {
+			return paths, err
+		}
+		p := filepath.Join(dir, name+".meta.json")
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// fileExtension returns the file extension for a MIME type, ".bin" for
+// unknown types.
+func fileExtension(mimeType string) string {
+	switch strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0])) {
+	case MimeTypePdf:
+		return ".pdf"
+	case "application/xml", "text/xml":
+		return ".xml"
+	case "application/json":
+		return ".json"
+	case MimeTypeText:
+		return ".txt"
+	case MimeTypePng:
+		return ".png"
+	case MimeTypeJpeg:
+		return ".jpg"
+	case MimeTypeGif:
+		return ".gif"
+	case MimeTypeTiff:
+		return ".tif"
+	}
+	return ".bin"
+}
+
+// safeFileName makes an ID safe to use as a file name. Signature IDs are
+// base64 and may contain "/". The ID is hex encoded, so that different IDs
+// get different names, also on file systems that ignore case.
+func safeFileName(id string) string {
+	return hex.EncodeToString([]byte(id))
+}
@@ -0,0 +1,87 @@
+package content_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestDocumentMetadataAndDecode(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 200, map[string]any{
+			"content": []map[string]any{
+				{"content": base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 doc")), "content_type": "application/octet-stream"},
+				{"content": base64.StdEncoding.EncodeToString([]byte("<invoice/>")), "content_type": "application/xml"},
+			},
+			"meta": map[string]any{
+				"subject": "Rechnung", "size": 1234, "sent_date": "2025-03-01T10:00:00Z",
+				"read": true, "read_date": "2025-03-02T08:00:00.000Z", "receiver_state": "read",
+			},
+		})
+	})
+	documentId := "doc-1"
+	doc, _, err := content.GetContent(client, context.Background(), &documentId, nil)
+	if err != nil {
+		t.Errorf("GetContent failed: %v", err)
+		return
+	}
+
+	meta, err := doc.Metadata()
+	if err != nil {
+		t.Errorf("Metadata failed: %v", err)
+		return
+	}
+	if meta.Subject != "Rechnung" || meta.Size != 1234 || !meta.Read || meta.ReceiverState != content.ReceiverStateRead {
+		t.Errorf("Unexpected metadata %+v", meta)
+	}
+	if !meta.SentAt.Equal(time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)) || meta.ReadAt.Day() != 2 || !meta.DeliveredAt.IsZero() {
+		t.Errorf("Unexpected dates %+v", meta)
+	}
+
+	parts, err := doc.Decode()
+	if err != nil {
+		t.Errorf("Decode failed: %v", err)
+		return
+	}
+	if len(parts) != 2 || parts[0].MimeType != content.MimeTypePdf || parts[1].MimeType != "application/xml" {
+		t.Errorf("Unexpected parts %+v", parts)
+	}
+}
+
+func TestSaveDocument(t *testing.T) {
+	var read string
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		read = r.URL.Query().Get("read")
+		writeJson(w, 200, map[string]any{
+			"content": []map[string]any{{"content": base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 doc")), "content_type": "application/pdf"}},
+			"meta":    map[string]any{"subject": "Rechnung"},
+		})
+	})
+	dir := filepath.Join(t.TempDir(), "archive")
+	documentId := "doc/1"
+	paths, status, err := content.SaveDocument(client, context.Background(), &documentId, dir, nil)
+	if err != nil || status.HttpStatus != 200 {
+		t.Errorf("SaveDocument failed: %v %v", err, status)
+		return
+	}
+	if read != "false" {
+		t.Errorf("Expected the document not to be marked as read, got read=%q", read)
+	}
+	// IDs are hex encoded, so that "doc/1" and "doc_1" do not collide
+	name := hex.EncodeToString([]byte(documentId))
+	expected := []string{filepath.Join(dir, name+"_1.pdf"), filepath.Join(dir, name+".meta.json")}
+	if len(paths) != len(expected) || paths[0] != expected[0] || paths[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, paths)
+		return
+	}
+	if data, err := os.ReadFile(paths[0]); err != nil || string(data) != "%PDF-1.4 doc" {
+		t.Errorf("Unexpected file content %q: %v", data, err)
+	}
+}
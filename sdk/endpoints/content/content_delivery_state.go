@@ -0,0 +1,271 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeliveryMode is the way a document is delivered.
+type DeliveryMode string
+
+// Delivery modes.
+const (
+	// DeliveryModeBrifle is electronic delivery to a Brifle mailbox.
+	DeliveryModeBrifle DeliveryMode = "brifle"
+	// DeliveryModePhysical is delivery as paper mail.
+	DeliveryModePhysical DeliveryMode = "physical"
+)
+
+// PhysicalState is the state of a paper mail delivery.
+type PhysicalState string
+
+// States of a paper mail delivery.
+const (
+	PhysicalStatePreprocessing PhysicalState = "preprocessing"
+	PhysicalStateProcessing    PhysicalState = "processing"
+	PhysicalStateSent          PhysicalState = "sent"
+	PhysicalStateError         PhysicalState = "error"
+	// PhysicalStateTestSent is the final state of letters sent with a test
+	// API key; they are not printed.
+	PhysicalStateTestSent PhysicalState = "test_sent"
+	PhysicalStateUnknown  PhysicalState = "unknown"
+)
+
+// ErrIllegalTransition is returned by ValidateTransition.
+var ErrIllegalTransition = errors.New("illegal delivery status transition")
+
+// IsTerminal reports whether the state is final: sent, error or test_sent.
+func (s PhysicalState) IsTerminal() bool {
+	switch s {
+	case PhysicalStateSent, PhysicalStateError, PhysicalStateTestSent:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether s is one of the known states.
+func (s PhysicalState) IsValid() bool {
+	switch s {
+	case PhysicalStatePreprocessing, PhysicalStateProcessing, PhysicalStateSent,
+		PhysicalStateError, PhysicalStateTestSent, PhysicalStateUnknown:
+		return true
+	}
+	return false
+}
+
+// rank orders the states of the regular flow; polling may skip states.
+func (s PhysicalState) rank() int {
+	switch s {
+	case PhysicalStatePreprocessing:
+		return 0
+	case PhysicalStateProcessing:
+		return 1
+	}
+	return 2
+}
+
+// CanTransitionTo reports whether a delivery may move from s to next. States
+// only move forward (preprocessing, processing, then one of the terminal
+// states), terminal states are never left, and unknown may be entered from
+// and left to any non-terminal state.
+func (s PhysicalState) CanTransitionTo(next PhysicalState) bool {
+	switch {
+	case !s.IsValid() || !next.IsValid():
+		return false
+	case s == next:
+		return true
+	case s.IsTerminal():
+		return false
+	case s == PhysicalStateUnknown || next == PhysicalStateUnknown:
+		return true
+	}
+	return s.rank() < next.rank()
+}
+
+// DeliveryDetails is the delivery status with typed fields and parsed dates.
+type DeliveryDetails struct {
+	DocumentId string
+	TenantId   string
+	Mode       DeliveryMode
+	// Brifle is set for DeliveryModeBrifle.
+	Brifle *BrifleDelivery
+	// Physical is set for DeliveryModePhysical.
+	Physical *PhysicalDelivery
+}
+
+// BrifleDelivery is the state of an electronic delivery.
+type BrifleDelivery struct {
+	// DeliveredAt is zero while the document is not delivered.
+	DeliveredAt time.Time
+	Read        bool
+}
+
+// PhysicalDelivery is the state of a paper mail delivery.
+type PhysicalDelivery struct {
+	State           PhysicalState
+	Deliverer       string
+	DelivererPrefix string
+	// LetterId is the ID of the letter in the Deutsche Post system.
+	LetterId        string
+	LastStatusCheck time.Time
+	Errors          []PhysicalError
+}
+
+// PhysicalError is an error reported by Deutsche Post.
+type PhysicalError struct {
+	Code        string
+	Level       string
+	Description string
+	Date        time.Time
+}
+
+// Details returns the status with typed fields. It fails if a date can not be
+// parsed.
+func (s *DeliveryStatus) Details() (*DeliveryDetails, error) {
+	if s == nil || s.ContentGetDeliveryStatusResponse == nil {
+		return nil, errors.New("delivery status is nil")
+	}
+	ds := s.DeliveryStatus
+	details := &DeliveryDetails{
+		DocumentId: ds.DocumentId,
+		TenantId:   ds.TenantId.String(),
+		Mode:       DeliveryMode(ds.DeliveryMode),
+	}
+	if b := ds.Brifle; b != nil {
+		delivered, err := parseTimestamp(b.DeliveredDate)
+		if err != nil {
+			return nil, fmt.Errorf("delivered_date: %w", err)
+		}
+		details.Brifle = &BrifleDelivery{DeliveredAt: delivered, Read: b.Read}
+	}
+	if p := ds.Physical; p != nil {
+		checked, err := parseTimestamp(p.LastStatusCheck)
+		if err != nil {
+			return nil, fmt.Errorf("last_status_check: %w", err)
+		}
+		details.Physical = &PhysicalDelivery{
+			State:           PhysicalState(p.State),
+			Deliverer:       string(p.Deliverer),
+			DelivererPrefix: string(p.DelivererPrefix),
+			LetterId:        p.LetterId,
+			LastStatusCheck: checked,
+		}
+		for _, e := range p.Errors {
+			date, err := parseTimestamp(e.DpErrorDate)
+			if err != nil {
+				return nil, fmt.Errorf("dp_error_date: %w", err)
+			}
+			details.Physical.Errors = append(details.Physical.Errors, PhysicalError{
+				Code:        e.DpErrorCode,
+				Level:       e.DpLevel,
+				Description: e.DpErrorDescription,
+				Date:        date,
+			})
+		}
+	}
+	return details, nil
+}
+
+// Mode returns the delivery mode.
+func (s *DeliveryStatus) Mode() DeliveryMode {
+	if s == nil || s.ContentGetDeliveryStatusResponse == nil {
+		return ""
+	}
+	return DeliveryMode(s.DeliveryStatus.DeliveryMode)
+}
+
+// PhysicalState returns the state of a paper mail delivery, or an empty state
+// for electronic delivery.
+func (s *DeliveryStatus) PhysicalState() PhysicalState {
+	if s == nil || s.ContentGetDeliveryStatusResponse == nil || s.DeliveryStatus.Physical == nil {
+		return ""
+	}
+	return PhysicalState(s.DeliveryStatus.Physical.State)
+}
+
+// IsDelivered reports whether an electronic delivery reached the mailbox of
+// the receiver.
+func (s *DeliveryStatus) IsDelivered() bool {
+	return s != nil && s.ContentGetDeliveryStatusResponse != nil &&
+		s.DeliveryStatus.Brifle != nil && s.DeliveryStatus.Brifle.DeliveredDate != ""
+}
+
+// IsRead reports whether the receiver opened an electronically delivered
+// document.
+func (s *DeliveryStatus) IsRead() bool {
+	return s.IsDelivered() && s.DeliveryStatus.Brifle.Read
+}
+
+// IsTerminal reports whether the delivery will not change anymore, apart
+// from the read flag: delivered for Brifle delivery, sent, error or test_sent
+// for paper mail.
+func (s *DeliveryStatus) IsTerminal() bool {
+	switch s.Mode() {
+	case DeliveryModeBrifle:
+		return s.IsDelivered()
+	case DeliveryModePhysical:
+		return s.PhysicalState().IsTerminal()
+	}
+	return false
+}
+
+// IsFailed reports whether the paper mail delivery failed.
+func (s *DeliveryStatus) IsFailed() bool {
+	return s.PhysicalState() == PhysicalStateError
+}
+
+// IsTestDelivery reports whether the document was sent with a test API key
+// and not actually printed.
+func (s *DeliveryStatus) IsTestDelivery() bool {
+	return s.PhysicalState() == PhysicalStateTestSent
+}
+
+// ValidateTransition checks that a document may move from the status prev to
+// next, e.g. between two polls. The delivery mode and document never change,
+// a delivered or read document stays so, and paper mail follows
+// PhysicalState.CanTransitionTo. It returns an error wrapping
+// ErrIllegalTransition otherwise.
+func ValidateTransition(prev, next *DeliveryStatus) error {
+	if prev == nil || prev.ContentGetDeliveryStatusResponse == nil || next == nil || next.ContentGetDeliveryStatusResponse == nil {
+		return errors.New("delivery status is nil")
+	}
+	if p, n := prev.DeliveryStatus.DocumentId, next.DeliveryStatus.DocumentId; p != n {
+		return fmt.Errorf("%w: document changed from %s to %s", ErrIllegalTransition, p, n)
+	}
+	if p, n := prev.Mode(), next.Mode(); p != n {
+		return fmt.Errorf("%w: delivery mode changed from %s to %s", ErrIllegalTransition, p, n)
+	}
+	switch {
+	case prev.IsDelivered() && !next.IsDelivered():
+		return fmt.Errorf("%w: delivered document is no longer delivered", ErrIllegalTransition)
+	case prev.IsRead() && !next.IsRead():
+		return fmt.Errorf("%w: read document is no longer read", ErrIllegalTransition)
+	}
+	if p, n := prev.PhysicalState(), next.PhysicalState(); p != n && p != "" && !p.CanTransitionTo(n) {
+		return fmt.Errorf("%w: physical state changed from %s to %s", ErrIllegalTransition, p, n)
+	}
+	return nil
+}
+
+// timestampLayouts are the ISO 8601 forms used by the API.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTimestamp parses an ISO 8601 date. An empty string is the zero time.
+// Dates without time zone are UTC.
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an ISO 8601 date", s)
+}
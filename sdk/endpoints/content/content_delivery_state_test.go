@@ -0,0 +1,102 @@
+package content_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func deliveryStatus(t *testing.T, body string) *content.DeliveryStatus {
+	t.Helper()
+	var res content.DeliveryStatus
+	if err := json.Unmarshal([]byte(`{"delivery_status": `+body+`}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	return &res
+}
+
+func TestPhysicalStateTransitions(t *testing.T) {
+	tests := []struct {
+		from, to content.PhysicalState
+		legal    bool
+	}{
+		{content.PhysicalStatePreprocessing, content.PhysicalStateProcessing, true},
+		{content.PhysicalStatePreprocessing, content.PhysicalStateSent, true},
+		{content.PhysicalStateProcessing, content.PhysicalStateError, true},
+		{content.PhysicalStateProcessing, content.PhysicalStateUnknown, true},
+		{content.PhysicalStateUnknown, content.PhysicalStateProcessing, true},
+		{content.PhysicalStateSent, content.PhysicalStateSent, true},
+		{content.PhysicalStateProcessing, content.PhysicalStatePreprocessing, false},
+		{content.PhysicalStateSent, content.PhysicalStateError, false},
+		{content.PhysicalStateTestSent, content.PhysicalStateUnknown, false},
+		{content.PhysicalStateProcessing, "shredded", false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.legal {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.legal, got)
+		}
+	}
+}
+
+func TestDeliveryStatusDetails(t *testing.T) {
+	status := deliveryStatus(t, `{
+		"delivery_mode": "physical",
+		"document_id": "doc-1",
+		"tenant_id": "0b8f1a4e-6c0e-4e55-9a62-3c1f2b3d4e5f",
+		"physical": {
+			"state": "error",
+			"deliverer": "Deutsche Post",
+			"deliverer_prefix": "dp",
+			"letter_id": "L-1",
+			"last_status_check": "2025-03-02T08:30:00.000Z",
+			"errors": [{"dp_error_code": "E1", "dp_level": "error", "dp_error_description": "address unknown", "dp_error_date": "2025-03-02T08:00:00Z"}]
+		}
+	}`)
+	if !status.IsTerminal() || !status.IsFailed() || status.IsTestDelivery() || status.Mode() != content.DeliveryModePhysical {
+		t.Errorf("Unexpected state helpers for %+v", status.DeliveryStatus)
+	}
+
+	details, err := status.Details()
+	if err != nil {
+		t.Errorf("Details failed: %v", err)
+		return
+	}
+	p := details.Physical
+	if p == nil || p.State != content.PhysicalStateError || p.LetterId != "L-1" || len(p.Errors) != 1 {
+		t.Errorf("Unexpected physical details %+v", p)
+		return
+	}
+	if !p.LastStatusCheck.Equal(time.Date(2025, 3, 2, 8, 30, 0, 0, time.UTC)) || p.Errors[0].Date.Hour() != 8 {
+		t.Errorf("Unexpected dates %v %v", p.LastStatusCheck, p.Errors[0].Date)
+	}
+
+	if _, err := deliveryStatus(t, `{"delivery_mode": "brifle", "brifle": {"delivered_date": "yesterday"}}`).Details(); err == nil {
+		t.Errorf("Expected an error for an invalid date")
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	unread := deliveryStatus(t, `{"delivery_mode": "brifle", "document_id": "doc-1", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": false}}`)
+	read := deliveryStatus(t, `{"delivery_mode": "brifle", "document_id": "doc-1", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": true}}`)
+	processing := deliveryStatus(t, `{"delivery_mode": "physical", "document_id": "doc-1", "physical": {"state": "processing"}}`)
+	sent := deliveryStatus(t, `{"delivery_mode": "physical", "document_id": "doc-1", "physical": {"state": "sent"}}`)
+
+	if err := content.ValidateTransition(unread, read); err != nil {
+		t.Errorf("Expected unread -> read to be legal, got %v", err)
+	}
+	if err := content.ValidateTransition(processing, sent); err != nil {
+		t.Errorf("Expected processing -> sent to be legal, got %v", err)
+	}
+	for name, pair := range map[string][2]*content.DeliveryStatus{
+		"read -> unread":     {read, unread},
+		"sent -> processing": {sent, processing},
+		"brifle -> physical": {read, sent},
+	} {
+		if err := content.ValidateTransition(pair[0], pair[1]); !errors.Is(err, content.ErrIllegalTransition) {
+			t.Errorf("%s: expected ErrIllegalTransition, got %v", name, err)
+		}
+	}
+}
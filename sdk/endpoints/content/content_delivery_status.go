@@ -31,6 +31,7 @@ type DeliveryStatus struct {
 
 // CheckReceiverBulk checks whether multiple receivers exist on Brifle in a
 // single request. The order of the results matches the order of the input.
+// Use a ReceiverChecker for lists that may exceed the request size limit.
 func CheckReceiverBulk(client *sdkClient.BrifleClient, ctx context.Context, receivers *[]ReceiverData) (*ReceiverBulkCheckResponse, *api.ResponseStatus, error) {
 	if receivers == nil {
 		return nil, nil, errors.New("receivers is nil")
@@ -0,0 +1,285 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+// StatusError is returned by Document when the API answers with an error
+// status.
+type StatusError struct {
+	// Operation is the request that failed, e.g. "get delivery certificate".
+	Operation string
+	Status    *api.ResponseStatus
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Operation, statusText(e.Status))
+}
+
+// DocumentSignature is an exported signature of a document. Parse and
+// verify it with the signatures package.
+type DocumentSignature struct {
+	Id string
+	// XML is the export returned by the API.
+	XML string
+}
+
+// Document is a handle to a single document. Its parts are fetched on first
+// use and cached until Refresh is called. It is safe for concurrent use.
+//
+//	doc := content.NewDocument(client, documentId)
+//	meta, err := doc.Meta(ctx)
+//	status, err := doc.DeliveryStatus(ctx)
+//
+// Errors of the API are returned as *StatusError.
+type Document struct {
+	client *sdkClient.BrifleClient
+	id     string
+	item   *api.Item
+
+	mu         sync.Mutex
+	meta       *DocumentMeta
+	response   *DocumentResponse
+	content    []DecodedContent
+	actions    *ContentActions
+	status     *DeliveryStatus
+	cert       *DeliveryCertificate
+	signatures []DocumentSignature
+	signed     bool
+}
+
+// NewDocument returns a handle to the document with the given ID. Nothing is
+// fetched until a method is called.
+func NewDocument(client *sdkClient.BrifleClient, documentId string) *Document {
+	return &Document{client: client, id: documentId}
+}
+
+// DocumentFromItem returns a handle to a document found in a mailbox or
+// outbox. The metadata of the item is used by Meta until Refresh is called.
+func DocumentFromItem(client *sdkClient.BrifleClient, item *api.Item) (*Document, error) {
+	if item == nil || item.Id == nil || *item.Id == "" {
+		return nil, errors.New("item has no document ID")
+	}
+	return &Document{client: client, id: *item.Id, item: item}, nil
+}
+
+// Id returns the ID of the document.
+func (d *Document) Id() string {
+	return d.id
+}
+
+// Meta returns the metadata of the document. It is taken from the mailbox
+// item if the handle was created from one; otherwise the document is
+// retrieved, without marking it as read. Items do not carry the receiver and
+// sender state.
+func (d *Document) Meta(ctx context.Context) (*DocumentMeta, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.meta != nil {
+		return d.meta, nil
+	}
+	if d.item != nil && d.response == nil {
+		meta, err := itemMeta(d.item)
+		if err != nil {
+			return nil, err
+		}
+		d.meta = meta
+		return meta, nil
+	}
+	res, err := d.fetchContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := res.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	d.meta = meta
+	return meta, nil
+}
+
+// Content returns the decoded parts of the document. Retrieving the content
+// does not mark the document as read.
+func (d *Document) Content(ctx context.Context) ([]DecodedContent, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.content != nil {
+		return d.content, nil
+	}
+	res, err := d.fetchContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := res.Decode()
+	if err != nil {
+		return nil, err
+	}
+	d.content = parts
+	return parts, nil
+}
+
+// Actions returns the payments and signature requests of the document.
+func (d *Document) Actions(ctx context.Context) (*ContentActions, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.fetchActions(ctx)
+}
+
+// DeliveryStatus returns the delivery status of the document.
+func (d *Document) DeliveryStatus(ctx context.Context) (*DeliveryStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.status != nil {
+		return d.status, nil
+	}
+	res, status, err := GetDeliveryStatus(d.client, ctx, &d.id)
+	if err != nil {
+		return nil, err
+	}
+	if !statusOk(status) {
+		return nil, &StatusError{Operation: "get delivery status", Status: status}
+	}
+	d.status = res
+	return res, nil
+}
+
+// Certificate returns the delivery certificate of the document. Parse and
+// verify it with the certificates package.
+func (d *Document) Certificate(ctx context.Context) (*DeliveryCertificate, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cert != nil {
+		return d.cert, nil
+	}
+	res, status, err := GetDeliveryCertificate(d.client, ctx, &d.id)
+	if err != nil {
+		return nil, err
+	}
+	if !statusOk(status) {
+		return nil, &StatusError{Operation: "get delivery certificate", Status: status}
+	}
+	d.cert = res
+	return res, nil
+}
+
+// Signatures returns the exported signatures of the document, sorted by ID.
+// It fetches the actions of the document to find them.
+func (d *Document) Signatures(ctx context.Context) ([]DocumentSignature, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.signed {
+		return d.signatures, nil
+	}
+	actions, err := d.fetchActions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := signatureIds(actions)
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]DocumentSignature, 0, len(ids))
+	for _, id := range ids {
+		xml, status, err := exportSignatureXml(d.client, ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !statusOk(status) {
+			return nil, &StatusError{Operation: "export signature " + id, Status: status}
+		}
+		signatures = append(signatures, DocumentSignature{Id: id, XML: xml})
+	}
+	d.signatures = signatures
+	d.signed = true
+	return signatures, nil
+}
+
+// Refresh drops all cached parts, including the metadata of the mailbox
+// item, so that they are fetched again on next use.
+func (d *Document) Refresh() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.item = nil
+	d.meta = nil
+	d.response = nil
+	d.content = nil
+	d.actions = nil
+	d.status = nil
+	d.cert = nil
+	d.signatures = nil
+	d.signed = false
+}
+
+func (d *Document) fetchContent(ctx context.Context) (*DocumentResponse, error) {
+	if d.response != nil {
+		return d.response, nil
+	}
+	res, status, err := GetContent(d.client, ctx, &d.id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !statusOk(status) || res.ContentGetResponse == nil {
+		return nil, &StatusError{Operation: "get content", Status: status}
+	}
+	d.response = res
+	return res, nil
+}
+
+func (d *Document) fetchActions(ctx context.Context) (*ContentActions, error) {
+	if d.actions != nil {
+		return d.actions, nil
+	}
+	res, status, err := GetContentAction(d.client, ctx, &d.id)
+	if err != nil {
+		return nil, err
+	}
+	if !statusOk(status) {
+		return nil, &StatusError{Operation: "get content actions", Status: status}
+	}
+	d.actions = res
+	return res, nil
+}
+
+// exportSignatureXml exports a signature as XML. The signatures package
+// can not be used here as it depends on this package through the
+// certificates package.
+func exportSignatureXml(client *sdkClient.BrifleClient, ctx context.Context, id string) (string, *api.ResponseStatus, error) {
+	response, err := client.ApiClient.WebApiControllerSignatureControllerExportSignature(ctx, id, api.Xml)
+	if err != nil {
+		return "", nil, fmt.Errorf("exporting signature %s: %w", id, err)
+	}
+	status, xml, err := api.ParseResponseAsString(response)
+	if err != nil {
+		return "", nil, fmt.Errorf("exporting signature %s: %w", id, err)
+	}
+	return xml, status, nil
+}
+
+func itemMeta(item *api.Item) (*DocumentMeta, error) {
+	meta := &DocumentMeta{
+		Subject:   strVal(item.Subject),
+		Type:      strVal(item.Type),
+		Sender:    strVal(item.Sender),
+		Receiver:  strVal(item.Receiver),
+		Size:      int64(f32Val(item.Size)),
+		Delivered: item.Delivered != nil && *item.Delivered,
+		Read:      item.Read != nil && *item.Read,
+	}
+	var err error
+	if meta.SentAt, err = parseTimestamp(strVal(item.SentDate)); err != nil {
+		return nil, fmt.Errorf("sent_date: %w", err)
+	}
+	if meta.DeliveredAt, err = parseTimestamp(strVal(item.DeliveredDate)); err != nil {
+		return nil, fmt.Errorf("delivered_date: %w", err)
+	}
+	if meta.ReadAt, err = parseTimestamp(strVal(item.ReadDate)); err != nil {
+		return nil, fmt.Errorf("read_date: %w", err)
+	}
+	return meta, nil
+}
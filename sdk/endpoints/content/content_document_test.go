@@ -0,0 +1,78 @@
+package content_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestDocumentCachesParts(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+		evidenceServer(t)(w, r)
+	})
+	ctx := context.Background()
+	doc := content.NewDocument(client, "doc-1")
+
+	for i := 0; i < 2; i++ {
+		meta, err := doc.Meta(ctx)
+		if err != nil || meta.Subject != "Vertrag" {
+			t.Errorf("Unexpected meta %+v: %v", meta, err)
+			return
+		}
+		parts, err := doc.Content(ctx)
+		if err != nil || len(parts) != 1 || parts[0].MimeType != content.MimeTypePdf {
+			t.Errorf("Unexpected content %+v: %v", parts, err)
+			return
+		}
+		signatures, err := doc.Signatures(ctx)
+		if err != nil || len(signatures) != 2 || signatures[0].Id != "sig-1" {
+			t.Errorf("Unexpected signatures %+v: %v", signatures, err)
+			return
+		}
+	}
+	if calls["/v1/content/document/doc-1"] != 1 || calls["/v1/content/document/doc-1/actions"] != 1 {
+		t.Errorf("Expected each part to be fetched once, got %v", calls)
+	}
+
+	doc.Refresh()
+	if _, err := doc.Actions(ctx); err != nil {
+		t.Errorf("Actions failed: %v", err)
+	}
+	if calls["/v1/content/document/doc-1/actions"] != 2 {
+		t.Errorf("Expected Refresh to drop the cached actions, got %v", calls)
+	}
+
+	var statusErr *content.StatusError
+	if _, err := doc.Certificate(ctx); !errors.As(err, &statusErr) || statusErr.Status.HttpStatus != 404 {
+		t.Errorf("Expected a StatusError with 404, got %v", err)
+	}
+}
+
+func TestDocumentFromItem(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request %s", r.URL.Path)
+	})
+	doc, err := content.DocumentFromItem(client, &api.Item{Id: sdk.String("doc-1"), Subject: sdk.String("Rechnung"), SentDate: sdk.String("2025-03-01T10:00:00Z")})
+	if err != nil {
+		t.Errorf("DocumentFromItem failed: %v", err)
+		return
+	}
+	meta, err := doc.Meta(context.Background())
+	if err != nil || doc.Id() != "doc-1" || meta.Subject != "Rechnung" || meta.SentAt.Year() != 2025 {
+		t.Errorf("Unexpected meta %+v: %v", meta, err)
+	}
+	if _, err := content.DocumentFromItem(client, &api.Item{}); err == nil {
+		t.Errorf("Expected an error for an item without ID")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/brifle-de/brifle-sdk/sdk/api"
 	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
 )
 
 // strVal safely dereferences a *string, returning "" when nil.
@@ -86,14 +87,10 @@ func buildReceiver(receiver *ReceiverData) *api.ApiSendContentReceiverRequest {
 // See [ReceiverData] for the ways to address a recipient, and the [Letter],
 // [Invoice] and [Contract] constants for the document type.
 func SendContent(client *sdkClient.BrifleClient, context context.Context, tenant *string, sendContent *SendContentRequest) (*SendDocumentResponse, *api.ResponseStatus, error) {
-	if sendContent == nil {
-		return nil, nil, errors.New("send content request is nil")
+	if err := validateSendRequest(sendContent); err != nil {
+		return nil, nil, err
 	}
-
 	receiver := buildReceiver(sendContent.To)
-	if receiver == nil {
-		return nil, nil, errors.New("receiver data is invalid")
-	}
 
 	convertedBody := make([]api.ApiSendContentContentRequest, len(*sendContent.Body))
 	for i, item := range *sendContent.Body {
@@ -118,7 +115,11 @@ func SendContent(client *sdkClient.BrifleClient, context context.Context, tenant
 		SignatureInfo: sendContent.SignatureInfo.ToApiSignatureInfo(),
 	}
 
-	response, err := client.ApiClient.WebApiControllerContentControllerSend(context, *tenant, *request)
+	body, err := encodeSendRequest(request, sendContent.PaymentInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := client.ApiClient.WebApiControllerContentControllerSendWithBody(context, *tenant, "application/json", body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -132,6 +133,20 @@ func SendContent(client *sdkClient.BrifleClient, context context.Context, tenant
 	return &res, status, nil
 }
 
+// validateSendRequest returns the errors SendContent returns before sending.
+func validateSendRequest(sendContent *SendContentRequest) error {
+	if sendContent == nil {
+		return errors.New("send content request is nil")
+	}
+	if buildReceiver(sendContent.To) == nil {
+		return errors.New("receiver data is invalid")
+	}
+	if sendContent.Body == nil {
+		return errors.New("body is required")
+	}
+	return sendContent.PaymentInfo.Validate()
+}
+
 // CheckReceiver checks if the receiver data is valid and returns a response indicating the result.
 func CheckReceiver(client *sdkClient.BrifleClient, context context.Context, receiver *ReceiverData) (*ReceiverCheckResponse, *api.ResponseStatus, error) {
 	if receiver == nil {
@@ -205,26 +220,8 @@ type DeliveryCertificate struct {
 
 type ContentActions struct {
 	Payments *struct {
-		Details *struct {
-			// Amount the amount to pay in the smallest unit of the currency
-			Amount *float32 `json:"amount,omitempty"`
-
-			// Currency the currency of the payment
-			Currency *string `json:"currency,omitempty"`
-
-			// Iban the iban of the payment
-			Iban *string `json:"iban,omitempty"`
-
-			// Market the market of the payment. Important for the payment provider, e.g. Tink
-			Market *string `json:"market,omitempty"`
-
-			// Reference the reference of the payment
-			Reference *string `json:"reference,omitempty"`
-
-			// TinkPaymentId the payment id in the Tink system
-			TinkPaymentId *string `json:"tink_payment_id,omitempty"`
-		} `json:"details,omitempty"`
-		Link *string `json:"link,omitempty"`
+		Details *PaymentActionDetails `json:"details,omitempty"`
+		Link    *string               `json:"link,omitempty"`
 	} `json:"payments,omitempty"`
 	Signatures *struct {
 		DocumentSignatures *struct {
@@ -295,6 +292,9 @@ type ReceiverCheckResponse struct {
 
 type SendDocumentResponse struct {
 	*api.ContentCreateResponse
+	// Replayed is set by IdempotentSender when the response is that of an
+	// earlier send with the same idempotency key.
+	Replayed bool `json:"-"`
 }
 
 type SendContentRequest struct {
@@ -402,12 +402,12 @@ type ContentItem struct {
 	Type *string `json:"type,omitempty"`
 }
 
+// PaymentDetails are the payment details of an invoice. It is encoded as
+// JSON with the amount in minor units and the currency as separate fields,
+// as sent to the API.
 type PaymentDetails struct {
-	// Amount Amount
-	Amount *float32 `json:"amount,omitempty"`
-
-	// Currency Currency
-	Currency *string `json:"currency,omitempty"`
+	// Amount is the amount to pay, e.g. payments.Cents(1250).
+	Amount *payments.Money `json:"-"`
 
 	// Description Description
 	Description *string `json:"description,omitempty"`
@@ -420,6 +420,10 @@ type PaymentDetails struct {
 
 	// Reference Reference
 	Reference *string `json:"reference,omitempty"`
+
+	// StructuredReference marks Reference as an RF creditor reference
+	// (ISO 11649); Validate then checks its check digits. It is not sent.
+	StructuredReference bool `json:"-"`
 }
 
 type PaymentInfo struct {
@@ -481,6 +485,9 @@ func (reqSigner *SignatureInfo) ToApiSignatureInfo() *api.ApiSendContentSignatur
 	}
 }
 
+// ToApiPaymentInfo converts the PaymentInfo to an ApiSendContentPaymentInfo.
+// The generated type holds the amount as float32, which is exact up to
+// 16,777,216 minor units; SendContent sends larger amounts exactly.
 func (paymentInfo *PaymentInfo) ToApiPaymentInfo() *api.ApiSendContentPaymentInfo {
 	if paymentInfo == nil {
 		return nil
@@ -490,10 +497,14 @@ func (paymentInfo *PaymentInfo) ToApiPaymentInfo() *api.ApiSendContentPaymentInf
 			Payable: paymentInfo.Payable,
 		}
 	}
+	var amount payments.Money
+	if paymentInfo.Details.Amount != nil {
+		amount = *paymentInfo.Details.Amount
+	}
 	return &api.ApiSendContentPaymentInfo{
 		Details: &api.ApiSendContentPaymentDetails{
-			Amount:      f32Val(paymentInfo.Details.Amount),
-			Currency:    strVal(paymentInfo.Details.Currency),
+			Amount:      float32(amount.Amount),
+			Currency:    amount.Currency,
 			Description: strVal(paymentInfo.Details.Description),
 			DueDate:     strVal(paymentInfo.Details.DueDate),
 			Iban:        strVal(paymentInfo.Details.Iban),
@@ -0,0 +1,445 @@
+package content
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+const (
+	// EvidenceMimeType is the media type of an evidence bundle. It is stored
+	// uncompressed in the first entry "mimetype", like in other ZIP based
+	// formats. A bundle is not an ASiC container: it has no signature of its
+	// own.
+	EvidenceMimeType = "application/vnd.brifle.evidence+zip"
+	// EvidenceExtension is the file name extension of evidence bundles.
+	EvidenceExtension = ".brifle-evidence.zip"
+	// EvidenceManifestPath is the path of the manifest in an evidence bundle.
+	EvidenceManifestPath = "META-INF/manifest.json"
+	// MaxEvidenceManifestSize is the maximum size of the manifest read by
+	// VerifyEvidence. Bundles come from archives and third parties, and a
+	// small ZIP entry can inflate to gigabytes.
+	MaxEvidenceManifestSize = 16 << 20
+	// EvidenceVersion is the version of the manifest written by
+	// ExportEvidence.
+	EvidenceVersion = 1
+)
+
+// Parts of an evidence bundle, used in EvidenceMissing.
+const (
+	EvidencePartActions             = "actions"
+	EvidencePartDeliveryStatus      = "delivery_status"
+	EvidencePartDeliveryCertificate = "delivery_certificate"
+	EvidencePartSignature           = "signature"
+)
+
+// EvidenceManifest lists the files of an evidence bundle with their SHA-256
+// hashes. The manifest is not signed; the delivery certificate and the
+// signatures in the bundle carry their own signatures.
+type EvidenceManifest struct {
+	Version    int            `json:"version"`
+	DocumentId string         `json:"document_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Files      []EvidenceFile `json:"files"`
+	// Missing lists the parts that could not be collected, e.g. the
+	// delivery certificate of a document that has none.
+	Missing []EvidenceMissing `json:"missing,omitempty"`
+}
+
+// EvidenceFile is a file of an evidence bundle.
+type EvidenceFile struct {
+	Path      string `json:"path"`
+	MediaType string `json:"media_type"`
+	Size      int64  `json:"size"`
+	// Sha256 is the hex encoded SHA-256 hash of the file.
+	Sha256 string `json:"sha256"`
+}
+
+// EvidenceMissing is a part that was not added to an evidence bundle.
+type EvidenceMissing struct {
+	Part string `json:"part"`
+	// Id is the ID of the missing signature.
+	Id         string `json:"id,omitempty"`
+	HttpStatus int    `json:"http_status,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// ExportEvidence writes everything known about a document to w as a ZIP
+// container: the decoded content, its metadata, the content actions, the
+// delivery status, the delivery certificate and all exported signatures,
+// together with a manifest of their SHA-256 hashes.
+//
+// The bundle is only written once all parts were collected. ExportEvidence
+// fails if the content can not be retrieved; other parts the API answers
+// with an error status for are listed in EvidenceManifest.Missing.
+//
+//	f, _ := os.Create(documentId + content.EvidenceExtension)
+//	defer f.Close()
+//	manifest, err := content.ExportEvidence(client, ctx, &documentId, f)
+func ExportEvidence(client *sdkClient.BrifleClient, ctx context.Context, documentId *string, w io.Writer) (*EvidenceManifest, error) {
+	if documentId == nil || *documentId == "" {
+		return nil, errors.New("document ID is required")
+	}
+	if w == nil {
+		return nil, errors.New("writer is required")
+	}
+
+	bundle := &evidenceBundle{manifest: EvidenceManifest{
+		Version:    EvidenceVersion,
+		DocumentId: *documentId,
+		CreatedAt:  time.Now().UTC(),
+	}}
+
+	doc, status, err := GetContent(client, ctx, documentId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting content: %w", err)
+	}
+	if !statusOk(status) || doc.ContentGetResponse == nil {
+		return nil, fmt.Errorf("getting content: %s", statusText(status))
+	}
+	parts, err := doc.Decode()
+	if err != nil {
+		return nil, err
+	}
+	for i, part := range parts {
+		bundle.add(fmt.Sprintf("content/%d%s", i+1, fileExtension(part.MimeType)), part.MimeType, part.Data)
+	}
+	if err := bundle.addJson("meta.json", doc.Meta); err != nil {
+		return nil, err
+	}
+
+	actions, status, err := GetContentAction(client, ctx, documentId)
+	if err != nil {
+		return nil, fmt.Errorf("getting content actions: %w", err)
+	}
+	if bundle.collected(EvidencePartActions, "", status) {
+		if err := bundle.addJson("actions.json", actions); err != nil {
+			return nil, err
+		}
+	}
+
+	deliveryStatus, status, err := GetDeliveryStatus(client, ctx, documentId)
+	if err != nil {
+		return nil, fmt.Errorf("getting delivery status: %w", err)
+	}
+	if bundle.collected(EvidencePartDeliveryStatus, "", status) {
+		if err := bundle.addJson("delivery_status.json", deliveryStatus); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, status, err := GetDeliveryCertificate(client, ctx, documentId)
+	if err != nil {
+		return nil, fmt.Errorf("getting delivery certificate: %w", err)
+	}
+	if bundle.collected(EvidencePartDeliveryCertificate, "", status) {
+		if cert.Certificate != nil {
+			bundle.add("delivery_certificate.xml", "application/xml", []byte(*cert.Certificate))
+		}
+		if err := bundle.addJson("delivery_certificate.json", cert.Meta); err != nil {
+			return nil, err
+		}
+	}
+
+	if actions != nil {
+		ids, err := signatureIds(actions)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			xml, status, err := exportSignatureXml(client, ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if bundle.collected(EvidencePartSignature, id, status) {
+				bundle.add("signatures/"+safeFileName(id)+".xml", "application/xml", []byte(xml))
+			}
+		}
+	}
+
+	if err := bundle.write(w); err != nil {
+		return nil, err
+	}
+	return &bundle.manifest, nil
+}
+
+// EvidenceProblem is an integrity problem of an evidence bundle.
+type EvidenceProblem struct {
+	// Path is the file concerned, if any.
+	Path   string `json:"path,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// EvidenceVerification is the outcome of VerifyEvidence.
+type EvidenceVerification struct {
+	// Valid is set if every file matches the manifest and the bundle has no
+	// files the manifest does not list.
+	Valid    bool              `json:"valid"`
+	Manifest *EvidenceManifest `json:"manifest,omitempty"`
+	Problems []EvidenceProblem `json:"problems,omitempty"`
+}
+
+// VerifyEvidence checks the integrity of an evidence bundle written by
+// ExportEvidence: every file listed in the manifest must be present with the
+// listed size and SHA-256 hash, and no other files may be present. Files are
+// read up to their listed size and the manifest up to
+// MaxEvidenceManifestSize. It does not verify the delivery certificate and
+// signatures themselves; use the certificates and signatures packages for
+// that.
+//
+// Integrity problems are reported in the result; an error is only returned
+// if the bundle can not be read at all.
+//
+//	f, _ := os.Open("doc-1" + content.EvidenceExtension)
+//	info, _ := f.Stat()
+//	res, err := content.VerifyEvidence(f, info.Size())
+func VerifyEvidence(r io.ReaderAt, size int64) (*EvidenceVerification, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading evidence bundle: %w", err)
+	}
+	res := &EvidenceVerification{}
+	problem := func(path, format string, args ...any) {
+		res.Problems = append(res.Problems, EvidenceProblem{Path: path, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		if _, ok := entries[f.Name]; ok {
+			problem(f.Name, "file is present more than once")
+		}
+		entries[f.Name] = f
+	}
+
+	if len(zr.File) == 0 || zr.File[0].Name != "mimetype" {
+		problem("mimetype", "the first file is not mimetype")
+	} else if data, err := readZipFile(zr.File[0], int64(len(EvidenceMimeType))); err != nil || string(data) != EvidenceMimeType {
+		problem("mimetype", "media type is not %s", EvidenceMimeType)
+	}
+
+	manifestFile, ok := entries[EvidenceManifestPath]
+	if !ok {
+		problem(EvidenceManifestPath, "manifest is missing")
+		return res, nil
+	}
+	data, err := readZipFile(manifestFile, MaxEvidenceManifestSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var manifest EvidenceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		problem(EvidenceManifestPath, "manifest is invalid: %v", err)
+		return res, nil
+	}
+	res.Manifest = &manifest
+	if manifest.Version != EvidenceVersion {
+		problem(EvidenceManifestPath, "unsupported manifest version %d", manifest.Version)
+	}
+
+	listed := map[string]bool{"mimetype": true, EvidenceManifestPath: true}
+	for _, file := range manifest.Files {
+		if listed[file.Path] {
+			problem(file.Path, "file is listed more than once")
+			continue
+		}
+		listed[file.Path] = true
+		f, ok := entries[file.Path]
+		if !ok {
+			problem(file.Path, "file is missing")
+			continue
+		}
+		size, hash, err := hashZipFile(f, file.Size)
+		switch {
+		case err != nil:
+			problem(file.Path, "file can not be read: %v", err)
+		case size > file.Size:
+			problem(file.Path, "size exceeds %d listed in the manifest", file.Size)
+		case size != file.Size:
+			problem(file.Path, "size is %d, manifest lists %d", size, file.Size)
+		case !strings.EqualFold(hash, file.Sha256):
+			problem(file.Path, "SHA-256 hash is %s, manifest lists %s", hash, file.Sha256)
+		}
+	}
+	for _, f := range zr.File {
+		if !listed[f.Name] {
+			problem(f.Name, "file is not listed in the manifest")
+		}
+	}
+
+	res.Valid = len(res.Problems) == 0
+	return res, nil
+}
+
+type evidenceBundle struct {
+	manifest EvidenceManifest
+	data     [][]byte
+}
+
+func (b *evidenceBundle) add(path, mediaType string, data []byte) {
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	sum := sha256.Sum256(data)
+	b.manifest.Files = append(b.manifest.Files, EvidenceFile{
+		Path:      path,
+		MediaType: mediaType,
+		Size:      int64(len(data)),
+		Sha256:    hex.EncodeToString(sum[:]),
+	})
+	b.data = append(b.data, data)
+}
+
+func (b *evidenceBundle) addJson(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	b.add(path, "application/json", data)
+	return nil
+}
+
+// collected reports whether a part was retrieved and records it as missing
+// otherwise.
+func (b *evidenceBundle) collected(part, id string, status *api.ResponseStatus) bool {
+	if statusOk(status) {
+		return true
+	}
+	missing := EvidenceMissing{Part: part, Id: id}
+	if status != nil {
+		missing.HttpStatus = status.HttpStatus
+		missing.Message = status.Message()
+	}
+	b.manifest.Missing = append(b.manifest.Missing, missing)
+	return false
+}
+
+func (b *evidenceBundle) write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	// mimetype comes first and uncompressed so it can be read at a fixed
+	// offset
+	mt, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("writing evidence bundle: %w", err)
+	}
+	if _, err := io.WriteString(mt, EvidenceMimeType); err != nil {
+		return fmt.Errorf("writing evidence bundle: %w", err)
+	}
+	for i, file := range b.manifest.Files {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: file.Path, Method: zip.Deflate, Modified: b.manifest.CreatedAt})
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", file.Path, err)
+		}
+		if _, err := f.Write(b.data[i]); err != nil {
+			return fmt.Errorf("writing %s: %w", file.Path, err)
+		}
+	}
+	manifest, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: EvidenceManifestPath, Method: zip.Deflate, Modified: b.manifest.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if _, err := f.Write(manifest); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return zw.Close()
+}
+
+// signatureIds returns the IDs of the signatures of a document, sorted and
+// without duplicates.
+func signatureIds(actions *ContentActions) ([]string, error) {
+	if actions.Signatures == nil {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	if embedded := actions.Signatures.EmbeddedSignatures; embedded != nil {
+		for _, s := range *embedded {
+			if id := strVal(s.Id); id != "" {
+				seen[id] = true
+			}
+		}
+	}
+	if ds := actions.Signatures.DocumentSignatures; ds != nil && len(ds.SignatureIds) > 0 {
+		// the ids are a JSON array, sometimes encoded as a JSON string
+		raw := []byte(ds.SignatureIds)
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err == nil {
+			raw = []byte(encoded)
+		}
+		var ids []string
+		if err := json.Unmarshal(raw, &ids); err != nil {
+			return nil, errors.New("failed to unmarshal signature ids: " + err.Error())
+		}
+		for _, id := range ids {
+			if id != "" {
+				seen[id] = true
+			}
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func statusOk(status *api.ResponseStatus) bool {
+	return status != nil && status.HttpStatus >= 200 && status.HttpStatus < 300
+}
+
+func statusText(status *api.ResponseStatus) string {
+	if status == nil {
+		return "no response"
+	}
+	if msg := status.Message(); msg != "" {
+		return fmt.Sprintf("HTTP %d: %s", status.HttpStatus, msg)
+	}
+	return fmt.Sprintf("HTTP %d", status.HttpStatus)
+}
+
+// readZipFile reads f, failing if it is larger than max bytes.
+func readZipFile(f *zip.File, max int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%s exceeds %d bytes", f.Name, max)
+	}
+	return data, nil
+}
+
+// hashZipFile returns the size and the hex encoded SHA-256 hash of f. At most
+// max+1 bytes are read, so a size above max means the file is larger.
+func hashZipFile(f *zip.File, max int64) (int64, string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	n, err := io.Copy(hash, io.LimitReader(rc, max+1))
+	if err != nil {
+		return n, "", err
+	}
+	return n, hex.EncodeToString(hash.Sum(nil)), nil
+}
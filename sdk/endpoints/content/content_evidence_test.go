@@ -0,0 +1,153 @@
+package content_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+var evidencePdf = []byte("%PDF-1.4 evidence")
+
+// evidenceServer serves a delivered document with two signatures; the
+// delivery certificate does not exist.
+func evidenceServer(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/content/document/doc-1":
+			writeJson(w, 200, map[string]any{
+				"content": []map[string]any{{"content": base64.StdEncoding.EncodeToString(evidencePdf), "content_type": "application/pdf"}},
+				"meta":    map[string]any{"subject": "Vertrag", "delivered": true},
+			})
+		case "/v1/content/document/doc-1/actions":
+			writeJson(w, 200, map[string]any{"signatures": map[string]any{
+				"document_signatures": map[string]any{"signature_ids": `["sig/2"]`},
+				"embedded_signatures": []map[string]any{{"id": "sig-1"}, {"id": "sig/2"}},
+			}})
+		case "/v1/content/document/doc-1/delivery_status":
+			writeJson(w, 200, map[string]any{"delivery_status": map[string]any{"delivery_mode": "brifle", "document_id": "doc-1"}})
+		case "/v1/content/document/doc-1/delivery_certificate":
+			writeJson(w, 404, api.ResponseError{Code: 40400, Message: "no certificate"})
+		case "/v1/signature/sig-1/export/xml", "/v1/signature/sig/2/export/xml":
+			w.WriteHeader(200)
+			_, _ = io.WriteString(w, "<Signature>"+r.URL.Path+"</Signature>")
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(500)
+		}
+	}
+}
+
+func TestExportEvidence(t *testing.T) {
+	client := mockClient(t, evidenceServer(t))
+	var buf bytes.Buffer
+	documentId := "doc-1"
+	manifest, err := content.ExportEvidence(client, context.Background(), &documentId, &buf)
+	if err != nil {
+		t.Errorf("ExportEvidence failed: %v", err)
+		return
+	}
+
+	paths := map[string]bool{}
+	for _, f := range manifest.Files {
+		paths[f.Path] = true
+	}
+	for _, p := range []string{"content/1.pdf", "meta.json", "actions.json", "delivery_status.json", "signatures/" + hex.EncodeToString([]byte("sig-1")) + ".xml", "signatures/" + hex.EncodeToString([]byte("sig/2")) + ".xml"} {
+		if !paths[p] {
+			t.Errorf("Expected %s in the manifest, got %+v", p, manifest.Files)
+		}
+	}
+	if len(manifest.Missing) != 1 || manifest.Missing[0].Part != content.EvidencePartDeliveryCertificate || manifest.Missing[0].HttpStatus != 404 {
+		t.Errorf("Expected the delivery certificate to be missing, got %+v", manifest.Missing)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Errorf("Bundle is not a zip file: %v", err)
+		return
+	}
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Errorf("Expected an uncompressed mimetype first, got %s", zr.File[0].Name)
+	}
+
+	res, err := content.VerifyEvidence(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Errorf("VerifyEvidence failed: %v", err)
+		return
+	}
+	if !res.Valid || res.Manifest == nil || res.Manifest.DocumentId != "doc-1" {
+		t.Errorf("Expected a valid bundle, got %+v", res.Problems)
+	}
+}
+
+func TestVerifyEvidenceDetectsTampering(t *testing.T) {
+	client := mockClient(t, evidenceServer(t))
+	var buf bytes.Buffer
+	documentId := "doc-1"
+	if _, err := content.ExportEvidence(client, context.Background(), &documentId, &buf); err != nil {
+		t.Errorf("ExportEvidence failed: %v", err)
+		return
+	}
+
+	// rewrite the bundle with a modified content file, an inflated meta.json
+	// and an extra file
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tampered bytes.Buffer
+	zw := zip.NewWriter(&tampered)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if f.Name == "content/1.pdf" {
+			data = []byte(strings.Replace(string(data), "evidence", "forgery!", 1))
+		}
+		if f.Name == "meta.json" {
+			data = bytes.Repeat([]byte(" "), 64<<20)
+		}
+		w, _ := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: f.Method})
+		_, _ = w.Write(data)
+	}
+	w, _ := zw.Create("extra.txt")
+	_, _ = w.Write([]byte("extra"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := content.VerifyEvidence(bytes.NewReader(tampered.Bytes()), int64(tampered.Len()))
+	if err != nil {
+		t.Errorf("VerifyEvidence failed: %v", err)
+		return
+	}
+	problems := map[string]bool{}
+	for _, p := range res.Problems {
+		problems[p.Path] = true
+	}
+	if res.Valid || !problems["content/1.pdf"] || !problems["meta.json"] || !problems["extra.txt"] || len(res.Problems) != 3 {
+		t.Errorf("Expected problems with content/1.pdf, meta.json and extra.txt, got %+v", res.Problems)
+	}
+}
+
+func TestExportEvidenceRequiresContent(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 404, api.ResponseError{Code: 40400, Message: "not found"})
+	})
+	var buf bytes.Buffer
+	documentId := "doc-1"
+	if _, err := content.ExportEvidence(client, context.Background(), &documentId, &buf); err == nil || buf.Len() != 0 {
+		t.Errorf("Expected an error and no output, got %v and %d bytes", err, buf.Len())
+	}
+}
@@ -0,0 +1,347 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+// Outcomes of a fallback decision.
+const (
+	// FallbackElectronic means the receiver is on Brifle; physical delivery
+	// is disabled.
+	FallbackElectronic = "electronic"
+	// FallbackPhysical means the receiver is not on Brifle and physical
+	// delivery is enabled.
+	FallbackPhysical = "physical"
+	// FallbackUndeliverable means the receiver is not on Brifle, but the
+	// policy or the postal address does not allow paper mail.
+	FallbackUndeliverable = "undeliverable"
+	// FallbackUnknown means the receiver check failed; physical delivery is
+	// disabled.
+	FallbackUnknown = "unknown"
+)
+
+// CoverLetterChoice names a cover letter template.
+type CoverLetterChoice struct {
+	// Type is CoverLetterDefault or CoverLetterCustom.
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Preview returns the cover letter configuration for PreviewPaperMail.
+func (c *CoverLetterChoice) Preview() *PreviewCoverLetter {
+	if c == nil {
+		return &PreviewCoverLetter{Enable: false}
+	}
+	return &PreviewCoverLetter{Enable: true, Type: &c.Type, Name: &c.Name}
+}
+
+// FallbackPolicy decides when a tenant's documents fall back to paper mail.
+// The zero value allows paper mail for all document types to addresses in
+// Germany, without a cover letter.
+type FallbackPolicy struct {
+	// Disabled never enables physical delivery.
+	Disabled bool
+	// Types limits physical delivery to these document types, e.g. Invoice.
+	// All types if empty.
+	Types []string
+	// Countries are the ISO 3166-1 alpha-2 codes of accepted postal
+	// addresses. Defaults to "DE".
+	Countries []string
+	// CoverLetters selects the cover letter by document type.
+	CoverLetters map[string]CoverLetterChoice
+	// DefaultCoverLetter is used for types without an entry in CoverLetters.
+	// No cover letter if nil.
+	DefaultCoverLetter *CoverLetterChoice
+}
+
+// FallbackDecision records why physical delivery was enabled or not for a
+// document.
+type FallbackDecision struct {
+	// Key identifies the document, e.g. the key of a BulkJob.
+	Key    string `json:"key,omitempty"`
+	Tenant string `json:"tenant,omitempty"`
+	// DocumentType is the type of the request, e.g. Invoice.
+	DocumentType string `json:"document_type,omitempty"`
+	// Outcome is FallbackElectronic, FallbackPhysical, FallbackUndeliverable
+	// or FallbackUnknown.
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	// ReceiverType is the type under which the receiver was found, e.g.
+	// "email".
+	ReceiverType string `json:"receiver_type,omitempty"`
+	// CoverLetter is the cover letter selected for physical delivery.
+	CoverLetter *CoverLetterChoice `json:"cover_letter,omitempty"`
+	// HttpStatus and ErrorCode are those of the receiver check.
+	HttpStatus int       `json:"http_status,omitempty"`
+	ErrorCode  int       `json:"error_code,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// FallbackRecorder persists fallback decisions. Implementations must be
+// safe for concurrent use.
+type FallbackRecorder interface {
+	Record(decision FallbackDecision) error
+}
+
+// FallbackEngine sets Fallback.EnabledPhysicalDelivery of send requests by
+// checking the receivers first: physical delivery is enabled only for
+// receivers that are not on Brifle, if the tenant's policy allows it and a
+// valid postal address is set in Fallback.PaperMail. In all other cases it
+// is disabled, so that no document is printed by accident.
+type FallbackEngine struct {
+	Client *sdkClient.BrifleClient
+	// Policies configures each tenant.
+	Policies map[string]*FallbackPolicy
+	// DefaultPolicy applies to tenants without an entry in Policies. If nil,
+	// the zero FallbackPolicy is used.
+	DefaultPolicy *FallbackPolicy
+	// Recorder records every decision. Optional.
+	Recorder FallbackRecorder
+}
+
+// Policy returns the policy of tenant.
+func (e *FallbackEngine) Policy(tenant string) *FallbackPolicy {
+	if p, ok := e.Policies[tenant]; ok && p != nil {
+		return p
+	}
+	if e.DefaultPolicy != nil {
+		return e.DefaultPolicy
+	}
+	return &FallbackPolicy{}
+}
+
+// Apply checks the receiver of req with CheckReceiver and sets its fallback
+// accordingly. The returned error is that of the recorder; a failed check
+// is reported as FallbackUnknown.
+func (e *FallbackEngine) Apply(ctx context.Context, tenant string, key string, req *SendContentRequest) (*FallbackDecision, error) {
+	if req == nil {
+		return nil, errors.New("request is nil")
+	}
+	var check receiverCheck
+	if req.To == nil {
+		check.err = errors.New("request has no receiver")
+	} else {
+		res, status, err := CheckReceiver(e.Client, ctx, req.To)
+		check = receiverCheck{status: status, err: err}
+		if err == nil && statusOk(status) && res != nil && res.ReceiverExistResponse != nil && res.Receiver != nil && res.Receiver.Type != nil {
+			check.found = string(*res.Receiver.Type)
+		}
+	}
+	decision := e.apply(tenant, key, req, check)
+	return &decision, e.record(decision)
+}
+
+// ApplyBulk checks the receivers of all jobs with a ReceiverChecker and sets
+// the fallback of every request. Receivers whose check failed, e.g. because
+// a response did not hold a result for every receiver or the check could
+// not run at all, are decided as FallbackUnknown. Decisions are returned in
+// the order of jobs.
+func (e *FallbackEngine) ApplyBulk(ctx context.Context, tenant string, jobs []BulkJob) ([]FallbackDecision, error) {
+	checks := make([]receiverCheck, len(jobs))
+	var receivers []ReceiverData
+	var indexes []int
+	for i, job := range jobs {
+		if job.Request == nil || job.Request.To == nil {
+			checks[i].err = errors.New("request has no receiver")
+			continue
+		}
+		receivers = append(receivers, *job.Request.To)
+		indexes = append(indexes, i)
+	}
+	if len(receivers) > 0 {
+		// failures are reported per receiver in the results, unless the
+		// check could not run at all, e.g. without a client
+		results, checkErr := (&ReceiverChecker{Client: e.Client}).Check(ctx, receivers)
+		if len(results) != len(receivers) {
+			if checkErr == nil {
+				checkErr = errors.New("receiver check returned no results")
+			}
+			results = make([]ReceiverCheckResult, len(receivers))
+			for n := range results {
+				results[n] = ReceiverCheckResult{Index: n, Err: checkErr}
+			}
+		}
+		for n, i := range indexes {
+			res := results[n]
+			checks[i] = receiverCheck{err: res.Err}
+			if res.HttpStatus != 0 {
+				checks[i].status = &api.ResponseStatus{HttpStatus: res.HttpStatus, ErrorCode: res.ErrorCode}
+			}
+			switch {
+			case res.Err != nil:
+			case res.Found:
+				checks[i].found = res.ReceiverType
+			default:
+				// the bulk check answers 200 for the whole request and
+				// reports missing receivers without a type
+				checks[i].missing = true
+			}
+		}
+	}
+
+	decisions := make([]FallbackDecision, 0, len(jobs))
+	for i, job := range jobs {
+		if job.Request == nil {
+			return decisions, fmt.Errorf("job %d has no request", i)
+		}
+		decision := e.apply(tenant, job.Key, job.Request, checks[i])
+		decisions = append(decisions, decision)
+		if err := e.record(decision); err != nil {
+			return decisions, err
+		}
+	}
+	return decisions, nil
+}
+
+// receiverCheck is the outcome of a receiver check.
+type receiverCheck struct {
+	// found is the receiver type if the receiver is on Brifle.
+	found string
+	// missing is set when a bulk check reports the receiver as not found.
+	missing bool
+	status  *api.ResponseStatus
+	err     error
+}
+
+func (e *FallbackEngine) apply(tenant string, key string, req *SendContentRequest, check receiverCheck) FallbackDecision {
+	policy := e.Policy(tenant)
+	decision := FallbackDecision{
+		Key:          key,
+		Tenant:       tenant,
+		DocumentType: strVal(req.Type),
+		ReceiverType: check.found,
+		Time:         time.Now().UTC(),
+	}
+	if check.status != nil {
+		decision.HttpStatus = check.status.HttpStatus
+		decision.ErrorCode = check.status.ErrorCode
+	}
+
+	switch {
+	case check.found != "":
+		decision.Outcome = FallbackElectronic
+	case check.err != nil:
+		decision.Outcome = FallbackUnknown
+		decision.Reason = check.err.Error()
+	case !check.missing && !receiverNotFound(check.status):
+		decision.Outcome = FallbackUnknown
+		decision.Reason = "receiver check failed: " + statusText(check.status)
+	case policy.Disabled:
+		decision.Outcome = FallbackUndeliverable
+		decision.Reason = "physical delivery is disabled for the tenant"
+	case len(policy.Types) > 0 && !slices.Contains(policy.Types, decision.DocumentType):
+		decision.Outcome = FallbackUndeliverable
+		decision.Reason = fmt.Sprintf("physical delivery is disabled for type %q", decision.DocumentType)
+	default:
+		if problem := policy.addressProblem(req.Fallback); problem != "" {
+			decision.Outcome = FallbackUndeliverable
+			decision.Reason = problem
+			break
+		}
+		decision.Outcome = FallbackPhysical
+		decision.CoverLetter = policy.coverLetter(decision.DocumentType)
+	}
+
+	if req.Fallback == nil {
+		req.Fallback = &Fallback{}
+	}
+	req.Fallback.EnabledPhysicalDelivery = decision.Outcome == FallbackPhysical
+	return decision
+}
+
+func (e *FallbackEngine) record(decision FallbackDecision) error {
+	if e.Recorder == nil {
+		return nil
+	}
+	return e.Recorder.Record(decision)
+}
+
+// receiverNotFound reports whether a CheckReceiver status means the
+// receiver is not on Brifle.
+func receiverNotFound(status *api.ResponseStatus) bool {
+	return status != nil && (status.ErrorCode == api.ErrorCodeReceiverNotFound ||
+		status.HttpStatus == 404 && status.ErrorCode == 0)
+}
+
+// addressProblem describes why the postal address of fallback cannot be
+// used, or returns "".
+func (p *FallbackPolicy) addressProblem(fallback *Fallback) string {
+	if fallback == nil || fallback.PaperMail == nil || fallback.PaperMail.Recipient == nil {
+		return "no postal address"
+	}
+	block := FormatAddressBlock(nil, fallback.PaperMail.Recipient)
+	for _, problem := range block.Problems {
+		// the layout is checked by PreviewPaperMail
+		if problem.Field != AddressFieldBlock {
+			return "postal address: " + problem.String()
+		}
+	}
+	countries := p.Countries
+	if len(countries) == 0 {
+		countries = []string{"DE"}
+	}
+	if !slices.ContainsFunc(countries, func(c string) bool { return strings.EqualFold(c, block.Country) }) {
+		return fmt.Sprintf("postal addresses in %s are not accepted", block.Country)
+	}
+	return ""
+}
+
+func (p *FallbackPolicy) coverLetter(documentType string) *CoverLetterChoice {
+	if c, ok := p.CoverLetters[documentType]; ok {
+		return &c
+	}
+	if p.DefaultCoverLetter != nil {
+		c := *p.DefaultCoverLetter
+		return &c
+	}
+	return nil
+}
+
+// FileFallbackRecorder is a FallbackRecorder that appends decisions as JSON
+// lines to a local file.
+type FileFallbackRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFileFallbackRecorder opens or creates the file at path for appending.
+func OpenFileFallbackRecorder(path string) (*FileFallbackRecorder, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileFallbackRecorder{file: file}, nil
+}
+
+// Record appends decision to the file.
+func (f *FileFallbackRecorder) Record(decision FallbackDecision) error {
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (f *FileFallbackRecorder) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
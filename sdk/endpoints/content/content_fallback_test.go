@@ -0,0 +1,214 @@
+package content_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+// receiverServer answers receiver checks: emails starting with "on" are on
+// Brifle, "down" fails with 503 and all others are not found. Bulk checks
+// containing a "down" email fail as a whole.
+func receiverServer(t *testing.T) http.HandlerFunc {
+	find := func(r map[string]any) string {
+		addr, _ := r["email"].(string)
+		return addr
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/content/receiver/check":
+			var req map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			switch addr := find(req); {
+			case strings.HasPrefix(addr, "on"):
+				writeJson(w, 200, map[string]any{"receiver": map[string]any{"type": "email"}})
+			case strings.HasPrefix(addr, "down"):
+				writeJson(w, 503, api.ResponseError{Code: 50300, Message: "unavailable"})
+			default:
+				writeJson(w, 404, api.ResponseError{Code: api.ErrorCodeReceiverNotFound, Message: "receiver not found"})
+			}
+		case "/v1/content/receiver/check/bulk":
+			var req struct {
+				Receivers []map[string]any `json:"receivers"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			results := []map[string]any{}
+			for _, rec := range req.Receivers {
+				switch addr := find(rec); {
+				case strings.HasPrefix(addr, "on"):
+					results = append(results, map[string]any{"type": "email"})
+				case strings.HasPrefix(addr, "down"):
+					writeJson(w, 503, api.ResponseError{Code: 50300, Message: "unavailable"})
+					return
+				default:
+					results = append(results, map[string]any{})
+				}
+			}
+			writeJson(w, 200, map[string]any{"receivers": results})
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(500)
+		}
+	}
+}
+
+func fallbackRequest(email string, docType string, postalCode string) *content.SendContentRequest {
+	req := &content.SendContentRequest{
+		To:   &content.ReceiverData{Email: &content.EmailReceiver{Email: sdk.String(email), Name: sdk.String("Max Mustermann")}},
+		Type: sdk.String(docType),
+	}
+	if postalCode != "" {
+		req.Fallback = &content.Fallback{
+			EnabledPhysicalDelivery: true,
+			PaperMail: &content.PaperMail{Recipient: &content.Recipient{
+				AddressLine1: sdk.String("Musterstraße 1"),
+				PostalCode:   sdk.String(postalCode),
+				City:         sdk.String("Berlin"),
+			}},
+		}
+	}
+	return req
+}
+
+func TestFallbackEngineApply(t *testing.T) {
+	engine := &content.FallbackEngine{
+		Client: mockClient(t, receiverServer(t)),
+		Policies: map[string]*content.FallbackPolicy{
+			"tenant-1": {
+				Types:        []string{content.Invoice, content.Letter},
+				CoverLetters: map[string]content.CoverLetterChoice{content.Invoice: {Type: content.CoverLetterCustom, Name: "rechnung"}},
+			},
+			"tenant-2": {Disabled: true},
+		},
+	}
+	tests := []struct {
+		name    string
+		tenant  string
+		req     *content.SendContentRequest
+		outcome string
+		enabled bool
+	}{
+		{"on brifle", "tenant-1", fallbackRequest("on@example.com", content.Invoice, "10115"), content.FallbackElectronic, false},
+		{"not found", "tenant-1", fallbackRequest("off@example.com", content.Invoice, "10115"), content.FallbackPhysical, true},
+		{"check failed", "tenant-1", fallbackRequest("down@example.com", content.Invoice, "10115"), content.FallbackUnknown, false},
+		{"no address", "tenant-1", fallbackRequest("off@example.com", content.Invoice, ""), content.FallbackUndeliverable, false},
+		{"invalid postal code", "tenant-1", fallbackRequest("off@example.com", content.Invoice, "1011"), content.FallbackUndeliverable, false},
+		{"type not allowed", "tenant-1", fallbackRequest("off@example.com", content.Contract, "10115"), content.FallbackUndeliverable, false},
+		{"disabled tenant", "tenant-2", fallbackRequest("off@example.com", content.Invoice, "10115"), content.FallbackUndeliverable, false},
+		{"default policy", "tenant-3", fallbackRequest("off@example.com", content.Contract, "10115"), content.FallbackPhysical, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := engine.Apply(context.Background(), tt.tenant, tt.name, tt.req)
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if decision.Outcome != tt.outcome {
+				t.Errorf("Expected %s, got %s (%s)", tt.outcome, decision.Outcome, decision.Reason)
+			}
+			if tt.req.Fallback == nil || tt.req.Fallback.EnabledPhysicalDelivery != tt.enabled {
+				t.Errorf("Expected physical delivery enabled=%v, got %+v", tt.enabled, tt.req.Fallback)
+			}
+		})
+	}
+
+	decision, _ := engine.Apply(context.Background(), "tenant-1", "inv", fallbackRequest("off@example.com", content.Invoice, "10115"))
+	if decision.CoverLetter == nil || decision.CoverLetter.Name != "rechnung" || decision.ErrorCode != api.ErrorCodeReceiverNotFound {
+		t.Errorf("Expected the invoice cover letter, got %+v", decision)
+	}
+	if preview := decision.CoverLetter.Preview(); !preview.Enable || *preview.Type != content.CoverLetterCustom {
+		t.Errorf("Unexpected preview cover letter %+v", preview)
+	}
+}
+
+func TestFallbackEngineApplyBulk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	recorder, err := content.OpenFileFallbackRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &content.FallbackEngine{Client: mockClient(t, receiverServer(t)), Recorder: recorder}
+	jobs := []content.BulkJob{
+		{Key: "a", Request: fallbackRequest("on@example.com", content.Letter, "10115")},
+		{Key: "b", Request: fallbackRequest("off@example.com", content.Letter, "10115")},
+		{Key: "c", Request: &content.SendContentRequest{Type: sdk.String(content.Letter)}},
+	}
+	decisions, err := engine.ApplyBulk(context.Background(), "tenant-1", jobs)
+	if err != nil {
+		t.Fatalf("ApplyBulk failed: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{content.FallbackElectronic, content.FallbackPhysical, content.FallbackUnknown}
+	for i, d := range decisions {
+		if d.Key != jobs[i].Key || d.Outcome != want[i] {
+			t.Errorf("Decision %d: expected %s, got %+v", i, want[i], d)
+		}
+	}
+	if !jobs[1].Request.Fallback.EnabledPhysicalDelivery || jobs[0].Request.Fallback.EnabledPhysicalDelivery {
+		t.Errorf("Expected physical delivery only for job b")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var recorded []content.FallbackDecision
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var d content.FallbackDecision
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, d)
+	}
+	if len(recorded) != 3 || recorded[1].Key != "b" || recorded[1].Outcome != content.FallbackPhysical {
+		t.Errorf("Unexpected recorded decisions %+v", recorded)
+	}
+}
+
+func TestFallbackEngineApplyBulkShortResponse(t *testing.T) {
+	// the server drops the last result, so that no result can be matched to
+	// its receiver
+	engine := &content.FallbackEngine{Client: mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 200, map[string]any{"receivers": []map[string]any{{"type": "email"}}})
+	})}
+	jobs := []content.BulkJob{
+		{Key: "a", Request: fallbackRequest("on@example.com", content.Letter, "10115")},
+		{Key: "b", Request: fallbackRequest("off@example.com", content.Letter, "10115")},
+	}
+	decisions, err := engine.ApplyBulk(context.Background(), "tenant-1", jobs)
+	if err != nil {
+		t.Fatalf("ApplyBulk failed: %v", err)
+	}
+	for i, d := range decisions {
+		if d.Outcome != content.FallbackUnknown || jobs[i].Request.Fallback.EnabledPhysicalDelivery {
+			t.Errorf("Decision %d: expected %s without physical delivery, got %+v", i, content.FallbackUnknown, d)
+		}
+	}
+}
+
+func TestFallbackEngineApplyBulkWithoutClient(t *testing.T) {
+	// the check can not run, so no receiver can be decided
+	engine := &content.FallbackEngine{}
+	jobs := []content.BulkJob{{Key: "a", Request: fallbackRequest("on@example.com", content.Letter, "10115")}}
+	decisions, err := engine.ApplyBulk(context.Background(), "tenant-1", jobs)
+	if err != nil {
+		t.Fatalf("ApplyBulk failed: %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Outcome != content.FallbackUnknown || jobs[0].Request.Fallback.EnabledPhysicalDelivery {
+		t.Errorf("Expected %s without physical delivery, got %+v", content.FallbackUnknown, decisions)
+	}
+}
@@ -0,0 +1,471 @@
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/mailbox"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/jsonl"
+)
+
+// States of an idempotency record.
+const (
+	// IdempotencyPending means a send was started but its outcome is unknown,
+	// e.g. because the request timed out.
+	IdempotencyPending = "pending"
+	// IdempotencySent means the document was accepted.
+	IdempotencySent = "sent"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is used again for a
+	// different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+	// ErrOutcomeUnknown is returned when an earlier send with the same key may
+	// have been accepted, but the document could not be identified in the
+	// outbox unambiguously, e.g. because its receiver cannot be compared.
+	// Check the outbox manually, then Forget the key to send again.
+	ErrOutcomeUnknown = errors.New("outcome of an earlier send with the same idempotency key is unknown")
+)
+
+// IdempotencyRecord is the stored state of an idempotency key.
+type IdempotencyRecord struct {
+	Key   string `json:"key"`
+	State string `json:"state"`
+	// Fingerprint is the derived key of the request, used to detect a key
+	// reused for a different request.
+	Fingerprint string `json:"fingerprint"`
+	DocumentId  string `json:"document_id,omitempty"`
+	// Subject and Type identify the document in the outbox when the outcome
+	// is unknown.
+	Subject   string    `json:"subject,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// IdempotencyStore persists idempotency records. Implementations must be
+// safe for concurrent use.
+type IdempotencyStore interface {
+	// Get returns the record of key, or nil if there is none.
+	Get(key string) (*IdempotencyRecord, error)
+	// Put stores record, replacing an existing record with the same key.
+	Put(record IdempotencyRecord) error
+	// Delete removes the record of key.
+	Delete(key string) error
+}
+
+// IdempotencyKey derives a key from the receiver, the subject, the type and a
+// hash of the content of req. Sending the same document to the same receiver
+// again yields the same key.
+func IdempotencyKey(req *SendContentRequest) string {
+	hash := sha256.New()
+	write := func(v any) {
+		data, _ := json.Marshal(v)
+		hash.Write(data)
+		hash.Write([]byte{0})
+	}
+	if req == nil {
+		return ""
+	}
+	write(req.To)
+	write(strVal(req.Subject))
+	write(strVal(req.Type))
+	if req.Body != nil {
+		for _, item := range *req.Body {
+			write(item.Type)
+			write(item.Content)
+		}
+	}
+	write(req.PaymentInfo)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IdempotentSender sends documents at most once per idempotency key. A repeated
+// send with the same key returns the response of the first one. When the
+// outcome of the first send is unknown, the outbox is searched for the
+// document before sending it again. Outbox documents only name their receiver
+// by account id, so a document found there is only taken as the result when
+// MatchReceiver confirms its receiver.
+//
+//	sender := &content.IdempotentSender{Client: client, Tenant: tenant, Store: store}
+//	res, respStatus, err := sender.Send(ctx, "invoice-2024-0815", &req)
+type IdempotentSender struct {
+	Client *sdkClient.BrifleClient
+	Tenant string
+	Store  IdempotencyStore
+	// ClockSkew is the tolerance when matching the sent date of outbox
+	// documents against the start of an unknown send. Defaults to 5 minutes.
+	ClockSkew time.Duration
+	// MaxPages is the number of outbox pages searched when reconciling.
+	// Defaults to 5.
+	MaxPages int
+	// MatchReceiver reports whether item, an outbox document with the
+	// subject and type of req sent since the first attempt, was sent to the
+	// receiver of req, e.g. by comparing item.Receiver with the account id
+	// of a customer. Without it, or if it fails, such documents make Send
+	// return ErrOutcomeUnknown instead of sending again. Optional.
+	MatchReceiver func(ctx context.Context, req *SendContentRequest, item *api.Item) (bool, error)
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock serializes sends with the same key. It is removed from
+// IdempotentSender.locks when the last holder or waiter releases it.
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks key and returns the function that unlocks it.
+func (s *IdempotentSender) lock(key string) func() {
+	s.mu.Lock()
+	if s.locks == nil {
+		s.locks = map[string]*keyLock{}
+	}
+	l, ok := s.locks[key]
+	if !ok {
+		l = &keyLock{}
+		s.locks[key] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Send sends req unless a document was already sent with key. An empty key is
+// derived with IdempotencyKey.
+//
+// A response replayed from the store has Replayed set and a synthetic
+// respStatus with HTTP status 200.
+func (s *IdempotentSender) Send(ctx context.Context, key string, req *SendContentRequest) (*SendDocumentResponse, *api.ResponseStatus, error) {
+	if s.Client == nil {
+		return nil, nil, errors.New("client is required")
+	}
+	if s.Store == nil {
+		return nil, nil, errors.New("store is required")
+	}
+	// reject requests SendContent would reject before sending, so that every
+	// error after the record is stored means the outcome is unknown
+	if err := validateSendRequest(req); err != nil {
+		return nil, nil, err
+	}
+	fingerprint := IdempotencyKey(req)
+	if key == "" {
+		key = fingerprint
+	}
+
+	// serialize sends with the same key within this process
+	defer s.lock(key)()
+
+	record, err := s.Store.Get(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading idempotency store: %w", err)
+	}
+	if record != nil {
+		if record.Fingerprint != fingerprint {
+			return nil, nil, ErrIdempotencyKeyReused
+		}
+		switch record.State {
+		case IdempotencySent:
+			return replayed(record.DocumentId), &api.ResponseStatus{HttpStatus: 200}, nil
+		case IdempotencyPending:
+			documentId, err := s.reconcile(ctx, record, req)
+			if err != nil {
+				return nil, nil, err
+			}
+			if documentId != "" {
+				record.State = IdempotencySent
+				record.DocumentId = documentId
+				if err := s.Store.Put(*record); err != nil {
+					return nil, nil, fmt.Errorf("writing idempotency store: %w", err)
+				}
+				return replayed(documentId), &api.ResponseStatus{HttpStatus: 200}, nil
+			}
+		}
+	}
+
+	record = &IdempotencyRecord{
+		Key:         key,
+		State:       IdempotencyPending,
+		Fingerprint: fingerprint,
+		Subject:     strVal(req.Subject),
+		Type:        strVal(req.Type),
+		StartedAt:   time.Now().UTC(),
+	}
+	if err := s.Store.Put(*record); err != nil {
+		return nil, nil, fmt.Errorf("writing idempotency store: %w", err)
+	}
+
+	tenant := s.Tenant
+	res, status, err := SendContent(s.Client, ctx, &tenant, req)
+	switch {
+	case err == nil && status != nil && status.HttpStatus >= 200 && status.HttpStatus < 300:
+		record.State = IdempotencySent
+		if res != nil && res.ContentCreateResponse != nil && res.Id != nil {
+			record.DocumentId = *res.Id
+		}
+		if putErr := s.Store.Put(*record); putErr != nil {
+			return res, status, fmt.Errorf("writing idempotency store: %w", putErr)
+		}
+	case err == nil && status != nil && status.HttpStatus < 500 && status.HttpStatus != 408 && status.HttpStatus != 429:
+		// rejected: nothing was sent, so the key may be used again
+		if delErr := s.Store.Delete(key); delErr != nil {
+			return res, status, fmt.Errorf("writing idempotency store: %w", delErr)
+		}
+	}
+	// otherwise the outcome is unknown and the record stays pending
+	return res, status, err
+}
+
+// Forget removes the record of key so that the next Send sends again.
+func (s *IdempotentSender) Forget(key string) error {
+	if s.Store == nil {
+		return errors.New("store is required")
+	}
+	return s.Store.Delete(key)
+}
+
+// reconcile searches the outbox for the document of a pending record sent
+// with req. It returns an empty id if no document was found, and
+// ErrOutcomeUnknown if documents were found whose receiver cannot be
+// confirmed, or more than one document matches.
+func (s *IdempotentSender) reconcile(ctx context.Context, record *IdempotencyRecord, req *SendContentRequest) (string, error) {
+	skew := s.ClockSkew
+	if skew <= 0 {
+		skew = 5 * time.Minute
+	}
+	maxPages := s.MaxPages
+	if maxPages <= 0 {
+		maxPages = 5
+	}
+	earliest := record.StartedAt.Add(-skew)
+
+	tenant := s.Tenant
+	var candidates []*api.Item
+	seen := map[string]bool{}
+	for p := 1; p <= maxPages; p++ {
+		page := float32(p)
+		search := &mailbox.OutboxSearch{Page: &page}
+		if record.Subject != "" {
+			search.Filter = &mailbox.OutboxFilter{Subject: &record.Subject}
+		}
+		res, status, err := mailbox.SearchOutbox(s.Client, ctx, &tenant, search)
+		if err != nil {
+			return "", fmt.Errorf("%w: searching outbox: %v", ErrOutcomeUnknown, err)
+		}
+		if status == nil || status.HttpStatus != 200 || res == nil || len(res.Results) == 0 {
+			break
+		}
+		older := false
+		for _, item := range res.Results {
+			if item == nil || item.Item == nil || item.Id == nil {
+				continue
+			}
+			sent, err := time.Parse(time.RFC3339, strVal(item.SentDate))
+			if err != nil {
+				continue
+			}
+			if sent.Before(earliest) {
+				older = true
+				continue
+			}
+			if strVal(item.Subject) != record.Subject {
+				continue
+			}
+			if record.Type != "" && item.Type != nil && *item.Type != record.Type {
+				continue
+			}
+			if !seen[*item.Id] {
+				seen[*item.Id] = true
+				candidates = append(candidates, item.Item)
+			}
+		}
+		// the outbox is ordered by date, newest first
+		if older {
+			break
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", nil
+	}
+	// other receivers of a mail merge get documents with the same subject
+	if s.MatchReceiver == nil {
+		return "", fmt.Errorf("%w: %d documents in the outbox match, but their receiver cannot be compared", ErrOutcomeUnknown, len(candidates))
+	}
+	var matches []string
+	for _, item := range candidates {
+		ok, err := s.MatchReceiver(ctx, req, item)
+		if err != nil {
+			return "", fmt.Errorf("%w: matching receiver of document %s: %v", ErrOutcomeUnknown, *item.Id, err)
+		}
+		if ok {
+			matches = append(matches, *item.Id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%w: %d documents in the outbox match", ErrOutcomeUnknown, len(matches))
+}
+
+func replayed(documentId string) *SendDocumentResponse {
+	id := documentId
+	return &SendDocumentResponse{ContentCreateResponse: &api.ContentCreateResponse{Id: &id}, Replayed: true}
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory. Records are
+// lost when the process ends, so it only protects against retries within a
+// process.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+}
+
+// Get returns the record of key, or nil if there is none.
+func (m *MemoryIdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores record.
+func (m *MemoryIdempotencyStore) Put(record IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.Key] = record
+	return nil
+}
+
+// Delete removes the record of key.
+func (m *MemoryIdempotencyStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+// FileIdempotencyStore is an IdempotencyStore kept in memory and persisted as
+// JSON lines in a local file. Every change is synced to disk before it
+// returns. The file is compacted when it holds mostly replaced records.
+type FileIdempotencyStore struct {
+	memory MemoryIdempotencyStore
+	file   *jsonl.File
+}
+
+// OpenFileIdempotencyStore opens or creates the store file at path.
+func OpenFileIdempotencyStore(path string) (*FileIdempotencyStore, error) {
+	store := &FileIdempotencyStore{memory: MemoryIdempotencyStore{records: map[string]IdempotencyRecord{}}}
+	file, err := jsonl.Open(path, func(_ int, data []byte) error {
+		var record IdempotencyRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		// a record without state marks a deleted key
+		if record.State == "" {
+			delete(store.memory.records, record.Key)
+		} else {
+			store.memory.records[record.Key] = record
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("idempotency store: %w", err)
+	}
+	store.file = file
+	return store, nil
+}
+
+// Get returns the record of key, or nil if there is none.
+func (f *FileIdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+	return f.memory.Get(key)
+}
+
+// Put appends record to the file and syncs it to disk.
+func (f *FileIdempotencyStore) Put(record IdempotencyRecord) error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	if err := f.file.Append(record); err != nil {
+		return err
+	}
+	f.memory.records[record.Key] = record
+	// the record is stored; a failed compaction is retried with the next change
+	_ = f.compact(false)
+	return nil
+}
+
+// Delete appends a deletion marker for key to the file and syncs it to disk.
+func (f *FileIdempotencyStore) Delete(key string) error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	if _, ok := f.memory.records[key]; !ok {
+		return nil
+	}
+	if err := f.file.Append(IdempotencyRecord{Key: key}); err != nil {
+		return err
+	}
+	delete(f.memory.records, key)
+	_ = f.compact(false)
+	return nil
+}
+
+// Compact rewrites the file with the current records only.
+func (f *FileIdempotencyStore) Compact() error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.compact(true)
+}
+
+func (f *FileIdempotencyStore) compact(force bool) error {
+	if !force && !f.file.NeedsCompaction(len(f.memory.records)) {
+		return nil
+	}
+	keys := make([]string, 0, len(f.memory.records))
+	for key := range f.memory.records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	records := make([]any, len(keys))
+	for i, key := range keys {
+		records[i] = f.memory.records[key]
+	}
+	return f.file.Rewrite(records)
+}
+
+// Close closes the store file.
+func (f *FileIdempotencyStore) Close() error {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.file.Close()
+}
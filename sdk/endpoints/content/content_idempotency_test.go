@@ -0,0 +1,234 @@
+package content_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// idempotencyServer accepts every document but answers the first send with
+// 504, as a gateway timing out after the document was stored would. Accepted
+// documents are listed in the outbox when outbox is true, with the account
+// "account-<email>" as receiver, which the returned sender's MatchReceiver
+// compares.
+func idempotencyServer(t *testing.T, outbox bool) (*content.IdempotentSender, *int) {
+	var (
+		mu    sync.Mutex
+		sends int
+		items []api.Item
+	)
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(r.URL.Path, "/mailbox/outbox/") {
+			var search api.MyOutboxRequest
+			_ = json.NewDecoder(r.Body).Decode(&search)
+			results := []api.Item{}
+			if outbox && (search.Page == nil || *search.Page == 1) {
+				results = items
+			}
+			writeJson(w, 200, map[string]any{"total": len(results), "results": results})
+			return
+		}
+		var req api.ApiSendContentSendContentRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sends++
+		id := "doc-" + string(rune('0'+sends))
+		items = append(items, api.Item{
+			Id:       sdk.String(id),
+			Receiver: sdk.String("account-" + strVal(req.To.Email)),
+			Subject:  sdk.String(req.Subject),
+			Type:     sdk.String(string(req.Type)),
+			SentDate: sdk.String(time.Now().UTC().Format(time.RFC3339)),
+		})
+		if sends == 1 {
+			writeJson(w, 504, api.ResponseError{Code: 50400})
+			return
+		}
+		writeJson(w, 200, api.ContentCreateResponse{Id: sdk.String(id)})
+	})
+	sender := &content.IdempotentSender{Client: client, Tenant: "tenant", Store: content.NewMemoryIdempotencyStore(), MatchReceiver: matchAccount}
+	return sender, &sends
+}
+
+// matchAccount matches outbox documents of the account "account-<email>".
+func matchAccount(_ context.Context, req *content.SendContentRequest, item *api.Item) (bool, error) {
+	return strVal(item.Receiver) == "account-"+strVal(req.To.Email.Email), nil
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestIdempotentSenderReconcilesFromOutbox(t *testing.T) {
+	sender, sends := idempotencyServer(t, true)
+	req := bulkJob("", "Rechnung 1").Request
+
+	_, status, err := sender.Send(context.Background(), "inv-1", req)
+	if err != nil || status.HttpStatus != 504 {
+		t.Errorf("Expected a 504 on the first send, got %v %v", status, err)
+		return
+	}
+	res, status, err := sender.Send(context.Background(), "inv-1", req)
+	if err != nil || status.HttpStatus != 200 {
+		t.Errorf("Expected the retry to succeed, got %v %v", status, err)
+		return
+	}
+	if !res.Replayed || *res.Id != "doc-1" {
+		t.Errorf("Expected doc-1 from the outbox, got %+v (replayed %v)", *res.Id, res.Replayed)
+	}
+	if *sends != 1 {
+		t.Errorf("Expected 1 send, got %d", *sends)
+	}
+
+	// the outcome is now stored
+	res, _, err = sender.Send(context.Background(), "inv-1", req)
+	if err != nil || *res.Id != "doc-1" || *sends != 1 {
+		t.Errorf("Expected a replay of doc-1, got %v %v after %d sends", res, err, *sends)
+	}
+}
+
+func TestIdempotentSenderConcurrentSends(t *testing.T) {
+	sender, sends := idempotencyServer(t, true)
+	req := bulkJob("", "Rechnung 5").Request
+
+	// sends with the same key wait for the first one and reconcile its
+	// unknown outcome instead of sending again
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = sender.Send(context.Background(), "inv-5", req)
+		}()
+	}
+	wg.Wait()
+	if *sends != 1 {
+		t.Errorf("Expected 1 send, got %d", *sends)
+	}
+	res, _, err := sender.Send(context.Background(), "inv-5", req)
+	if err != nil || !res.Replayed || *res.Id != "doc-1" {
+		t.Errorf("Expected a replay of doc-1, got %v %v", res, err)
+	}
+}
+
+func TestIdempotentSenderRejectsInvalidRequest(t *testing.T) {
+	sender, sends := idempotencyServer(t, true)
+	req := bulkJob("", "Rechnung 6").Request
+	req.PaymentInfo = &content.PaymentInfo{Details: &content.PaymentDetails{
+		Amount:    &payments.Money{Amount: 1250, Currency: "EUR"},
+		Iban:      sdk.String("DE89370400440532013001"),
+		Reference: sdk.String("R-6"),
+	}}
+
+	// an invalid request is rejected before the key is stored, so that the
+	// corrected request can be sent with the same key
+	if _, _, err := sender.Send(context.Background(), "inv-6", req); !errors.Is(err, payments.ErrInvalidIban) {
+		t.Fatalf("Expected ErrInvalidIban, got %v", err)
+	}
+	req.PaymentInfo.Details.Iban = sdk.String("DE89370400440532013000")
+	if _, _, err := sender.Send(context.Background(), "inv-6", req); err != nil {
+		t.Errorf("Expected the corrected request to be sent, got %v", err)
+	}
+	if *sends != 1 {
+		t.Errorf("Expected 1 send, got %d", *sends)
+	}
+}
+
+func TestIdempotentSenderMatchesReceiver(t *testing.T) {
+	// the outbox holds the document of another receiver of a mail merge
+	// with the same subject
+	sends := 0
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/mailbox/outbox/") {
+			writeJson(w, 200, map[string]any{"total": 1, "results": []api.Item{{
+				Id:       sdk.String("doc-other"),
+				Receiver: sdk.String("account-erika@example.com"),
+				Subject:  sdk.String("Rechnung 7"),
+				Type:     sdk.String(content.Letter),
+				SentDate: sdk.String(time.Now().UTC().Format(time.RFC3339)),
+			}}})
+			return
+		}
+		if sends++; sends == 1 {
+			writeJson(w, 504, api.ResponseError{Code: 50400})
+			return
+		}
+		writeJson(w, 200, api.ContentCreateResponse{Id: sdk.String("doc-max")})
+	})
+	sender := &content.IdempotentSender{Client: client, Tenant: "tenant", Store: content.NewMemoryIdempotencyStore()}
+	req := bulkJob("", "Rechnung 7").Request
+
+	_, _, _ = sender.Send(context.Background(), "inv-7", req)
+	if _, _, err := sender.Send(context.Background(), "inv-7", req); !errors.Is(err, content.ErrOutcomeUnknown) {
+		t.Errorf("Expected ErrOutcomeUnknown without MatchReceiver, got %v", err)
+	}
+	sender.MatchReceiver = matchAccount
+	res, _, err := sender.Send(context.Background(), "inv-7", req)
+	if err != nil || res.Replayed || *res.Id != "doc-max" || sends != 2 {
+		t.Errorf("Expected the document to be sent again, got %v %v after %d sends", res, err, sends)
+	}
+}
+
+func TestIdempotentSenderResendsWhenNotInOutbox(t *testing.T) {
+	sender, sends := idempotencyServer(t, false)
+	req := bulkJob("", "Rechnung 2").Request
+
+	_, _, _ = sender.Send(context.Background(), "", req)
+	res, status, err := sender.Send(context.Background(), "", req)
+	if err != nil || status.HttpStatus != 200 || res.Replayed || *res.Id != "doc-2" {
+		t.Errorf("Expected a second send, got %v %v", status, err)
+	}
+	if *sends != 2 {
+		t.Errorf("Expected 2 sends, got %d", *sends)
+	}
+}
+
+func TestIdempotentSenderRejectsReusedKey(t *testing.T) {
+	sender, _ := idempotencyServer(t, true)
+	_, _, _ = sender.Send(context.Background(), "inv-3", bulkJob("", "Rechnung 3").Request)
+	_, _, err := sender.Send(context.Background(), "inv-3", bulkJob("", "Rechnung 4").Request)
+	if !errors.Is(err, content.ErrIdempotencyKeyReused) {
+		t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+	}
+}
+
+func TestFileIdempotencyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.jsonl")
+	store, err := content.OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Put(content.IdempotencyRecord{Key: "a", State: content.IdempotencyPending})
+	_ = store.Put(content.IdempotencyRecord{Key: "a", State: content.IdempotencySent, DocumentId: "doc-a"})
+	_ = store.Put(content.IdempotencyRecord{Key: "b", State: content.IdempotencySent, DocumentId: "doc-b"})
+	_ = store.Delete("b")
+	_ = store.Close()
+
+	store, err = content.OpenFileIdempotencyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	a, _ := store.Get("a")
+	if a == nil || a.DocumentId != "doc-a" {
+		t.Errorf("Expected the latest record of a, got %+v", a)
+	}
+	if b, _ := store.Get("b"); b != nil {
+		t.Errorf("Expected b to be deleted, got %+v", b)
+	}
+}
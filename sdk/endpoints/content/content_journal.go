@@ -0,0 +1,120 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/internal/jsonl"
+)
+
+// Journal states of a bulk send job.
+const (
+	JournalStarted = "started"
+	JournalSent    = "sent"
+	// JournalFailed means the document was not delivered.
+	JournalFailed = "failed"
+	// JournalUnknown means the request failed after it may have reached the
+	// server, e.g. with a timeout or a 5xx response.
+	JournalUnknown = "unknown"
+)
+
+// JournalEntry is a single progress record of a bulk send job.
+type JournalEntry struct {
+	Key        string    `json:"key"`
+	State      string    `json:"state"`
+	DocumentId string    `json:"document_id,omitempty"`
+	HttpStatus int       `json:"http_status,omitempty"`
+	ErrorCode  int       `json:"error_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Journal durably records the progress of bulk send jobs so that an
+// interrupted run can be resumed. Implementations must be safe for
+// concurrent use.
+type Journal interface {
+	// Load returns the latest entry of every job key.
+	Load() (map[string]JournalEntry, error)
+	// Record persists entry before returning.
+	Record(entry JournalEntry) error
+}
+
+// FileJournal is a Journal stored as JSON lines in a local file. Every entry
+// is synced to disk before Record returns.
+type FileJournal struct {
+	mu   sync.Mutex
+	path string
+	file *jsonl.File
+}
+
+// OpenFileJournal opens or creates the journal file at path. Reuse the same
+// path to resume a run.
+func OpenFileJournal(path string) (*FileJournal, error) {
+	file, err := jsonl.Open(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &FileJournal{path: path, file: file}, nil
+}
+
+// Load reads the journal and returns the latest entry of every key.
+func (j *FileJournal) Load() (map[string]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.load()
+}
+
+func (j *FileJournal) load() (map[string]JournalEntry, error) {
+	entries := map[string]JournalEntry{}
+	err := jsonl.Read(j.path, func(_ int, data []byte) error {
+		var entry JournalEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		entries[entry.Key] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+	return entries, nil
+}
+
+// Record appends entry to the journal and syncs it to disk.
+func (j *FileJournal) Record(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Append(entry)
+}
+
+// Compact rewrites the journal with the latest entry of every key only. Run
+// it between runs; the journal of a finished run can also be deleted.
+func (j *FileJournal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries, err := j.load()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	records := make([]any, len(keys))
+	for i, key := range keys {
+		records[i] = entries[key]
+	}
+	return j.file.Rewrite(records)
+}
+
+// Close closes the journal file.
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
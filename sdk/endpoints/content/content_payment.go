@@ -0,0 +1,189 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// PaymentActionDetails are the payment details of a received invoice, see
+// ContentActions.
+type PaymentActionDetails struct {
+	// Amount is the amount to pay.
+	Amount *payments.Money `json:"-"`
+
+	// Iban the iban of the payment
+	Iban *string `json:"iban,omitempty"`
+
+	// Market the market of the payment. Important for the payment provider, e.g. Tink
+	Market *string `json:"market,omitempty"`
+
+	// Reference the reference of the payment
+	Reference *string `json:"reference,omitempty"`
+
+	// TinkPaymentId the payment id in the Tink system
+	TinkPaymentId *string `json:"tink_payment_id,omitempty"`
+}
+
+// Validate checks the payment details locally before they are sent: the
+// amount must be positive, the IBAN valid and able to receive the currency,
+// and the reference within the SEPA constraints or, if StructuredReference
+// is set, a valid RF creditor reference. A PaymentInfo without
+// details is valid. Errors wrap the payments sentinel errors, e.g.
+// payments.ErrInvalidIban.
+func (paymentInfo *PaymentInfo) Validate() error {
+	if paymentInfo == nil || paymentInfo.Details == nil {
+		return nil
+	}
+	details := paymentInfo.Details
+	if details.Amount == nil {
+		return errors.New("payment amount is required")
+	}
+	if err := details.Amount.Validate(); err != nil {
+		return fmt.Errorf("payment amount: %w", err)
+	}
+	if details.Amount.Amount <= 0 {
+		return fmt.Errorf("payment amount %s is not positive", details.Amount)
+	}
+	if details.Iban == nil || *details.Iban == "" {
+		return errors.New("payment iban is required")
+	}
+	if err := payments.ValidateIban(*details.Iban); err != nil {
+		return err
+	}
+	if err := payments.CheckCurrency(*details.Iban, details.Amount.Currency); err != nil {
+		return err
+	}
+	switch {
+	case details.StructuredReference && details.Reference == nil:
+		return fmt.Errorf("%w: structured reference is missing", payments.ErrInvalidReference)
+	case details.StructuredReference:
+		return payments.ValidateCreditorReference(*details.Reference)
+	case details.Reference != nil:
+		return payments.ValidateReference(*details.Reference)
+	}
+	return nil
+}
+
+// wireAmount is the amount and currency as encoded by the API.
+type wireAmount struct {
+	Amount   *json.Number `json:"amount,omitempty"`
+	Currency *string      `json:"currency,omitempty"`
+}
+
+func newWireAmount(m *payments.Money) wireAmount {
+	if m == nil {
+		return wireAmount{}
+	}
+	amount := json.Number(strconv.FormatInt(m.Amount, 10))
+	currency := m.Currency
+	return wireAmount{Amount: &amount, Currency: &currency}
+}
+
+func (w wireAmount) money() (*payments.Money, error) {
+	if w.Amount == nil && w.Currency == nil {
+		return nil, nil
+	}
+	m := &payments.Money{Currency: strVal(w.Currency)}
+	if w.Amount != nil {
+		amount, err := payments.ParseMinorUnits(w.Amount.String())
+		if err != nil {
+			return nil, err
+		}
+		m.Amount = amount
+	}
+	return m, nil
+}
+
+func (d PaymentDetails) MarshalJSON() ([]byte, error) {
+	type fields PaymentDetails
+	return json.Marshal(struct {
+		wireAmount
+		fields
+	}{newWireAmount(d.Amount), fields(d)})
+}
+
+func (d *PaymentDetails) UnmarshalJSON(data []byte) error {
+	type fields PaymentDetails
+	var v struct {
+		wireAmount
+		fields
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	amount, err := v.money()
+	if err != nil {
+		return fmt.Errorf("payment details: %w", err)
+	}
+	*d = PaymentDetails(v.fields)
+	d.Amount = amount
+	return nil
+}
+
+func (d PaymentActionDetails) MarshalJSON() ([]byte, error) {
+	type fields PaymentActionDetails
+	return json.Marshal(struct {
+		wireAmount
+		fields
+	}{newWireAmount(d.Amount), fields(d)})
+}
+
+func (d *PaymentActionDetails) UnmarshalJSON(data []byte) error {
+	type fields PaymentActionDetails
+	var v struct {
+		wireAmount
+		fields
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	amount, err := v.money()
+	if err != nil {
+		return fmt.Errorf("payment details: %w", err)
+	}
+	*d = PaymentActionDetails(v.fields)
+	d.Amount = amount
+	return nil
+}
+
+// sendContentBody is the send request with the amount as an integer, which
+// the generated request holds as float32.
+type sendContentBody struct {
+	api.ApiSendContentSendContentRequest
+	PaymentInfo *sendPaymentInfo `json:"payment_info,omitempty"`
+}
+
+type sendPaymentInfo struct {
+	Details *sendPaymentDetails `json:"details,omitempty"`
+	Payable *bool               `json:"payable,omitempty"`
+}
+
+type sendPaymentDetails struct {
+	api.ApiSendContentPaymentDetails
+	Amount int64 `json:"amount"`
+}
+
+// encodeSendRequest encodes request with the exact amount of paymentInfo.
+func encodeSendRequest(request *api.ApiSendContentSendContentRequest, paymentInfo *PaymentInfo) (*bytes.Buffer, error) {
+	body := sendContentBody{ApiSendContentSendContentRequest: *request}
+	if info := request.PaymentInfo; info != nil {
+		body.PaymentInfo = &sendPaymentInfo{Payable: info.Payable}
+		if info.Details != nil {
+			body.PaymentInfo.Details = &sendPaymentDetails{ApiSendContentPaymentDetails: *info.Details}
+			if paymentInfo.Details.Amount != nil {
+				body.PaymentInfo.Details.Amount = paymentInfo.Details.Amount.Amount
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
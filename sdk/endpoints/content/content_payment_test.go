@@ -0,0 +1,155 @@
+package content_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func TestPaymentDetailsJson(t *testing.T) {
+	amount := payments.Cents(1250)
+	data, err := json.Marshal(content.PaymentDetails{Amount: &amount, Iban: sdk.String("DE89370400440532013000")})
+	if err != nil {
+		t.Errorf("Marshal failed: %v", err)
+		return
+	}
+	if string(data) != `{"amount":1250,"currency":"EUR","iban":"DE89370400440532013000"}` {
+		t.Errorf("Unexpected wire format %s", data)
+	}
+
+	var details content.PaymentDetails
+	if err := json.Unmarshal([]byte(`{"amount":1250.0,"currency":"EUR","reference":"RF18539007547034"}`), &details); err != nil {
+		t.Errorf("Unmarshal failed: %v", err)
+		return
+	}
+	if details.Amount == nil || *details.Amount != amount || *details.Reference != "RF18539007547034" {
+		t.Errorf("Unexpected details %+v", details)
+	}
+
+	var actions content.ContentActions
+	if err := json.Unmarshal([]byte(`{"payments":{"details":{"amount":99999999,"currency":"EUR","iban":"DE89370400440532013000"}}}`), &actions); err != nil {
+		t.Errorf("Unmarshal failed: %v", err)
+		return
+	}
+	if d := actions.Payments.Details; d.Amount == nil || d.Amount.Amount != 99999999 || *d.Iban != "DE89370400440532013000" {
+		t.Errorf("Unexpected action details %+v", d)
+	}
+}
+
+func TestSendContentSendsExactAmount(t *testing.T) {
+	var body string
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		writeJson(w, 200, api.ContentCreateResponse{Id: sdk.String("doc-1")})
+	})
+	// not representable as float32
+	amount := payments.Cents(123456789)
+	tenant := "tenant"
+	_, status, err := content.SendContent(client, context.Background(), &tenant, &content.SendContentRequest{
+		To:      &content.ReceiverData{Email: &content.EmailReceiver{Email: sdk.String("max@example.com")}},
+		Type:    sdk.String(content.Invoice),
+		Subject: sdk.String("Rechnung"),
+		Body:    &[]content.ContentItem{content.PdfContentItem([]byte("%PDF-1.4"))},
+		PaymentInfo: &content.PaymentInfo{Details: &content.PaymentDetails{
+			Amount:    &amount,
+			DueDate:   sdk.String("2025-04-01"),
+			Iban:      sdk.String("DE89370400440532013000"),
+			Reference: sdk.String("R-1"),
+		}},
+	})
+	if err != nil || status.HttpStatus != 200 {
+		t.Errorf("SendContent failed: %v %v", err, status)
+		return
+	}
+	var sent struct {
+		PaymentInfo struct {
+			Details struct {
+				Amount   json.Number `json:"amount"`
+				Currency string      `json:"currency"`
+			} `json:"details"`
+		} `json:"payment_info"`
+	}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&sent); err != nil || sent.PaymentInfo.Details.Amount != "123456789" || sent.PaymentInfo.Details.Currency != "EUR" {
+		t.Errorf("Expected the exact amount in %s: %v", body, err)
+	}
+}
+
+func TestPaymentInfoValidate(t *testing.T) {
+	valid := func() *content.PaymentInfo {
+		amount := payments.Cents(1250)
+		return &content.PaymentInfo{Details: &content.PaymentDetails{
+			Amount:    &amount,
+			Iban:      sdk.String("DE89 3704 0044 0532 0130 00"),
+			Reference: sdk.String("RF18 5390 0754 7034"),
+		}}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+	if err := (&content.PaymentInfo{}).Validate(); err != nil {
+		t.Errorf("Validate failed without details: %v", err)
+	}
+
+	info := valid()
+	info.Details.Iban = sdk.String("DE89370400440532013001")
+	if err := info.Validate(); !errors.Is(err, payments.ErrInvalidIban) {
+		t.Errorf("Expected ErrInvalidIban, got %v", err)
+	}
+	info = valid()
+	info.Details.Amount.Currency = "USD"
+	if err := info.Validate(); !errors.Is(err, payments.ErrInvalidCurrency) {
+		t.Errorf("Expected ErrInvalidCurrency, got %v", err)
+	}
+	info = valid()
+	info.Details.Reference = sdk.String("Rechnung für Müller")
+	if err := info.Validate(); !errors.Is(err, payments.ErrInvalidReference) {
+		t.Errorf("Expected ErrInvalidReference, got %v", err)
+	}
+	info = valid()
+	info.Details.StructuredReference = true
+	if err := info.Validate(); err != nil {
+		t.Errorf("Validate failed for a creditor reference: %v", err)
+	}
+	info.Details.Reference = sdk.String("RF19 5390 0754 7034")
+	if err := info.Validate(); !errors.Is(err, payments.ErrInvalidReference) {
+		t.Errorf("Expected ErrInvalidReference for a wrong check digit, got %v", err)
+	}
+	info.Details.StructuredReference = false
+	if err := info.Validate(); err != nil {
+		t.Errorf("Validate failed for free text starting with RF: %v", err)
+	}
+	info = valid()
+	info.Details.Amount.Amount = 0
+	if err := info.Validate(); err == nil {
+		t.Error("Expected an error for a zero amount")
+	}
+
+	// SendContent validates before sending
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected request")
+	})
+	info = valid()
+	info.Details.Iban = sdk.String("DE00370400440532013000")
+	tenant := "tenant"
+	_, _, err := content.SendContent(client, context.Background(), &tenant, &content.SendContentRequest{
+		To:          &content.ReceiverData{Email: &content.EmailReceiver{Email: sdk.String("max@example.com")}},
+		Type:        sdk.String(content.Invoice),
+		Body:        &[]content.ContentItem{content.PdfContentItem([]byte("%PDF-1.4"))},
+		PaymentInfo: info,
+	})
+	if !errors.Is(err, payments.ErrInvalidIban) {
+		t.Errorf("Expected ErrInvalidIban, got %v", err)
+	}
+}
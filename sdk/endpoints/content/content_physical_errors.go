@@ -0,0 +1,221 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PhysicalErrorCategory groups Deutsche Post errors by the follow-up action
+// they require.
+type PhysicalErrorCategory string
+
+// Categories of paper mail errors.
+const (
+	// PhysicalErrorAddressUnknown means the address does not exist or is
+	// incomplete, or the recipient is unknown at the address.
+	PhysicalErrorAddressUnknown PhysicalErrorCategory = "address_unknown"
+	// PhysicalErrorRecipientMoved means the recipient no longer lives at the
+	// address.
+	PhysicalErrorRecipientMoved PhysicalErrorCategory = "recipient_moved"
+	// PhysicalErrorRefused means the recipient refused or did not collect
+	// the letter.
+	PhysicalErrorRefused PhysicalErrorCategory = "refused"
+	// PhysicalErrorPrintFailure means printing or enveloping failed.
+	PhysicalErrorPrintFailure PhysicalErrorCategory = "print_failure"
+	// PhysicalErrorRejectedContent means the document was rejected, e.g.
+	// because of its format, margins or page count.
+	PhysicalErrorRejectedContent PhysicalErrorCategory = "rejected_content"
+	// PhysicalErrorUnknown is used for errors that could not be classified.
+	PhysicalErrorUnknown PhysicalErrorCategory = "unknown"
+)
+
+// PhysicalErrorSeverity is the severity of a paper mail error.
+type PhysicalErrorSeverity string
+
+// Severities of paper mail errors.
+const (
+	SeverityInfo    PhysicalErrorSeverity = "info"
+	SeverityWarning PhysicalErrorSeverity = "warning"
+	SeverityError   PhysicalErrorSeverity = "error"
+)
+
+// PhysicalErrorClass describes a category of paper mail errors.
+type PhysicalErrorClass struct {
+	Category PhysicalErrorCategory
+	Severity PhysicalErrorSeverity
+	// Resendable reports whether sending the same document to the same
+	// address again may succeed.
+	Resendable bool
+	// Remediation is a suggested follow-up action.
+	Remediation string
+}
+
+// physicalErrorClasses are the default classes of every category.
+var physicalErrorClasses = map[PhysicalErrorCategory]PhysicalErrorClass{
+	PhysicalErrorAddressUnknown: {
+		Category:    PhysicalErrorAddressUnknown,
+		Severity:    SeverityError,
+		Remediation: "Verify and correct the postal address of the recipient, then send the document again.",
+	},
+	PhysicalErrorRecipientMoved: {
+		Category:    PhysicalErrorRecipientMoved,
+		Severity:    SeverityError,
+		Remediation: "Obtain the new address of the recipient, update your records and send the document again.",
+	},
+	PhysicalErrorRefused: {
+		Category:    PhysicalErrorRefused,
+		Severity:    SeverityError,
+		Remediation: "Contact the recipient through another channel; sending again is unlikely to succeed.",
+	},
+	PhysicalErrorPrintFailure: {
+		Category:    PhysicalErrorPrintFailure,
+		Severity:    SeverityError,
+		Resendable:  true,
+		Remediation: "The letter could not be produced. Send the document again.",
+	},
+	PhysicalErrorRejectedContent: {
+		Category:    PhysicalErrorRejectedContent,
+		Severity:    SeverityError,
+		Remediation: "Check the document against the paper mail requirements (PDF format, margins, address field, page count), fix it and send it again.",
+	},
+	PhysicalErrorUnknown: {
+		Category:    PhysicalErrorUnknown,
+		Severity:    SeverityError,
+		Remediation: "Check the error description and contact Brifle support if the cause is unclear.",
+	},
+}
+
+// deutschePostReasons are the reasons Deutsche Post states on the
+// undeliverability notice ("Unzustellbarkeitsgründe") of a returned letter,
+// compared word by word with the description of an error.
+var deutschePostReasons = map[string]PhysicalErrorCategory{
+	"empfänger unter der angegebenen anschrift nicht zu ermitteln": PhysicalErrorAddressUnknown,
+	"anschrift unzureichend": PhysicalErrorAddressUnknown,
+	"empfänger verstorben":   PhysicalErrorAddressUnknown,
+	"empfänger verzogen":     PhysicalErrorRecipientMoved,
+	"annahme verweigert":     PhysicalErrorRefused,
+	"nicht abgeholt":         PhysicalErrorRefused,
+}
+
+// physicalErrorKeywords classify an error by its description when neither
+// its code nor its reason is known, in German and English. Keywords match
+// whole words; a trailing "*" also matches words starting with the keyword,
+// e.g. German compounds. Earlier entries win.
+var physicalErrorKeywords = []struct {
+	category PhysicalErrorCategory
+	keywords []string
+}{
+	{PhysicalErrorRecipientMoved, []string{"verzogen", "umgezogen", "moved", "nachsendeauftrag", "forwarding address"}},
+	{PhysicalErrorRefused, []string{"verweigert", "refused", "nicht abgeholt", "not collected", "unclaimed"}},
+	{PhysicalErrorRejectedContent, []string{"abgelehnt", "rejected", "inhalt", "content", "dateiformat", "file format", "seitenrand*", "seitenränder", "margin*", "adressfeld", "address field", "seitenanzahl", "page count", "too many pages"}},
+	{PhysicalErrorAddressUnknown, []string{"unbekannt", "recipient unknown", "addressee unknown", "unknown recipient", "unzustellbar", "undeliverable", "unzureichend", "insufficient", "anschrift", "adresse", "address", "verstorben", "deceased"}},
+	{PhysicalErrorPrintFailure, []string{"druck*", "print", "printing", "printer", "kuvertier*", "envelop*", "produktion", "production"}},
+}
+
+// PhysicalErrorClassifier classifies paper mail errors. The zero value
+// classifies by the reasons and keywords of the description only.
+//
+//	classifier := &content.PhysicalErrorClassifier{Codes: map[string]content.PhysicalErrorClass{
+//		"4711": content.PhysicalErrorClassOf(content.PhysicalErrorRecipientMoved),
+//	}}
+//	class := classifier.Classify(e)
+type PhysicalErrorClassifier struct {
+	// Codes assigns classes to Deutsche Post error codes. They take
+	// precedence over the classification by description. The SDK ships no
+	// default codes: the API does not document the codes it returns, so add
+	// the codes you encounter.
+	Codes map[string]PhysicalErrorClass
+}
+
+// PhysicalErrorClassOf returns the default class of a category.
+func PhysicalErrorClassOf(category PhysicalErrorCategory) PhysicalErrorClass {
+	if class, ok := physicalErrorClasses[category]; ok {
+		return class
+	}
+	return physicalErrorClasses[PhysicalErrorUnknown]
+}
+
+// PhysicalErrors returns the errors of a paper mail delivery. Dates that can
+// not be parsed are left zero; use Details to detect them.
+func (s *DeliveryStatus) PhysicalErrors() []PhysicalError {
+	if s == nil || s.ContentGetDeliveryStatusResponse == nil || s.DeliveryStatus.Physical == nil {
+		return nil
+	}
+	errs := make([]PhysicalError, 0, len(s.DeliveryStatus.Physical.Errors))
+	for _, e := range s.DeliveryStatus.Physical.Errors {
+		date, _ := parseTimestamp(e.DpErrorDate)
+		errs = append(errs, PhysicalError{Code: e.DpErrorCode, Level: e.DpLevel, Description: e.DpErrorDescription, Date: date})
+	}
+	return errs
+}
+
+// Category returns the category of the error.
+func (e PhysicalError) Category() PhysicalErrorCategory {
+	return e.Class().Category
+}
+
+// Class classifies the error by its description, see
+// PhysicalErrorClassifier.
+func (e PhysicalError) Class() PhysicalErrorClass {
+	return (&PhysicalErrorClassifier{}).Classify(e)
+}
+
+// Classify classifies e by its code or, failing that, by its description.
+// The severity reported by Deutsche Post in Level takes precedence over the
+// default severity of the class.
+func (c *PhysicalErrorClassifier) Classify(e PhysicalError) PhysicalErrorClass {
+	class, ok := c.Codes[e.Code]
+	if !ok {
+		class = PhysicalErrorClassOf(classifyDescription(e.Description))
+	}
+
+	switch strings.ToLower(e.Level) {
+	case "info", "information":
+		class.Severity = SeverityInfo
+	case "warn", "warning":
+		class.Severity = SeverityWarning
+	case "error", "fatal", "critical":
+		class.Severity = SeverityError
+	}
+	return class
+}
+
+func classifyDescription(description string) PhysicalErrorCategory {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if category, ok := deutschePostReasons[strings.Join(words, " ")]; ok {
+		return category
+	}
+	for _, entry := range physicalErrorKeywords {
+		for _, keyword := range entry.keywords {
+			if containsWords(words, strings.Fields(keyword)) {
+				return entry.category
+			}
+		}
+	}
+	return PhysicalErrorUnknown
+}
+
+// containsWords reports whether words contains the sequence keyword. A
+// keyword word ending with "*" matches any word with that prefix.
+func containsWords(words, keyword []string) bool {
+	for i := 0; i+len(keyword) <= len(words); i++ {
+		match := true
+		for j, k := range keyword {
+			w := words[i+j]
+			if prefix, ok := strings.CutSuffix(k, "*"); ok {
+				match = strings.HasPrefix(w, prefix)
+			} else {
+				match = w == k
+			}
+			if !match {
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package content_test
+
+import (
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestPhysicalErrorCategory(t *testing.T) {
+	tests := []struct {
+		description string
+		category    content.PhysicalErrorCategory
+		resendable  bool
+	}{
+		{"Empfänger verzogen", content.PhysicalErrorRecipientMoved, false},
+		{"Empfänger unbekannt", content.PhysicalErrorAddressUnknown, false},
+		{"Anschrift unzureichend", content.PhysicalErrorAddressUnknown, false},
+		{"Annahme verweigert", content.PhysicalErrorRefused, false},
+		{"Druckdaten abgelehnt: Adressfeld nicht frei", content.PhysicalErrorRejectedContent, false},
+		{"Print job failed", content.PhysicalErrorPrintFailure, true},
+		{"Missing information", content.PhysicalErrorUnknown, false},
+		{"Empfänger unter der angegebenen Anschrift nicht zu ermitteln", content.PhysicalErrorAddressUnknown, false},
+		{"Nicht abgeholt", content.PhysicalErrorRefused, false},
+		{"Druckfehler in Produktionsstraße 2", content.PhysicalErrorPrintFailure, true},
+		// keywords match whole words only
+		{"Attachment removed by sender", content.PhysicalErrorUnknown, false},
+		{"Fingerprint mismatch", content.PhysicalErrorUnknown, false},
+	}
+	for _, tt := range tests {
+		class := content.PhysicalError{Code: "x", Description: tt.description}.Class()
+		if class.Category != tt.category || class.Resendable != tt.resendable || class.Remediation == "" {
+			t.Errorf("%q: expected %s (resendable %v), got %+v", tt.description, tt.category, tt.resendable, class)
+		}
+	}
+}
+
+func TestPhysicalErrorClassifierCodes(t *testing.T) {
+	classifier := &content.PhysicalErrorClassifier{Codes: map[string]content.PhysicalErrorClass{
+		"TEST-17": content.PhysicalErrorClassOf(content.PhysicalErrorPrintFailure),
+	}}
+	e := content.PhysicalError{Code: "TEST-17", Level: "warning", Description: "Empfänger verzogen"}
+	if class := classifier.Classify(e); class.Category != content.PhysicalErrorPrintFailure || class.Severity != content.SeverityWarning {
+		t.Errorf("Expected the category of the code and the severity of the level, got %+v", class)
+	}
+	// codes of a classifier do not affect other classifications
+	if e.Category() != content.PhysicalErrorRecipientMoved {
+		t.Errorf("Expected the category of the description, got %s", e.Category())
+	}
+
+	status := deliveryStatus(t, `{"delivery_mode": "physical", "physical": {"state": "error", "errors": [{"dp_error_code": "TEST-17", "dp_level": "error", "dp_error_description": "", "dp_error_date": "2025-03-02T08:00:00Z"}]}}`)
+	errs := status.PhysicalErrors()
+	if len(errs) != 1 || classifier.Classify(errs[0]).Category != content.PhysicalErrorPrintFailure || errs[0].Category() != content.PhysicalErrorUnknown {
+		t.Errorf("Unexpected physical errors %+v", errs)
+	}
+}
@@ -0,0 +1,246 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+// MaxReceiverBulkRequestBytes is the size limit of a request body of the
+// API.
+const MaxReceiverBulkRequestBytes = 3 << 20
+
+// receiverBulkEnvelope is the size of the request body without receivers,
+// `{"receivers":[]}`, with some room to spare.
+const receiverBulkEnvelope = 64
+
+// ReceiverCheckResult is the outcome of checking a single receiver with a
+// ReceiverChecker.
+type ReceiverCheckResult struct {
+	// Index is the position of the receiver in the input.
+	Index int
+	// Key is the key of the receiver in the input of CheckMap.
+	Key string
+	// Found is set when the receiver is on Brifle.
+	Found bool
+	// ReceiverType is the type under which the receiver was found, e.g.
+	// "email".
+	ReceiverType string
+	// HttpStatus and ErrorCode are those of the bulk request that checked
+	// the receiver.
+	HttpStatus int
+	ErrorCode  int
+	// Err is set when the receiver could not be checked. Found is false then,
+	// which does not mean the receiver is not on Brifle.
+	Err error
+}
+
+// ReceiverChecker checks any number of receivers with CheckReceiverBulk. The
+// receivers are deduplicated and split into chunks that fit the request
+// size limit, which are sent concurrently. A failed chunk fails only its
+// receivers; the results of all other chunks are returned.
+//
+//	checker := &content.ReceiverChecker{Client: client, ChunkSize: 500}
+//	results, err := checker.CheckMap(ctx, receiversByCustomer)
+//	for customerId, res := range results {
+//		...
+//	}
+type ReceiverChecker struct {
+	Client *sdkClient.BrifleClient
+	// ChunkSize is the maximum number of receivers per request. Defaults to
+	// 100.
+	ChunkSize int
+	// MaxRequestBytes is the maximum size of a request body. Defaults to
+	// MaxReceiverBulkRequestBytes.
+	MaxRequestBytes int
+	// Concurrency is the number of parallel requests. Defaults to 4.
+	Concurrency int
+}
+
+// receiverChunk is a request of a ReceiverChecker.
+type receiverChunk struct {
+	receivers []ReceiverData
+	// unique are the indexes of the receivers in the unique receivers.
+	unique []int
+}
+
+// Check checks receivers and returns a result for each, in input order.
+// Identical receivers are checked once. The returned error joins the errors
+// of failed chunks; the results of the other receivers are valid anyway.
+func (c *ReceiverChecker) Check(ctx context.Context, receivers []ReceiverData) ([]ReceiverCheckResult, error) {
+	if c.Client == nil {
+		return nil, errors.New("client is required")
+	}
+	results := make([]ReceiverCheckResult, len(receivers))
+
+	// deduplicate by request body; uniqueOf maps each receiver to its
+	// unique receiver
+	var unique []ReceiverCheckResult
+	var sizes []int
+	var errs []error
+	uniqueOf := make([]int, len(receivers))
+	seen := map[string]int{}
+	for i := range receivers {
+		results[i].Index = i
+		converted := buildReceiver(&receivers[i])
+		if converted == nil {
+			results[i].Err = errors.New("receiver data is invalid")
+			errs = append(errs, fmt.Errorf("receiver %d: %w", i, results[i].Err))
+			uniqueOf[i] = -1
+			continue
+		}
+		data, err := json.Marshal(converted)
+		if err != nil {
+			results[i].Err = err
+			errs = append(errs, fmt.Errorf("receiver %d: %w", i, err))
+			uniqueOf[i] = -1
+			continue
+		}
+		n, ok := seen[string(data)]
+		if !ok {
+			n = len(unique)
+			seen[string(data)] = n
+			unique = append(unique, ReceiverCheckResult{Index: i})
+			sizes = append(sizes, len(data)+1)
+		}
+		uniqueOf[i] = n
+	}
+
+	chunks := c.chunks(receivers, unique, sizes, &errs)
+	errs = append(errs, c.run(ctx, chunks, unique)...)
+
+	for i, n := range uniqueOf {
+		if n < 0 {
+			continue
+		}
+		res := unique[n]
+		res.Index = i
+		results[i] = res
+	}
+	return results, errors.Join(errs...)
+}
+
+// CheckMap checks the receivers of a map, e.g. by customer id, and returns
+// the results by the same keys, see Check.
+func (c *ReceiverChecker) CheckMap(ctx context.Context, receivers map[string]ReceiverData) (map[string]ReceiverCheckResult, error) {
+	keys := make([]string, 0, len(receivers))
+	list := make([]ReceiverData, 0, len(receivers))
+	for key, receiver := range receivers {
+		keys = append(keys, key)
+		list = append(list, receiver)
+	}
+	results, err := c.Check(ctx, list)
+	byKey := make(map[string]ReceiverCheckResult, len(results))
+	for i, res := range results {
+		res.Key = keys[i]
+		byKey[keys[i]] = res
+	}
+	return byKey, err
+}
+
+// chunks splits the unique receivers into requests of at most ChunkSize
+// receivers and MaxRequestBytes. Receivers too large for any request fail.
+func (c *ReceiverChecker) chunks(receivers []ReceiverData, unique []ReceiverCheckResult, sizes []int, errs *[]error) []receiverChunk {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 100
+	}
+	maxBytes := c.MaxRequestBytes
+	if maxBytes <= 0 {
+		maxBytes = MaxReceiverBulkRequestBytes
+	}
+
+	var chunks []receiverChunk
+	var current receiverChunk
+	length := receiverBulkEnvelope
+	for n := range unique {
+		if receiverBulkEnvelope+sizes[n] > maxBytes {
+			unique[n].Err = fmt.Errorf("receiver has %d bytes, the request limit is %d", sizes[n], maxBytes)
+			*errs = append(*errs, fmt.Errorf("receiver %d: %w", unique[n].Index, unique[n].Err))
+			continue
+		}
+		if len(current.unique) == size || length+sizes[n] > maxBytes {
+			chunks = append(chunks, current)
+			current, length = receiverChunk{}, receiverBulkEnvelope
+		}
+		current.receivers = append(current.receivers, receivers[unique[n].Index])
+		current.unique = append(current.unique, n)
+		length += sizes[n]
+	}
+	if len(current.unique) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// run sends the chunks with Concurrency workers and stores the results in
+// unique.
+func (c *ReceiverChecker) run(ctx context.Context, chunks []receiverChunk, unique []ReceiverCheckResult) []error {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	errs := make([]error, len(chunks))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(concurrency, len(chunks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range work {
+				errs[n] = c.check(ctx, chunks[n], unique)
+				if errs[n] != nil {
+					errs[n] = fmt.Errorf("chunk %d: %w", n, errs[n])
+				}
+			}
+		}()
+	}
+	for n := range chunks {
+		work <- n
+	}
+	close(work)
+	wg.Wait()
+	return errs
+}
+
+// check sends a single chunk. Each chunk writes distinct elements of unique.
+func (c *ReceiverChecker) check(ctx context.Context, chunk receiverChunk, unique []ReceiverCheckResult) error {
+	fail := func(status *api.ResponseStatus, err error) error {
+		for _, n := range chunk.unique {
+			unique[n].Err = err
+			if status != nil {
+				unique[n].HttpStatus = status.HttpStatus
+				unique[n].ErrorCode = status.ErrorCode
+			}
+		}
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fail(nil, err)
+	}
+	res, status, err := CheckReceiverBulk(c.Client, ctx, &chunk.receivers)
+	if err != nil {
+		return fail(status, err)
+	}
+	if !statusOk(status) || res == nil || res.ReceiverBulkExistResponse == nil || res.Receivers == nil {
+		return fail(status, &StatusError{Operation: "check receivers", Status: status})
+	}
+	if len(*res.Receivers) != len(chunk.unique) {
+		return fail(status, fmt.Errorf("check receivers: %d results for %d receivers", len(*res.Receivers), len(chunk.unique)))
+	}
+	for i, n := range chunk.unique {
+		unique[n].HttpStatus = status.HttpStatus
+		unique[n].ErrorCode = status.ErrorCode
+		// receivers not found are reported without a type
+		if t := (*res.Receivers)[i].Type; t != nil && *t != "" {
+			unique[n].Found = true
+			unique[n].ReceiverType = string(*t)
+		}
+	}
+	return nil
+}
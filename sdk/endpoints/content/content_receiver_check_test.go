@@ -0,0 +1,150 @@
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+// bulkServer is a receiverServer recording the emails of every bulk check.
+func bulkServer(t *testing.T) (http.HandlerFunc, func() [][]string) {
+	var mu sync.Mutex
+	var requests [][]string
+	handler := receiverServer(t)
+	return func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req struct {
+				Receivers []map[string]any `json:"receivers"`
+			}
+			_ = json.Unmarshal(body, &req)
+			var emails []string
+			for _, rec := range req.Receivers {
+				email, _ := rec["email"].(string)
+				emails = append(emails, email)
+			}
+			mu.Lock()
+			requests = append(requests, emails)
+			mu.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			handler(w, r)
+		}, func() [][]string {
+			mu.Lock()
+			defer mu.Unlock()
+			return requests
+		}
+}
+
+func TestReceiverCheckerCheck(t *testing.T) {
+	handler, requests := bulkServer(t)
+	checker := &content.ReceiverChecker{Client: mockClient(t, handler), ChunkSize: 3, Concurrency: 2}
+	var receivers []content.ReceiverData
+	for i := 0; i < 10; i++ {
+		receivers = append(receivers, emailReceiver(fmt.Sprintf("on-%d@example.com", i)))
+	}
+	receivers = append(receivers,
+		emailReceiver("on-0@example.com"),
+		emailReceiver("off@example.com"),
+		content.ReceiverData{},
+	)
+
+	results, err := checker.Check(context.Background(), receivers)
+	if err == nil || !strings.Contains(err.Error(), "receiver 12") {
+		t.Errorf("Expected an error for the invalid receiver, got %v", err)
+	}
+	if len(results) != len(receivers) {
+		t.Fatalf("Expected %d results, got %d", len(receivers), len(results))
+	}
+	for i := 0; i < 11; i++ {
+		if r := results[i]; r.Index != i || !r.Found || r.ReceiverType != "email" || r.HttpStatus != 200 || r.Err != nil {
+			t.Errorf("Expected receiver %d to be found, got %+v", i, r)
+		}
+	}
+	if r := results[11]; r.Found || r.Err != nil {
+		t.Errorf("Expected receiver 11 not to be found, got %+v", r)
+	}
+	if r := results[12]; r.Err == nil {
+		t.Errorf("Expected receiver 12 to fail, got %+v", r)
+	}
+
+	sent := 0
+	for _, req := range requests() {
+		if len(req) > 3 {
+			t.Errorf("Expected at most 3 receivers per request, got %d", len(req))
+		}
+		sent += len(req)
+	}
+	if len(requests()) != 4 || sent != 11 {
+		t.Errorf("Expected 11 unique receivers in 4 requests, got %v", requests())
+	}
+}
+
+func TestReceiverCheckerPartialFailure(t *testing.T) {
+	handler, requests := bulkServer(t)
+	checker := &content.ReceiverChecker{Client: mockClient(t, handler), ChunkSize: 2}
+	results, err := checker.CheckMap(context.Background(), map[string]content.ReceiverData{
+		"a": emailReceiver("on-a@example.com"),
+		"b": emailReceiver("down@example.com"),
+		"c": emailReceiver("on-c@example.com"),
+		"d": emailReceiver("off@example.com"),
+	})
+	var statusErr *content.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status.HttpStatus != 503 {
+		t.Errorf("Expected a failed chunk, got %v", err)
+	}
+	if len(requests()) != 2 {
+		t.Errorf("Expected 2 requests, got %v", requests())
+	}
+
+	// the receiver sharing a request with "b" fails too
+	failed := 0
+	for key, res := range results {
+		if res.Key != key {
+			t.Errorf("Expected key %q, got %+v", key, res)
+		}
+		if res.Err != nil {
+			failed++
+			if res.HttpStatus != 503 {
+				t.Errorf("Expected the status of the failed request, got %+v", res)
+			}
+			continue
+		}
+		if res.Found != (key == "a" || key == "c") {
+			t.Errorf("Unexpected result for %s: %+v", key, res)
+		}
+	}
+	if results["b"].Err == nil || failed != 2 {
+		t.Errorf("Expected the chunk with b to fail, got %+v", results)
+	}
+}
+
+func TestReceiverCheckerRequestSize(t *testing.T) {
+	handler, requests := bulkServer(t)
+	checker := &content.ReceiverChecker{Client: mockClient(t, handler), MaxRequestBytes: 120}
+	receivers := []content.ReceiverData{
+		emailReceiver("on-1@example.com"),
+		emailReceiver("on-2@example.com"),
+		emailReceiver("on-3@example.com"),
+		emailReceiver("on-" + strings.Repeat("x", 200) + "@example.com"),
+	}
+	results, err := checker.Check(context.Background(), receivers)
+	if err == nil || results[3].Err == nil {
+		t.Errorf("Expected the oversized receiver to fail, got %+v, %v", results[3], err)
+	}
+	for _, r := range results[:3] {
+		if !r.Found {
+			t.Errorf("Expected receiver %d to be found, got %+v", r.Index, r)
+		}
+	}
+	if len(requests()) != 3 {
+		t.Errorf("Expected the receivers to be split by size, got %v", requests())
+	}
+}
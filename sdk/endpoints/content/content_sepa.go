@@ -0,0 +1,432 @@
+package content
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/accounts"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/mailbox"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// SepaNamespace is the namespace of the SEPA credit transfer initiation
+// written by ExportSepaTransfers.
+const SepaNamespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+// SepaExportOptions configure ExportSepaTransfers.
+type SepaExportOptions struct {
+	// DebtorName is the name of the account holder that pays. Required.
+	DebtorName string
+	// DebtorIban is the account to pay from. Required.
+	DebtorIban string
+	// DebtorBic is the BIC of the debtor's bank. Optional.
+	DebtorBic string
+	// ExecutionDate is the requested execution date. Defaults to today.
+	ExecutionDate time.Time
+	// MessageId identifies the file, at most 35 characters. Defaults to
+	// "BRIFLE-" and the creation time.
+	MessageId string
+	// Filter narrows the inbox search, e.g. by state. The type is always
+	// Invoice.
+	Filter *mailbox.InboxSearchFilter
+	// MaxPages limits the number of inbox pages read. Defaults to 100.
+	MaxPages int
+	// CreditorName returns the name of the payee for the account id of an
+	// invoice's sender. Defaults to AccountName. Invoices whose sender has no
+	// name are skipped.
+	CreditorName func(ctx context.Context, senderId string) (string, error)
+}
+
+// SepaExport records which documents were included in a credit transfer
+// file, and why the other payable invoices were skipped.
+type SepaExport struct {
+	MessageId     string         `json:"message_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	ExecutionDate string         `json:"execution_date"`
+	Total         payments.Money `json:"total"`
+	Transfers     []SepaTransfer `json:"transfers"`
+	Skipped       []SepaSkipped  `json:"skipped,omitempty"`
+}
+
+// SepaTransfer is a credit transfer for a received invoice.
+type SepaTransfer struct {
+	DocumentId string `json:"document_id"`
+	Subject    string `json:"subject,omitempty"`
+	// SenderId is the account id of the sender of the invoice.
+	SenderId string `json:"sender_id"`
+	// Creditor is the name of the sender, see SepaExportOptions.CreditorName.
+	Creditor string `json:"creditor"`
+	// EndToEndId identifies the transfer in the file and on the
+	// creditor's statement.
+	EndToEndId string         `json:"end_to_end_id"`
+	Amount     payments.Money `json:"amount"`
+	Iban       string         `json:"iban"`
+	Reference  string         `json:"reference,omitempty"`
+}
+
+// SepaSkipped is a payable invoice that was not included.
+type SepaSkipped struct {
+	DocumentId string `json:"document_id"`
+	Subject    string `json:"subject,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+var endToEndChars = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// ExportSepaTransfers searches the inbox for payable invoices, reads their
+// payment details with GetContentAction and writes a SEPA credit transfer
+// initiation (pain.001.001.09) to w, which can be imported for review in
+// online banking.
+//
+// Invoices are skipped, and listed in the result with the reason, if their
+// payment details are missing or invalid, they are not in EUR, or they were
+// already paid through the payment link. Nothing is written if no invoice
+// is left.
+func ExportSepaTransfers(client *sdkClient.BrifleClient, ctx context.Context, opts *SepaExportOptions, w io.Writer) (*SepaExport, error) {
+	if opts == nil || strings.TrimSpace(opts.DebtorName) == "" {
+		return nil, errors.New("debtor name is required")
+	}
+	if err := payments.ValidateIban(opts.DebtorIban); err != nil {
+		return nil, fmt.Errorf("debtor: %w", err)
+	}
+	if opts.DebtorBic != "" {
+		if err := payments.ValidateBic(opts.DebtorBic); err != nil {
+			return nil, fmt.Errorf("debtor: %w", err)
+		}
+	}
+
+	now := time.Now()
+	export := &SepaExport{
+		MessageId: opts.MessageId,
+		CreatedAt: now.UTC().Truncate(time.Second),
+		Total:     payments.Cents(0),
+	}
+	if export.MessageId == "" {
+		export.MessageId = "BRIFLE-" + now.UTC().Format("20060102150405")
+	}
+	if len(export.MessageId) > 35 {
+		return nil, errors.New("message id has more than 35 characters")
+	}
+	execution := opts.ExecutionDate
+	if execution.IsZero() {
+		execution = now
+	}
+	export.ExecutionDate = execution.Format("2006-01-02")
+
+	items, err := payableInvoices(client, ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	creditorName := opts.CreditorName
+	if creditorName == nil {
+		creditorName = func(ctx context.Context, senderId string) (string, error) {
+			return AccountName(client, ctx, senderId)
+		}
+	}
+	// senders usually send more than one invoice
+	type lookup struct{ name, reason string }
+	names := map[string]lookup{}
+	creditor := func(senderId string) (string, string) {
+		if senderId == "" {
+			return "", "no sender"
+		}
+		if l, ok := names[senderId]; ok {
+			return l.name, l.reason
+		}
+		var l lookup
+		if name, err := creditorName(ctx, senderId); err != nil {
+			l.reason = fmt.Sprintf("creditor name of sender %s: %v", senderId, err)
+		} else {
+			l.name = strings.TrimSpace(name)
+		}
+		names[senderId] = l
+		return l.name, l.reason
+	}
+
+	endToEndIds := map[string]bool{}
+	for _, item := range items {
+		id, subject := strVal(item.Id), strVal(item.Subject)
+		transfer, reason := sepaTransfer(client, ctx, id)
+		if reason == "" {
+			transfer.SenderId = strVal(item.Sender)
+			transfer.Creditor, reason = creditor(transfer.SenderId)
+			if reason == "" && transfer.Creditor == "" {
+				reason = fmt.Sprintf("sender %s has no name", transfer.SenderId)
+			}
+		}
+		if reason != "" {
+			export.Skipped = append(export.Skipped, SepaSkipped{DocumentId: id, Subject: subject, Reason: reason})
+			continue
+		}
+		transfer.Subject = subject
+		transfer.EndToEndId = uniqueEndToEndId(id, endToEndIds)
+		export.Total, _ = export.Total.Add(transfer.Amount)
+		export.Transfers = append(export.Transfers, *transfer)
+	}
+	if len(export.Transfers) == 0 {
+		return export, nil
+	}
+	if err := writePain001(w, opts, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// payableInvoices returns the payable invoices of the inbox.
+func payableInvoices(client *sdkClient.BrifleClient, ctx context.Context, opts *SepaExportOptions) ([]*mailbox.InboxSearchResult, error) {
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = 100
+	}
+	filter := mailbox.InboxSearchFilter{}
+	if opts.Filter != nil {
+		filter = *opts.Filter
+	}
+	invoice := Invoice
+	filter.Type = &invoice
+
+	var items []*mailbox.InboxSearchResult
+	seen := map[string]bool{}
+	read := 0
+	for p := 1; p <= maxPages; p++ {
+		page := float32(p)
+		res, status, err := mailbox.SearchMyInbox(client, ctx, &mailbox.InboxSearch{Filter: &filter, Page: &page})
+		if err != nil {
+			if status != nil {
+				return nil, &StatusError{Operation: "search inbox", Status: status}
+			}
+			return nil, err
+		}
+		if res == nil || len(res.Results) == 0 {
+			break
+		}
+		for _, item := range res.Results {
+			if item == nil || item.Item == nil || item.Id == nil || seen[*item.Id] {
+				continue
+			}
+			seen[*item.Id] = true
+			if item.Payable != nil && *item.Payable && (item.Type == nil || *item.Type == Invoice) {
+				items = append(items, item)
+			}
+		}
+		read += len(res.Results)
+		if res.Total != nil && read >= int(*res.Total) {
+			break
+		}
+	}
+	return items, nil
+}
+
+// sepaTransfer reads the payment details of a document. It returns the
+// reason if the document cannot be paid by credit transfer.
+func sepaTransfer(client *sdkClient.BrifleClient, ctx context.Context, id string) (*SepaTransfer, string) {
+	actions, status, err := GetContentAction(client, ctx, &id)
+	switch {
+	case err != nil:
+		return nil, err.Error()
+	case !statusOk(status):
+		return nil, (&StatusError{Operation: "get content actions", Status: status}).Error()
+	case actions.Payments == nil || actions.Payments.Details == nil:
+		return nil, "no payment details"
+	}
+	details := actions.Payments.Details
+	switch {
+	case details.TinkPaymentId != nil && *details.TinkPaymentId != "":
+		return nil, "already paid through the payment link"
+	case details.Amount == nil || details.Amount.Amount <= 0:
+		return nil, "no amount to pay"
+	case details.Amount.Currency != "EUR":
+		return nil, fmt.Sprintf("currency %s is not supported by SEPA credit transfers", details.Amount.Currency)
+	}
+	iban := payments.NormalizeIban(strVal(details.Iban))
+	if err := payments.ValidateIban(iban); err != nil {
+		return nil, err.Error()
+	}
+	reference := strings.TrimSpace(strVal(details.Reference))
+	if reference != "" {
+		if err := payments.ValidateReference(reference); err != nil {
+			return nil, err.Error()
+		}
+	}
+	return &SepaTransfer{
+		DocumentId: id,
+		Amount:     *details.Amount,
+		Iban:       iban,
+		Reference:  reference,
+	}, ""
+}
+
+// AccountName returns the display name of an account, e.g. of the sender of
+// a document: the company name of business accounts, otherwise the first
+// and last name. It is empty if the account has no name.
+func AccountName(client *sdkClient.BrifleClient, ctx context.Context, accountId string) (string, error) {
+	info, status, err := accounts.GetBasicInformation(client, ctx, &accountId)
+	if err != nil {
+		return "", err
+	}
+	if !statusOk(status) || info == nil || info.BasicAccountInfoResponse == nil {
+		return "", &StatusError{Operation: "get account", Status: status}
+	}
+	if company := strings.TrimSpace(strVal(info.CompanyName)); company != "" {
+		return company, nil
+	}
+	return strings.Join(strings.Fields(strVal(info.FirstName)+" "+strVal(info.LastName)), " "), nil
+}
+
+// uniqueEndToEndId derives an end-to-end id of at most 35 letters and
+// digits from a document id.
+func uniqueEndToEndId(documentId string, used map[string]bool) string {
+	base := endToEndChars.ReplaceAllString(documentId, "")
+	if base == "" {
+		base = "DOC"
+	}
+	if len(base) > 35 {
+		base = base[:35]
+	}
+	id := base
+	for n := 2; used[id]; n++ {
+		suffix := fmt.Sprintf("%d", n)
+		id = base[:min(len(base), 35-len(suffix))] + suffix
+	}
+	used[id] = true
+	return id
+}
+
+// pain.001.001.09 elements written by writePain001.
+type (
+	painDocument struct {
+		XMLName   xml.Name       `xml:"Document"`
+		Namespace string         `xml:"xmlns,attr"`
+		Initn     painInitiation `xml:"CstmrCdtTrfInitn"`
+	}
+	painInitiation struct {
+		GrpHdr painGroupHeader `xml:"GrpHdr"`
+		PmtInf painPaymentInfo `xml:"PmtInf"`
+	}
+	painGroupHeader struct {
+		MsgId    string    `xml:"MsgId"`
+		CreDtTm  string    `xml:"CreDtTm"`
+		NbOfTxs  int       `xml:"NbOfTxs"`
+		CtrlSum  string    `xml:"CtrlSum"`
+		InitgPty painParty `xml:"InitgPty"`
+	}
+	painPaymentInfo struct {
+		PmtInfId    string            `xml:"PmtInfId"`
+		PmtMtd      string            `xml:"PmtMtd"`
+		BtchBookg   bool              `xml:"BtchBookg"`
+		NbOfTxs     int               `xml:"NbOfTxs"`
+		CtrlSum     string            `xml:"CtrlSum"`
+		SvcLvl      string            `xml:"PmtTpInf>SvcLvl>Cd"`
+		ReqdExctnDt string            `xml:"ReqdExctnDt>Dt"`
+		Dbtr        painParty         `xml:"Dbtr"`
+		DbtrAcct    painAccount       `xml:"DbtrAcct"`
+		DbtrAgt     painAgent         `xml:"DbtrAgt"`
+		ChrgBr      string            `xml:"ChrgBr"`
+		Txs         []painTransaction `xml:"CdtTrfTxInf"`
+	}
+	painParty struct {
+		Nm string `xml:"Nm"`
+	}
+	painAccount struct {
+		IBAN string `xml:"Id>IBAN"`
+	}
+	painAgent struct {
+		BICFI string `xml:"FinInstnId>BICFI,omitempty"`
+		Othr  string `xml:"FinInstnId>Othr>Id,omitempty"`
+	}
+	painAmount struct {
+		Ccy   string `xml:"Ccy,attr"`
+		Value string `xml:",chardata"`
+	}
+	painTransaction struct {
+		EndToEndId string          `xml:"PmtId>EndToEndId"`
+		Amt        painAmount      `xml:"Amt>InstdAmt"`
+		Cdtr       painParty       `xml:"Cdtr"`
+		CdtrAcct   painAccount     `xml:"CdtrAcct"`
+		RmtInf     *painRemittance `xml:"RmtInf,omitempty"`
+	}
+	painRemittance struct {
+		Ustrd string             `xml:"Ustrd,omitempty"`
+		Strd  *painStructuredRef `xml:"Strd,omitempty"`
+	}
+	painStructuredRef struct {
+		Cd   string `xml:"CdtrRefInf>Tp>CdOrPrtry>Cd"`
+		Issr string `xml:"CdtrRefInf>Tp>Issr"`
+		Ref  string `xml:"CdtrRefInf>Ref"`
+	}
+)
+
+func writePain001(w io.Writer, opts *SepaExportOptions, export *SepaExport) error {
+	info := painPaymentInfo{
+		PmtInfId:    export.MessageId,
+		PmtMtd:      "TRF",
+		BtchBookg:   true,
+		NbOfTxs:     len(export.Transfers),
+		CtrlSum:     export.Total.Decimal(),
+		SvcLvl:      "SEPA",
+		ReqdExctnDt: export.ExecutionDate,
+		Dbtr:        painParty{Nm: sepaName(opts.DebtorName)},
+		DbtrAcct:    painAccount{IBAN: payments.NormalizeIban(opts.DebtorIban)},
+		DbtrAgt:     painAgent{BICFI: strings.ToUpper(strings.TrimSpace(opts.DebtorBic))},
+		ChrgBr:      "SLEV",
+	}
+	if info.DbtrAgt.BICFI == "" {
+		info.DbtrAgt.Othr = "NOTPROVIDED"
+	}
+	for _, t := range export.Transfers {
+		tx := painTransaction{
+			EndToEndId: t.EndToEndId,
+			Amt:        painAmount{Ccy: t.Amount.Currency, Value: t.Amount.Decimal()},
+			Cdtr:       painParty{Nm: sepaName(t.Creditor)},
+			CdtrAcct:   painAccount{IBAN: t.Iban},
+		}
+		switch {
+		case t.Reference == "":
+		case payments.ValidateCreditorReference(t.Reference) == nil:
+			ref := strings.ToUpper(strings.ReplaceAll(t.Reference, " ", ""))
+			tx.RmtInf = &painRemittance{Strd: &painStructuredRef{Cd: "SCOR", Issr: "ISO", Ref: ref}}
+		default:
+			tx.RmtInf = &painRemittance{Ustrd: t.Reference}
+		}
+		info.Txs = append(info.Txs, tx)
+	}
+	doc := painDocument{
+		Namespace: SepaNamespace,
+		Initn: painInitiation{
+			GrpHdr: painGroupHeader{
+				MsgId:    export.MessageId,
+				CreDtTm:  export.CreatedAt.Format("2006-01-02T15:04:05Z"),
+				NbOfTxs:  len(export.Transfers),
+				CtrlSum:  export.Total.Decimal(),
+				InitgPty: painParty{Nm: sepaName(opts.DebtorName)},
+			},
+			PmtInf: info,
+		},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// sepaName shortens a name to the 70 characters allowed by SEPA.
+func sepaName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > 70 {
+		return string(runes[:70])
+	}
+	return name
+}
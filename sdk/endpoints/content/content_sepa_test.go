@@ -0,0 +1,238 @@
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// Account ids of the invoice senders served by sepaServer.
+const (
+	stadtwerkeId   = "0b6c7a52-1f0e-4c55-9d0e-6f1c8d1e2a01"
+	verwaltungId   = "0b6c7a52-1f0e-4c55-9d0e-6f1c8d1e2a02"
+	versicherungId = "0b6c7a52-1f0e-4c55-9d0e-6f1c8d1e2a03"
+	namelessId     = "0b6c7a52-1f0e-4c55-9d0e-6f1c8d1e2a04"
+)
+
+// sepaServer serves two inbox pages of invoices, the payment actions of each
+// document and the accounts of the senders. It counts the account lookups.
+func sepaServer(t *testing.T, lookups *int) http.HandlerFunc {
+	pages := map[float64][]map[string]any{
+		1: {
+			{"id": "inv-1", "subject": "Rechnung 1", "sender": stadtwerkeId, "type": "invoice", "payable": true},
+			{"id": "inv-2", "subject": "Rechnung 2", "sender": verwaltungId, "type": "invoice", "payable": true},
+			{"id": "info-1", "subject": "Hinweis", "sender": verwaltungId, "type": "invoice", "payable": false},
+			{"id": "inv-6", "subject": "Rechnung 6", "sender": verwaltungId, "type": "invoice", "payable": true},
+		},
+		2: {
+			{"id": "inv-3", "subject": "Rechnung 3", "sender": versicherungId, "type": "invoice", "payable": true},
+			{"id": "inv-4", "subject": "Rechnung 4", "sender": versicherungId, "type": "invoice", "payable": true},
+			{"id": "inv-5", "subject": "Rechnung 5", "sender": namelessId, "type": "invoice", "payable": true},
+		},
+	}
+	accounts := map[string]map[string]any{
+		stadtwerkeId:   {"company_name": "Stadtwerke Musterstadt", "type": "business"},
+		verwaltungId:   {"first_name": "Hans", "last_name": "Verwalter", "type": "private"},
+		versicherungId: {"company_name": "Versicherung AG", "type": "business"},
+		namelessId:     {"type": "business"},
+	}
+	actions := map[string]any{
+		"inv-1": map[string]any{"details": map[string]any{"amount": 12345, "currency": "EUR", "iban": "DE89 3704 0044 0532 0130 00", "reference": "RF18 5390 0754 7034"}},
+		"inv-2": map[string]any{"details": map[string]any{"amount": 50000, "currency": "EUR", "iban": "AT611904300234573201", "reference": "Miete Mai"}},
+		"inv-3": map[string]any{"details": map[string]any{"amount": 1000, "currency": "EUR", "iban": "DE00370400440532013000"}},
+		"inv-4": map[string]any{"details": map[string]any{"amount": 1000, "currency": "EUR", "iban": "DE89370400440532013000", "tink_payment_id": "tink-1"}},
+		"inv-5": map[string]any{"details": map[string]any{"amount": 2000, "currency": "EUR", "iban": "DE89370400440532013000"}},
+		"inv-6": map[string]any{"details": map[string]any{"amount": 3000, "currency": "EUR", "iban": "DE89370400440532013000", "reference": "Nebenkosten"}},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/mailbox/inbox":
+			var req struct {
+				Filter map[string]any `json:"filter"`
+				Page   float64        `json:"page"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("Invalid inbox request: %v", err)
+			}
+			if req.Filter["type"] != content.Invoice {
+				t.Errorf("Expected the search to filter invoices, got %v", req.Filter)
+			}
+			writeJson(w, 200, map[string]any{"total": 7, "results": pages[req.Page]})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/accounts/"):
+			*lookups++
+			account, ok := accounts[strings.TrimPrefix(r.URL.Path, "/v1/accounts/")]
+			if !ok {
+				writeJson(w, 404, api.ResponseError{Code: 40400, Message: "not found"})
+				return
+			}
+			writeJson(w, 200, account)
+		case strings.HasSuffix(r.URL.Path, "/actions"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/content/document/"), "/actions")
+			writeJson(w, 200, map[string]any{"payments": actions[id]})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(500)
+		}
+	}
+}
+
+func TestExportSepaTransfers(t *testing.T) {
+	lookups := 0
+	client := mockClient(t, sepaServer(t, &lookups))
+	var buf bytes.Buffer
+	export, err := content.ExportSepaTransfers(client, context.Background(), &content.SepaExportOptions{
+		DebtorName:    "Erika Mustermann",
+		DebtorIban:    "DE02120300000000202051",
+		MessageId:     "MSG-1",
+		ExecutionDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+	}, &buf)
+	if err != nil {
+		t.Fatalf("ExportSepaTransfers failed: %v", err)
+	}
+
+	if len(export.Transfers) != 3 || export.Transfers[0].DocumentId != "inv-1" || export.Transfers[1].DocumentId != "inv-2" || export.Transfers[2].DocumentId != "inv-6" {
+		t.Fatalf("Expected inv-1, inv-2 and inv-6 to be included, got %+v", export.Transfers)
+	}
+	if export.Transfers[1].SenderId != verwaltungId || export.Transfers[1].Creditor != "Hans Verwalter" {
+		t.Errorf("Expected the creditor name of the sender account, got %+v", export.Transfers[1])
+	}
+	if export.Total != payments.Cents(65345) {
+		t.Errorf("Expected a total of 653.45 EUR, got %s", export.Total)
+	}
+	skipped := map[string]string{}
+	for _, s := range export.Skipped {
+		skipped[s.DocumentId] = s.Reason
+	}
+	if len(skipped) != 3 || !strings.Contains(skipped["inv-3"], "iban") || !strings.Contains(skipped["inv-4"], "already paid") ||
+		!strings.Contains(skipped["inv-5"], "no name") {
+		t.Errorf("Expected inv-3, inv-4 and inv-5 to be skipped, got %v", skipped)
+	}
+	// one lookup per sender of a payable invoice
+	if lookups != 3 {
+		t.Errorf("Expected 3 account lookups, got %d", lookups)
+	}
+
+	var doc struct {
+		Namespace string `xml:"xmlns,attr"`
+		GrpHdr    struct {
+			MsgId   string
+			NbOfTxs int
+			CtrlSum string
+		} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+		PmtInf struct {
+			ReqdExctnDt string `xml:"ReqdExctnDt>Dt"`
+			DbtrAgt     string `xml:"DbtrAgt>FinInstnId>Othr>Id"`
+			Txs         []struct {
+				EndToEndId string `xml:"PmtId>EndToEndId"`
+				Amt        struct {
+					Ccy   string `xml:"Ccy,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"Amt>InstdAmt"`
+				Cdtr  string `xml:"Cdtr>Nm"`
+				Iban  string `xml:"CdtrAcct>Id>IBAN"`
+				Ustrd string `xml:"RmtInf>Ustrd"`
+				Scor  string `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"CstmrCdtTrfInitn>PmtInf"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Namespace != content.SepaNamespace || doc.GrpHdr.MsgId != "MSG-1" || doc.GrpHdr.NbOfTxs != 3 || doc.GrpHdr.CtrlSum != "653.45" {
+		t.Errorf("Unexpected group header %+v", doc.GrpHdr)
+	}
+	if doc.PmtInf.ReqdExctnDt != "2025-06-02" || doc.PmtInf.DbtrAgt != "NOTPROVIDED" {
+		t.Errorf("Unexpected payment information %+v", doc.PmtInf)
+	}
+	if len(doc.PmtInf.Txs) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(doc.PmtInf.Txs))
+	}
+	first, second := doc.PmtInf.Txs[0], doc.PmtInf.Txs[1]
+	if first.EndToEndId != "inv1" || first.Cdtr != "Stadtwerke Musterstadt" || first.Amt.Value != "123.45" || first.Amt.Ccy != "EUR" || first.Iban != "DE89370400440532013000" || first.Scor != "RF18539007547034" {
+		t.Errorf("Unexpected first transaction %+v", first)
+	}
+	if second.Cdtr != "Hans Verwalter" || second.Ustrd != "Miete Mai" || second.Scor != "" {
+		t.Errorf("Unexpected second transaction %+v", second)
+	}
+}
+
+func TestExportSepaTransfersCreditorName(t *testing.T) {
+	lookups := 0
+	client := mockClient(t, sepaServer(t, &lookups))
+	names := map[string]string{stadtwerkeId: "Stadtwerke", versicherungId: "Versicherung"}
+	export, err := content.ExportSepaTransfers(client, context.Background(), &content.SepaExportOptions{
+		DebtorName: "Erika Mustermann",
+		DebtorIban: "DE02120300000000202051",
+		CreditorName: func(ctx context.Context, senderId string) (string, error) {
+			if senderId == verwaltungId {
+				return "", errors.New("unknown sender")
+			}
+			return names[senderId], nil
+		},
+	}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("ExportSepaTransfers failed: %v", err)
+	}
+	if lookups != 0 {
+		t.Errorf("Expected no account lookups, got %d", lookups)
+	}
+	if len(export.Transfers) != 1 || export.Transfers[0].Creditor != "Stadtwerke" {
+		t.Errorf("Expected only inv-1 to be included, got %+v", export.Transfers)
+	}
+	skipped := map[string]string{}
+	for _, s := range export.Skipped {
+		skipped[s.DocumentId] = s.Reason
+	}
+	if !strings.Contains(skipped["inv-2"], "unknown sender") || !strings.Contains(skipped["inv-6"], "unknown sender") {
+		t.Errorf("Expected the invoices of the unknown sender to be skipped, got %v", skipped)
+	}
+}
+
+func TestExportSepaTransfersNothingToPay(t *testing.T) {
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 200, map[string]any{"total": 0, "results": []any{}})
+	})
+	var buf bytes.Buffer
+	export, err := content.ExportSepaTransfers(client, context.Background(), &content.SepaExportOptions{
+		DebtorName: "Erika Mustermann",
+		DebtorIban: "DE02120300000000202051",
+	}, &buf)
+	if err != nil {
+		t.Fatalf("ExportSepaTransfers failed: %v", err)
+	}
+	if len(export.Transfers) != 0 || buf.Len() != 0 {
+		t.Errorf("Expected no file without transfers, got %d bytes", buf.Len())
+	}
+}
+
+func TestExportSepaTransfersErrors(t *testing.T) {
+	_, err := content.ExportSepaTransfers(nil, context.Background(), &content.SepaExportOptions{
+		DebtorName: "Erika Mustermann",
+		DebtorIban: "DE00120300000000202051",
+	}, &bytes.Buffer{})
+	if !errors.Is(err, payments.ErrInvalidIban) {
+		t.Errorf("Expected ErrInvalidIban for the debtor, got %v", err)
+	}
+
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, 401, api.ResponseError{Code: 40100, Message: "unauthorized"})
+	})
+	_, err = content.ExportSepaTransfers(client, context.Background(), &content.SepaExportOptions{
+		DebtorName: "Erika Mustermann",
+		DebtorIban: "DE02120300000000202051",
+	}, &bytes.Buffer{})
+	var statusErr *content.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status.HttpStatus != 401 {
+		t.Errorf("Expected a StatusError for the failed search, got %v", err)
+	}
+}
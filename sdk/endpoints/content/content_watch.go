@@ -0,0 +1,285 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	sdkClient "github.com/brifle-de/brifle-sdk/sdk/client"
+)
+
+// Kinds of delivery events.
+const (
+	// DeliveryEventDelivered means the document was delivered to the Brifle
+	// mailbox of the receiver.
+	DeliveryEventDelivered = "delivered"
+	// DeliveryEventRead means the receiver opened the document.
+	DeliveryEventRead = "read"
+	// DeliveryEventPhysicalState means the physical delivery moved to a new
+	// state (preprocessing, processing, sent, error, test_sent or unknown).
+	DeliveryEventPhysicalState = "physical_state"
+	// DeliveryEventPhysicalError means Deutsche Post reported a new error.
+	DeliveryEventPhysicalError = "physical_error"
+	// DeliveryEventPollError means the status could not be retrieved.
+	DeliveryEventPollError = "poll_error"
+)
+
+// DeliveryEvent is a change of the delivery status of a watched document.
+type DeliveryEvent struct {
+	Kind       string
+	DocumentId string
+	// Status is the delivery status the event was derived from. It is nil
+	// for DeliveryEventPollError.
+	Status *DeliveryStatus
+	// PhysicalState is the new state for DeliveryEventPhysicalState.
+	PhysicalState PhysicalState
+	// Final is set on the last event of a document: it reached a terminal
+	// state, or polling failed permanently.
+	Final bool
+	// HttpStatus and Err describe a DeliveryEventPollError.
+	HttpStatus int
+	Err        error
+	Time       time.Time
+}
+
+// WatchOptions configure WatchDelivery. The zero value is usable.
+type WatchOptions struct {
+	// InitialInterval is the delay between polls of a document right after
+	// it changed. Defaults to 10 seconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between polls of a document that has not
+	// changed for a while. Defaults to 10 minutes.
+	MaxInterval time.Duration
+	// Multiplier increases the delay after every poll without change.
+	// Defaults to 2.
+	Multiplier float64
+	// UntilRead keeps watching documents delivered through Brifle until they
+	// were read. By default delivery is terminal.
+	UntilRead bool
+	// MaxErrors is the number of consecutive failed polls after which a
+	// document is given up. Defaults to 10.
+	MaxErrors int
+}
+
+// WatchDelivery polls the delivery status of the documents with the given ids
+// and sends an event for every change. Unchanged states are not reported. The
+// poll interval of a document grows while it does not change and is reset by
+// every change.
+//
+// The channel is closed when every document reached a terminal state (read or
+// delivered through Brifle, sent, error or test_sent for physical delivery),
+// was given up after repeated errors, or ctx ends. The caller must drain the
+// channel.
+//
+//	events, err := content.WatchDelivery(client, ctx, []string{documentId}, nil)
+//	for e := range events {
+//		fmt.Println(e.DocumentId, e.Kind, e.PhysicalState)
+//	}
+func WatchDelivery(client *sdkClient.BrifleClient, ctx context.Context, ids []string, opts *WatchOptions) (<-chan DeliveryEvent, error) {
+	watcher, err := newDeliveryWatcher(client, ids, opts)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan DeliveryEvent)
+	go func() {
+		defer close(events)
+		_ = watcher.run(ctx, func(e DeliveryEvent) {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return events, nil
+}
+
+// WatchDeliveryFunc is like WatchDelivery but calls fn for every event and
+// returns when watching ends. It returns the context's error if ctx ended
+// before every document reached a terminal state.
+func WatchDeliveryFunc(client *sdkClient.BrifleClient, ctx context.Context, ids []string, opts *WatchOptions, fn func(DeliveryEvent)) error {
+	if fn == nil {
+		return errors.New("fn is required")
+	}
+	watcher, err := newDeliveryWatcher(client, ids, opts)
+	if err != nil {
+		return err
+	}
+	return watcher.run(ctx, fn)
+}
+
+// watchedDocument is the polling state of a single document.
+type watchedDocument struct {
+	id       string
+	next     time.Time
+	interval time.Duration
+	errors   int
+	// last reported state
+	delivered     bool
+	read          bool
+	physicalState PhysicalState
+	physicalErrs  int
+}
+
+type deliveryWatcher struct {
+	client *sdkClient.BrifleClient
+	opts   WatchOptions
+	docs   []*watchedDocument
+}
+
+func newDeliveryWatcher(client *sdkClient.BrifleClient, ids []string, opts *WatchOptions) (*deliveryWatcher, error) {
+	if client == nil || client.ApiClient == nil {
+		return nil, errors.New("client or client.ApiClient cannot be nil")
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("ids is required")
+	}
+	w := &deliveryWatcher{client: client}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.InitialInterval <= 0 {
+		w.opts.InitialInterval = 10 * time.Second
+	}
+	if w.opts.MaxInterval <= 0 {
+		w.opts.MaxInterval = 10 * time.Minute
+	}
+	if w.opts.MaxInterval < w.opts.InitialInterval {
+		w.opts.MaxInterval = w.opts.InitialInterval
+	}
+	if w.opts.Multiplier < 1 {
+		w.opts.Multiplier = 2
+	}
+	if w.opts.MaxErrors <= 0 {
+		w.opts.MaxErrors = 10
+	}
+
+	seen := map[string]bool{}
+	now := time.Now()
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("document ID is required")
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		w.docs = append(w.docs, &watchedDocument{id: id, next: now, interval: w.opts.InitialInterval})
+	}
+	return w, nil
+}
+
+func (w *deliveryWatcher) run(ctx context.Context, emit func(DeliveryEvent)) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for len(w.docs) > 0 {
+		next := w.docs[0].next
+		for _, d := range w.docs[1:] {
+			if d.next.Before(next) {
+				next = d.next
+			}
+		}
+		timer.Reset(time.Until(next))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		remaining := w.docs[:0]
+		for _, d := range w.docs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if time.Now().Before(d.next) || !w.poll(ctx, d, emit) {
+				remaining = append(remaining, d)
+			}
+		}
+		w.docs = remaining
+	}
+	return nil
+}
+
+// poll retrieves the status of d, emits the changes and schedules the next
+// poll. It returns true when watching d has ended.
+func (w *deliveryWatcher) poll(ctx context.Context, d *watchedDocument, emit func(DeliveryEvent)) bool {
+	id := d.id
+	res, status, err := GetDeliveryStatus(w.client, ctx, &id)
+	now := time.Now()
+	if err == nil && (status == nil || status.HttpStatus != 200 || res == nil || res.ContentGetDeliveryStatusResponse == nil) {
+		err = errors.New("unexpected delivery status response")
+		if status != nil {
+			err = fmt.Errorf("brifle error %d (http %d)", status.ErrorCode, status.HttpStatus)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		d.errors++
+		event := DeliveryEvent{Kind: DeliveryEventPollError, DocumentId: id, Err: err, Time: now}
+		if status != nil {
+			event.HttpStatus = status.HttpStatus
+		}
+		// a missing document or a rejected request will not recover
+		event.Final = d.errors >= w.opts.MaxErrors || (status != nil && !api.IsTransientFailure(status, nil))
+		emit(event)
+		w.backoff(d, now)
+		return event.Final
+	}
+	d.errors = 0
+
+	var events []DeliveryEvent
+	add := func(kind string) *DeliveryEvent {
+		events = append(events, DeliveryEvent{Kind: kind, DocumentId: id, Status: res, Time: now})
+		return &events[len(events)-1]
+	}
+	ds := res.DeliveryStatus
+	terminal := false
+	if b := ds.Brifle; b != nil {
+		if b.DeliveredDate != "" && !d.delivered {
+			d.delivered = true
+			add(DeliveryEventDelivered)
+		}
+		if b.Read && !d.read {
+			d.read = true
+			add(DeliveryEventRead)
+		}
+		terminal = d.read || (d.delivered && !w.opts.UntilRead)
+	}
+	if p := ds.Physical; p != nil {
+		state := res.PhysicalState()
+		if state != d.physicalState {
+			d.physicalState = state
+			add(DeliveryEventPhysicalState).PhysicalState = state
+		}
+		if len(p.Errors) > d.physicalErrs {
+			d.physicalErrs = len(p.Errors)
+			add(DeliveryEventPhysicalError).PhysicalState = state
+		}
+		terminal = state.IsTerminal()
+	}
+
+	if len(events) > 0 {
+		d.interval = w.opts.InitialInterval
+		d.next = now.Add(d.interval)
+		if terminal {
+			events[len(events)-1].Final = true
+		}
+		for _, e := range events {
+			emit(e)
+		}
+	} else {
+		w.backoff(d, now)
+	}
+	return terminal
+}
+
+func (w *deliveryWatcher) backoff(d *watchedDocument, now time.Time) {
+	d.next = now.Add(d.interval)
+	d.interval = time.Duration(float64(d.interval) * w.opts.Multiplier)
+	if d.interval > w.opts.MaxInterval {
+		d.interval = w.opts.MaxInterval
+	}
+}
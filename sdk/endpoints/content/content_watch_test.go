@@ -0,0 +1,99 @@
+package content_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/api"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+)
+
+func TestWatchDelivery(t *testing.T) {
+	sequences := map[string][]string{
+		// delivered through Brifle, read on the third poll
+		"doc-b": {
+			`{"delivery_mode": "brifle", "document_id": "doc-b", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": false}}`,
+			`{"delivery_mode": "brifle", "document_id": "doc-b", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": false}}`,
+			`{"delivery_mode": "brifle", "document_id": "doc-b", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": true}}`,
+		},
+		// paper mail moving through its states
+		"doc-p": {
+			`{"delivery_mode": "physical", "document_id": "doc-p", "physical": {"state": "preprocessing", "errors": []}}`,
+			`{"delivery_mode": "physical", "document_id": "doc-p", "physical": {"state": "preprocessing", "errors": []}}`,
+			`{"delivery_mode": "physical", "document_id": "doc-p", "physical": {"state": "processing", "errors": []}}`,
+			`{"delivery_mode": "physical", "document_id": "doc-p", "physical": {"state": "sent", "errors": []}}`,
+		},
+		// unavailable on the first poll
+		"doc-busy": {
+			`{"delivery_mode": "brifle", "document_id": "doc-busy", "brifle": {"delivered_date": "2025-03-01T10:00:00Z", "read": true}}`,
+		},
+	}
+	var mu sync.Mutex
+	calls := map[string]int{}
+	client := mockClient(t, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/content/document/"), "/")[0]
+		mu.Lock()
+		n := calls[id]
+		calls[id]++
+		mu.Unlock()
+		seq, ok := sequences[id]
+		if !ok {
+			writeJson(w, 404, api.ResponseError{Code: 40400, Message: "document not found"})
+			return
+		}
+		if id == "doc-busy" && n == 0 {
+			writeJson(w, 503, api.ResponseError{Code: 50300, Message: "unavailable"})
+			return
+		}
+		if n >= len(seq) {
+			n = len(seq) - 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"delivery_status": ` + seq[n] + `}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := &content.WatchOptions{InitialInterval: time.Millisecond, MaxInterval: 4 * time.Millisecond, UntilRead: true}
+	events, err := content.WatchDelivery(client, ctx, []string{"doc-b", "doc-p", "doc-busy", "missing"}, opts)
+	if err != nil {
+		t.Errorf("WatchDelivery failed: %v", err)
+		return
+	}
+
+	got := map[string][]string{}
+	final := map[string]bool{}
+	for e := range events {
+		kind := e.Kind
+		if e.PhysicalState != "" {
+			kind += ":" + string(e.PhysicalState)
+		}
+		got[e.DocumentId] = append(got[e.DocumentId], kind)
+		if final[e.DocumentId] {
+			t.Errorf("Event after the final event of %s: %+v", e.DocumentId, e)
+		}
+		final[e.DocumentId] = e.Final
+	}
+	if ctx.Err() != nil {
+		t.Errorf("Expected watching to end before the timeout")
+	}
+
+	expected := map[string]string{
+		"doc-b":    "delivered read",
+		"doc-p":    "physical_state:preprocessing physical_state:processing physical_state:sent",
+		"doc-busy": "poll_error delivered read",
+		"missing":  "poll_error",
+	}
+	for id, want := range expected {
+		if strings.Join(got[id], " ") != want {
+			t.Errorf("%s: expected events %q, got %q", id, want, got[id])
+		}
+		if !final[id] {
+			t.Errorf("%s: expected the last event to be final", id)
+		}
+	}
+}
@@ -2,9 +2,19 @@
 //
 // It also covers receiver checks ([CheckReceiver], [CheckReceiverBulk]),
 // delivery certificates ([GetDeliveryCertificate]), delivery status
-// ([GetDeliveryStatus]), paper-mail previews ([PreviewPaperMail]) and cover
-// letter management ([UploadCoverLetter], [ListCoverLetters], [GetCoverLetter],
-// [DeleteCoverLetter]).
+// ([GetDeliveryStatus], [WatchDelivery]), paper-mail previews
+// ([PreviewPaperMail]) and cover letter management ([UploadCoverLetter],
+// [ListCoverLetters], [GetCoverLetter], [DeleteCoverLetter]). Images and plain text can be converted into PDFs
+// locally with [ConvertToPdf], [ImagesToPdf] and [TextToPdf]. [BulkSender]
+// sends large numbers of documents concurrently and can resume interrupted
+// runs from a [Journal]. [IdempotentSender] prevents duplicate documents when
+// a send is retried after a timeout. [FallbackEngine] enables paper mail
+// only for receivers that are not on Brifle, and [RecipientFromAddress] turns
+// a free-form address into a paper mail recipient. [FormatAddressBlock]
+// formats and checks the printed DIN 5008 address block. [Document] fetches and caches everything
+// about a single document, and [ExportEvidence] bundles it into one container
+// for disputes. [ExportSepaTransfers] turns the payable invoices of the inbox
+// into a SEPA credit transfer file.
 //
 // # Sending a document
 //
@@ -32,6 +42,10 @@
 // — set exactly one of BirthInformation, Email or Phone. Birth information is
 // the most precise and is recommended for sensitive content.
 //
+// [AddressBook] stores the receivers of your customers with the result of
+// their last [CheckReceiver], so that receivers are not checked before every
+// send.
+//
 // # Document types
 //
 // Use the [Letter], [Invoice] and [Contract] constants for the document Type.
@@ -13,5 +13,8 @@
 //		fmt.Println("reference id:", res.Id)
 //	}
 //
+// Exported signatures can be parsed with ParseExportedSignature and verified
+// offline against trust anchors with Verifier.
+//
 // See docs/signatures.md for more examples.
 package signatures
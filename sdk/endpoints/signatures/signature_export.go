@@ -0,0 +1,286 @@
+package signatures
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/certificates"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/xmlsig"
+)
+
+// ExportedSignature is a signature exported by ExportSignature. Values read
+// from the XML are not trusted until the signature was verified; use
+// Verification.Signed for the values covered by the XML signature.
+type ExportedSignature struct {
+	// XML is the raw export.
+	XML []byte
+	// Root is the name of the root element.
+	Root string
+	// Id is the ID of the signature, if the export contains it.
+	Id string
+	// Signer is the name of the person who signed, SignerId their account.
+	Signer   string
+	SignerId string
+	// SignedFor is the person or organisation the signer signed on behalf
+	// of, if any.
+	SignedFor string
+	// Field, Purpose and Role are the signature field of the signature
+	// reference that was signed.
+	Field   string
+	Purpose string
+	Role    string
+	// SigningTime is the signing time stated in the export.
+	SigningTime time.Time
+	// DocumentHash is the hash of the signed document as stated in the
+	// export.
+	DocumentHash    string
+	SignatureMethod string
+	DigestMethod    string
+	// SignatureValue is the value of the XML signature.
+	SignatureValue []byte
+	// Certificates are the certificates embedded in the XML signature; the
+	// first one is the signing certificate.
+	Certificates []*x509.Certificate
+	// Fields are all values of the export in document order.
+	Fields []certificates.Field
+}
+
+// Field names from which the typed values of an ExportedSignature are read,
+// in order of preference. The schema of the export is not published; the
+// names follow the embedded signatures of a document in the API (signed_by,
+// signed_for, field_name, purpose, signature_date) and are matched like
+// certificates.Certificate.Field does, so SignedBy and signed_by are the
+// same name.
+var (
+	namesId           = []string{"document_signature_id", "signature_id", "id"}
+	namesSigner       = []string{"signed_by", "signer_name", "signer"}
+	namesSignerId     = []string{"signed_by_id", "signer_id", "account_id"}
+	namesSignedFor    = []string{"signed_for", "signed_for_name"}
+	namesField        = []string{"field_name", "signature_field"}
+	namesPurpose      = []string{"purpose", "field_purpose"}
+	namesRole         = []string{"role", "field_role"}
+	namesSigningTime  = []string{"signature_date", "signing_time", "signed_at"}
+	namesDocumentHash = []string{"document_hash", "signed_document_hash", "document_sha256"}
+)
+
+// ParseExportedSignature parses the XML returned by ExportSignature. The
+// typed values are looked up by field name, e.g. signed_by for Signer and
+// document_hash for DocumentHash; they are empty if the export has no such
+// field. All values are available as Fields.
+//
+//	xml, _, err := signatures.ExportSignature(client, ctx, &signatureId, &signatures.ExportOptions{Format: "xml"})
+//	sig, err := signatures.ParseExportedSignature(*xml)
+func ParseExportedSignature(xml string) (*ExportedSignature, error) {
+	if strings.TrimSpace(xml) == "" {
+		return nil, errors.New("signature export is empty")
+	}
+	data := []byte(xml)
+	root, sig, err := xmlsig.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := exportedSignature(xmlsig.Fields(root))
+	if err != nil {
+		return nil, err
+	}
+	s.XML = data
+	s.Root = root.Tag
+	if sig != nil {
+		s.SignatureMethod = sig.SignatureMethod
+		s.DigestMethod = sig.DigestMethod
+		s.SignatureValue = sig.Value
+		s.Certificates = sig.Certificates
+	}
+	return s, nil
+}
+
+// exportedSignature reads the typed values from the fields of an export.
+func exportedSignature(in []xmlsig.Field) (*ExportedSignature, error) {
+	s := &ExportedSignature{Fields: make([]certificates.Field, len(in))}
+	for i, f := range in {
+		s.Fields[i] = certificates.Field{Path: f.Path, Name: f.Name, Value: f.Value}
+	}
+	s.Id = xmlsig.Lookup(in, namesId...)
+	s.SignerId = xmlsig.Lookup(in, namesSignerId...)
+	s.Signer = xmlsig.Lookup(in, namesSigner...)
+	s.SignedFor = xmlsig.Lookup(in, namesSignedFor...)
+	s.Field = xmlsig.Lookup(in, namesField...)
+	s.Purpose = xmlsig.Lookup(in, namesPurpose...)
+	s.Role = xmlsig.Lookup(in, namesRole...)
+	s.DocumentHash = xmlsig.Lookup(in, namesDocumentHash...)
+	t, err := xmlsig.ParseTime(xmlsig.Lookup(in, namesSigningTime...))
+	if err != nil {
+		return nil, fmt.Errorf("signing time: %w", err)
+	}
+	s.SigningTime = t
+	return s, nil
+}
+
+// Value returns the value of the field with the given path, e.g.
+// "SignedBy" or "@Id" for an attribute of the root. Paths are case
+// sensitive.
+func (s *ExportedSignature) Value(path string) string {
+	for _, f := range s.Fields {
+		if f.Path == path {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Verification is the outcome of verifying an exported signature. It uses
+// the check names of the certificates package: certificate_chain,
+// xml_signature and signed_document_hash.
+type Verification struct {
+	// Valid is set when no check failed, the XML signature and chain were
+	// verified and the signed document hash matches the document. It is
+	// never set without a document.
+	Valid bool `json:"valid"`
+	// DocumentSha256 is the hex encoded hash of the document passed to
+	// Verify.
+	DocumentSha256 string `json:"document_sha256,omitempty"`
+	// VerifiedAt is the time of the verification; ValidAt is the time at
+	// which the certificates had to be valid.
+	VerifiedAt      time.Time                      `json:"verified_at"`
+	ValidAt         time.Time                      `json:"valid_at"`
+	SignatureMethod string                         `json:"signature_method,omitempty"`
+	DigestMethod    string                         `json:"digest_method,omitempty"`
+	Signer          *certificates.CertificateInfo  `json:"signer,omitempty"`
+	Chain           []certificates.CertificateInfo `json:"chain,omitempty"`
+	// Signed holds the values covered by the XML signature. It is nil if
+	// the signature is invalid.
+	Signed *ExportedSignature   `json:"-"`
+	Checks []certificates.Check `json:"checks"`
+}
+
+// Check returns the check with the given name.
+func (v *Verification) Check(name string) (certificates.Check, bool) {
+	for _, c := range v.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return certificates.Check{}, false
+}
+
+// Verifier verifies exported signatures offline against a fixed set of
+// trust anchors.
+type Verifier struct {
+	// Roots are the trust anchors. Required.
+	Roots *x509.CertPool
+	// Intermediates complete the chain if the export does not embed it.
+	// Optional.
+	Intermediates []*x509.Certificate
+	// Time at which the certificates must be valid. Defaults to the current
+	// time. The signing time of the export is not used: it is only verified
+	// together with the certificates.
+	Time time.Time
+}
+
+// Verify checks the XML signature of sig and that the signed document hash
+// matches the document. The signed content must name the signer and the
+// document hash. Without a document the hash check is skipped and the
+// result is not Valid. Failed checks are reported in the Verification; an
+// error is only returned if the verification could not be run at all.
+//
+//	roots, _ := certificates.LoadTrustAnchorsFile("trust-anchors.pem")
+//	res, err := (&signatures.Verifier{Roots: roots}).Verify(sig, pdfBytes)
+//	if err == nil && res.Valid {
+//		fmt.Println(res.Signed.Signer, "signed", res.Signed.Field, "at", res.Signed.SigningTime)
+//	}
+func (v *Verifier) Verify(sig *ExportedSignature, document []byte) (*Verification, error) {
+	if sig == nil {
+		return nil, errors.New("signature is required")
+	}
+	if v.Roots == nil {
+		return nil, errors.New("trust anchors are required")
+	}
+
+	res := &Verification{
+		VerifiedAt:      time.Now().UTC(),
+		SignatureMethod: sig.SignatureMethod,
+		DigestMethod:    sig.DigestMethod,
+	}
+	check := func(name, result, format string, args ...any) {
+		res.Checks = append(res.Checks, certificates.Check{Name: name, Result: result, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	res.ValidAt = v.Time
+	if res.ValidAt.IsZero() {
+		res.ValidAt = res.VerifiedAt
+	}
+
+	if len(sig.Certificates) == 0 {
+		check(certificates.CheckCertificateChain, certificates.CheckFailed, "signature has no signing certificate")
+		check(certificates.CheckSignature, certificates.CheckFailed, "signature has no signing certificate")
+	} else {
+		info := certificates.NewCertificateInfo(sig.Certificates[0])
+		res.Signer = &info
+		content, chain, err := xmlsig.Verify(sig.XML, &xmlsig.Signature{Certificates: sig.Certificates}, xmlsig.Options{
+			Roots:         v.Roots,
+			Intermediates: v.Intermediates,
+			Time:          res.ValidAt,
+		})
+		for _, c := range chain {
+			res.Chain = append(res.Chain, certificates.NewCertificateInfo(c))
+		}
+		switch {
+		case chain == nil:
+			check(certificates.CheckCertificateChain, certificates.CheckFailed, "%v", err)
+			check(certificates.CheckSignature, certificates.CheckSkipped, "the signing certificate is not trusted")
+		case err != nil:
+			check(certificates.CheckCertificateChain, certificates.CheckPassed, "trusted at %s", res.ValidAt.Format(time.RFC3339))
+			check(certificates.CheckSignature, certificates.CheckFailed, "%v", err)
+		default:
+			check(certificates.CheckCertificateChain, certificates.CheckPassed, "trusted at %s", res.ValidAt.Format(time.RFC3339))
+			signed, err := exportedSignature(xmlsig.Fields(content))
+			if err != nil {
+				check(certificates.CheckSignature, certificates.CheckFailed, "signed content: %v", err)
+				break
+			}
+			if signed.Signer == "" && signed.SignerId == "" {
+				check(certificates.CheckSignature, certificates.CheckFailed, "the signed content names no signer")
+				break
+			}
+			check(certificates.CheckSignature, certificates.CheckPassed, "signed by %s", res.Signer.Subject)
+			signed.XML = sig.XML
+			signed.Root = content.Tag
+			signed.SignatureMethod = sig.SignatureMethod
+			signed.DigestMethod = sig.DigestMethod
+			signed.SignatureValue = sig.SignatureValue
+			signed.Certificates = sig.Certificates
+			res.Signed = signed
+		}
+	}
+
+	if document != nil {
+		sum := sha256.Sum256(document)
+		res.DocumentSha256 = hex.EncodeToString(sum[:])
+	}
+	switch {
+	case res.Signed == nil:
+		check(certificates.CheckSignedDocumentHash, certificates.CheckSkipped, "the signature was not verified")
+	case res.Signed.DocumentHash == "":
+		check(certificates.CheckSignedDocumentHash, certificates.CheckFailed, "the signed content has no document hash")
+	case document == nil:
+		check(certificates.CheckSignedDocumentHash, certificates.CheckSkipped, "no document given")
+	case strings.EqualFold(res.Signed.DocumentHash, res.DocumentSha256):
+		check(certificates.CheckSignedDocumentHash, certificates.CheckPassed, "signed document hash matches the document")
+	default:
+		check(certificates.CheckSignedDocumentHash, certificates.CheckFailed, "signed document hash %s does not match the document hash %s", res.Signed.DocumentHash, res.DocumentSha256)
+	}
+
+	hash, _ := res.Check(certificates.CheckSignedDocumentHash)
+	res.Valid = res.Signed != nil && hash.Result == certificates.CheckPassed
+	for _, c := range res.Checks {
+		if c.Result == certificates.CheckFailed {
+			res.Valid = false
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package signatures_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/certificates"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/signatures"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/xmlsig/xmlsigtest"
+)
+
+var contract = []byte("%PDF-1.4 signed contract")
+
+func contractHash() string {
+	sum := sha256.Sum256(contract)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// exportXml uses the field names of the embedded signatures of a document.
+func exportXml(hash string) string {
+	return exportXmlWith(`<signed_by>Max Mustermann</signed_by>`, hash)
+}
+
+func exportXmlWith(signer, hash string) string {
+	doc := `<signature_export><document_signature_id>sig-1</document_signature_id>` + signer +
+		`<signed_for>Muster GmbH</signed_for>` +
+		`<field_name>signature_1</field_name><purpose>approval</purpose><role>customer</role>` +
+		`<signature_date>2025-03-01T10:00:00Z</signature_date>`
+	if hash != "" {
+		doc += `<document_hash>` + hash + `</document_hash>`
+	}
+	return doc + `</signature_export>`
+}
+
+func TestParseExportedSignature(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Max Mustermann")
+	sig, err := signatures.ParseExportedSignature(string(authority.Sign(t, exportXml(contractHash()))))
+	if err != nil {
+		t.Errorf("ParseExportedSignature failed: %v", err)
+		return
+	}
+	if sig.Signer != "Max Mustermann" || sig.SignedFor != "Muster GmbH" ||
+		sig.Field != "signature_1" || sig.Purpose != "approval" || sig.Role != "customer" || sig.Id != "sig-1" {
+		t.Errorf("Unexpected signature %+v", sig)
+	}
+	if !sig.SigningTime.Equal(time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)) || sig.DocumentHash != contractHash() {
+		t.Errorf("Unexpected signing time %v or hash %s", sig.SigningTime, sig.DocumentHash)
+	}
+	if len(sig.SignatureValue) == 0 || len(sig.Certificates) != 2 {
+		t.Errorf("Expected a signature value and two certificates, got %d bytes and %d", len(sig.SignatureValue), len(sig.Certificates))
+	}
+
+	// names are matched in other spellings, but a document ID is not a hash
+	other, err := signatures.ParseExportedSignature(`<SignatureExport><SignedBy><Id>acc-1</Id><Name>Max Mustermann</Name></SignedBy><DocumentId>` + contractHash() + `</DocumentId></SignatureExport>`)
+	if err != nil {
+		t.Errorf("ParseExportedSignature failed: %v", err)
+		return
+	}
+	if other.Signer != "" || other.SignerId != "acc-1" || other.DocumentHash != "" || other.Value("SignedBy/Name") != "Max Mustermann" {
+		t.Errorf("Unexpected values %+v", other)
+	}
+
+	if _, err := signatures.ParseExportedSignature(""); err == nil {
+		t.Errorf("Expected an error for an empty export")
+	}
+}
+
+func TestVerifyExportedSignature(t *testing.T) {
+	authority := xmlsigtest.NewAuthority(t, "Max Mustermann")
+	signed := string(authority.Sign(t, exportXml(contractHash())))
+
+	verifier := &signatures.Verifier{Roots: authority.Roots()}
+	sig, err := signatures.ParseExportedSignature(signed)
+	if err != nil {
+		t.Errorf("ParseExportedSignature failed: %v", err)
+		return
+	}
+	res, err := verifier.Verify(sig, contract)
+	if err != nil {
+		t.Errorf("Verify failed: %v", err)
+		return
+	}
+	if !res.Valid || res.Signed == nil || res.Signed.Signer != "Max Mustermann" || len(res.Chain) != 3 {
+		t.Errorf("Expected a valid signature, got %+v", res.Checks)
+	}
+	// the test certificates are not yet valid at the signing time of the export
+	if !res.ValidAt.Equal(res.VerifiedAt) {
+		t.Errorf("Expected the certificates to be checked at the current time, got %s", res.ValidAt)
+	}
+
+	tests := []struct {
+		name     string
+		xml      string
+		roots    *xmlsigtest.Authority
+		document []byte
+		failed   string
+	}{
+		{"tampered", strings.Replace(signed, "Max Mustermann", "Erika Mustermann", 1), authority, contract, certificates.CheckSignature},
+		{"untrusted", signed, xmlsigtest.NewAuthority(t, "Other"), contract, certificates.CheckCertificateChain},
+		{"other document", signed, authority, []byte("other"), certificates.CheckSignedDocumentHash},
+		{"no signer", string(authority.Sign(t, exportXmlWith("", contractHash()))), authority, contract, certificates.CheckSignature},
+		{"no document hash", string(authority.Sign(t, exportXml(""))), authority, contract, certificates.CheckSignedDocumentHash},
+	}
+	for _, tt := range tests {
+		sig, err := signatures.ParseExportedSignature(tt.xml)
+		if err != nil {
+			t.Errorf("%s: ParseExportedSignature failed: %v", tt.name, err)
+			continue
+		}
+		res, err := (&signatures.Verifier{Roots: tt.roots.Roots(), Time: time.Now()}).Verify(sig, tt.document)
+		if err != nil {
+			t.Errorf("%s: Verify failed: %v", tt.name, err)
+			continue
+		}
+		if c, _ := res.Check(tt.failed); res.Valid || c.Result != certificates.CheckFailed {
+			t.Errorf("%s: expected %s to fail, got %+v", tt.name, tt.failed, res.Checks)
+		}
+	}
+
+	// without a document the hash is not compared and the result not valid
+	res, err = verifier.Verify(sig, nil)
+	if err != nil {
+		t.Errorf("Verify failed: %v", err)
+		return
+	}
+	if c, _ := res.Check(certificates.CheckSignedDocumentHash); res.Valid || res.Signed == nil || c.Result != certificates.CheckSkipped {
+		t.Errorf("Expected a verified signature without a valid result, got %+v", res.Checks)
+	}
+}
@@ -0,0 +1,18 @@
+// Package girocode generates EPC QR codes (EPC069-12, also known as
+// GiroCode) for SEPA credit transfers. Banking apps read the IBAN, amount and
+// reference from the code, so recipients of paper mail can pay an invoice as
+// easily as with the in-app payment link.
+//
+// A code is built from the payment details of an invoice and the name of the
+// beneficiary, and can be exported as PNG or SVG or stamped onto the invoice
+// PDF before it is sent:
+//
+//	req.PaymentInfo = &content.PaymentInfo{Details: details}
+//	req.Body = &[]content.ContentItem{content.PdfContentItem(invoicePdf)}
+//	if err := girocode.StampInvoice(req, "Muster GmbH", nil); err != nil {
+//		return err
+//	}
+//	res, status, err := content.SendContent(client, ctx, &tenant, req)
+//
+// See docs/girocode.md for more examples.
+package girocode
@@ -0,0 +1,235 @@
+package girocode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+	"github.com/skip2/go-qrcode"
+)
+
+// Limits of the EPC069-12 format.
+const (
+	MaxNameLength        = 70
+	MaxTextLength        = 140
+	MaxInformationLength = 70
+	MaxPayloadBytes      = 331
+	// MaxAmount is the largest amount in euro cents, 999,999,999.99 EUR.
+	MaxAmount = 99999999999
+)
+
+var purposeCode = regexp.MustCompile(`^[A-Z0-9]{4}$`)
+
+// Payment is the content of an EPC QR code.
+type Payment struct {
+	// Name of the beneficiary. Required.
+	Name string
+	// Iban of the beneficiary. Required, must be in a SEPA country.
+	Iban string
+	// Bic of the beneficiary's bank. Optional within the EEA.
+	Bic string
+	// Amount to transfer. Optional, must be in EUR.
+	Amount *payments.Money
+	// Purpose is an ISO 20022 purpose code, e.g. "GDDS". Optional.
+	Purpose string
+	// Reference is an RF creditor reference (ISO 11649). Only one of
+	// Reference and Text may be set.
+	Reference string
+	// Text is the unstructured remittance information.
+	Text string
+	// Information is shown to the payer by the banking app. Optional.
+	Information string
+}
+
+// FromPaymentDetails builds a code from the payment details of an invoice.
+// A reference that is a valid RF creditor reference is used as structured
+// reference, any other reference as remittance text. The description is
+// shown to the payer and shortened to 70 characters.
+func FromPaymentDetails(name string, details *content.PaymentDetails) (*Payment, error) {
+	if details == nil {
+		return nil, errors.New("payment details are required")
+	}
+	p := &Payment{
+		Name:   name,
+		Iban:   payments.NormalizeIban(strVal(details.Iban)),
+		Amount: details.Amount,
+	}
+	if ref := strVal(details.Reference); ref != "" {
+		if payments.ValidateCreditorReference(ref) == nil {
+			p.Reference = strings.ToUpper(strings.ReplaceAll(ref, " ", ""))
+		} else {
+			p.Text = ref
+		}
+	}
+	if desc := strings.TrimSpace(strVal(details.Description)); desc != "" {
+		if runes := []rune(desc); len(runes) > MaxInformationLength {
+			desc = string(runes[:MaxInformationLength])
+		}
+		p.Information = desc
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the fields against the EPC069-12 constraints.
+func (p *Payment) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return errors.New("beneficiary name is required")
+	case utf8.RuneCountInString(p.Name) > MaxNameLength:
+		return fmt.Errorf("beneficiary name has more than %d characters", MaxNameLength)
+	}
+	if err := payments.ValidateIban(p.Iban); err != nil {
+		return err
+	}
+	if country := payments.IbanCountry(p.Iban); !payments.IsSepaCountry(country) {
+		return fmt.Errorf("%w: %s is not a SEPA country", payments.ErrInvalidIban, country)
+	}
+	if p.Bic != "" {
+		if err := payments.ValidateBic(p.Bic); err != nil {
+			return err
+		}
+	}
+	if p.Amount != nil {
+		switch {
+		case p.Amount.Currency != "EUR":
+			return fmt.Errorf("%w: EPC QR codes only support EUR, got %q", payments.ErrInvalidCurrency, p.Amount.Currency)
+		case p.Amount.Amount < 1 || p.Amount.Amount > MaxAmount:
+			return fmt.Errorf("amount %s is out of range", p.Amount)
+		}
+	}
+	if p.Purpose != "" && !purposeCode.MatchString(p.Purpose) {
+		return fmt.Errorf("purpose %q is not a 4 character code", p.Purpose)
+	}
+	switch {
+	case p.Reference != "" && p.Text != "":
+		return errors.New("only one of reference and text may be set")
+	case p.Reference != "":
+		if err := payments.ValidateCreditorReference(p.Reference); err != nil {
+			return err
+		}
+	case utf8.RuneCountInString(p.Text) > MaxTextLength:
+		return fmt.Errorf("%w: text has more than %d characters", payments.ErrInvalidReference, MaxTextLength)
+	}
+	if utf8.RuneCountInString(p.Information) > MaxInformationLength {
+		return fmt.Errorf("information has more than %d characters", MaxInformationLength)
+	}
+	for _, v := range []string{p.Name, p.Text, p.Information} {
+		if strings.ContainsAny(v, "\r\n") {
+			return errors.New("fields must not contain line breaks")
+		}
+	}
+	return nil
+}
+
+// Payload returns the EPC069-12 payload (version 002, UTF-8) encoded in
+// the QR code.
+func (p *Payment) Payload() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	amount := ""
+	if p.Amount != nil {
+		amount = "EUR" + p.Amount.Decimal()
+	}
+	lines := []string{
+		"BCD",
+		"002",
+		"1",
+		"SCT",
+		strings.ToUpper(strings.TrimSpace(p.Bic)),
+		strings.TrimSpace(p.Name),
+		payments.NormalizeIban(p.Iban),
+		amount,
+		p.Purpose,
+		strings.ToUpper(strings.ReplaceAll(p.Reference, " ", "")),
+		p.Text,
+		p.Information,
+	}
+	// trailing empty fields are omitted
+	for lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	payload := strings.Join(lines, "\n")
+	if len(payload) > MaxPayloadBytes {
+		return "", fmt.Errorf("payload has %d bytes, at most %d are allowed", len(payload), MaxPayloadBytes)
+	}
+	return payload, nil
+}
+
+// code encodes the payload with error correction level M, as required by
+// the EPC guidelines.
+func (p *Payment) code() (*qrcode.QRCode, error) {
+	payload, err := p.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.New(payload, qrcode.Medium)
+}
+
+// PNG renders the code as a PNG image of size × size pixels.
+func (p *Payment) PNG(size int) ([]byte, error) {
+	if size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+	q, err := p.code()
+	if err != nil {
+		return nil, err
+	}
+	return q.PNG(size)
+}
+
+// SVG renders the code as an SVG image of size × size pixels. The image
+// scales without loss, so the size is only its default.
+func (p *Payment) SVG(size int) ([]byte, error) {
+	if size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+	q, err := p.code()
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for _, run := range runs(row) {
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", run[0], y, run[1], run[1])
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+// runs returns the horizontal runs of dark modules of a row as start and
+// length.
+func runs(row []bool) [][2]int {
+	var out [][2]int
+	for x := 0; x < len(row); x++ {
+		if !row[x] {
+			continue
+		}
+		start := x
+		for x < len(row) && row[x] {
+			x++
+		}
+		out = append(out, [2]int{start, x - start})
+	}
+	return out
+}
+
+func strVal(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
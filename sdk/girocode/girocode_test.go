@@ -0,0 +1,112 @@
+package girocode_test
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/girocode"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func testDetails() *content.PaymentDetails {
+	amount := payments.Cents(123456)
+	return &content.PaymentDetails{
+		Amount:      &amount,
+		Iban:        sdk.String("DE89 3704 0044 0532 0130 00"),
+		Reference:   sdk.String("RF18 5390 0754 7034"),
+		Description: sdk.String("Rechnung 2025-17"),
+		DueDate:     sdk.String("2025-04-01"),
+	}
+}
+
+func TestPayload(t *testing.T) {
+	p, err := girocode.FromPaymentDetails("Muster GmbH", testDetails())
+	if err != nil {
+		t.Errorf("FromPaymentDetails failed: %v", err)
+		return
+	}
+	payload, err := p.Payload()
+	if err != nil {
+		t.Errorf("Payload failed: %v", err)
+		return
+	}
+	expected := "BCD\n002\n1\nSCT\n\nMuster GmbH\nDE89370400440532013000\nEUR1234.56\n\nRF18539007547034\n\nRechnung 2025-17"
+	if payload != expected {
+		t.Errorf("Unexpected payload %q", payload)
+	}
+
+	details := testDetails()
+	details.Reference = sdk.String("R-1")
+	details.Description = nil
+	p, err = girocode.FromPaymentDetails("Muster GmbH", details)
+	if err != nil {
+		t.Errorf("FromPaymentDetails failed: %v", err)
+		return
+	}
+	p.Bic = "COBADEFFXXX"
+	payload, _ = p.Payload()
+	if payload != "BCD\n002\n1\nSCT\nCOBADEFFXXX\nMuster GmbH\nDE89370400440532013000\nEUR1234.56\n\n\nR-1" {
+		t.Errorf("Expected the reference as text, got %q", payload)
+	}
+}
+
+func TestPaymentValidation(t *testing.T) {
+	if _, err := girocode.FromPaymentDetails("", testDetails()); err == nil {
+		t.Error("Expected an error without beneficiary name")
+	}
+	details := testDetails()
+	amount := payments.Money{Amount: 100, Currency: "CHF"}
+	details.Amount = &amount
+	details.Iban = sdk.String("CH9300762011623852957")
+	if _, err := girocode.FromPaymentDetails("Muster AG", details); !errors.Is(err, payments.ErrInvalidCurrency) {
+		t.Errorf("Expected ErrInvalidCurrency, got %v", err)
+	}
+	details = testDetails()
+	details.Iban = sdk.String("BR1800360305000010009795493C1")
+	if _, err := girocode.FromPaymentDetails("Muster Ltda", details); !errors.Is(err, payments.ErrInvalidIban) {
+		t.Errorf("Expected ErrInvalidIban for a non-SEPA account, got %v", err)
+	}
+	details = testDetails()
+	details.Description = sdk.String(strings.Repeat("x", 100))
+	if p, err := girocode.FromPaymentDetails("Muster GmbH", details); err != nil || len(p.Information) != girocode.MaxInformationLength {
+		t.Errorf("Expected the description to be shortened: %v", err)
+	}
+	p := &girocode.Payment{Name: "Muster GmbH", Iban: "DE89370400440532013000", Reference: "RF18539007547034", Text: "R-1"}
+	if _, err := p.Payload(); err == nil {
+		t.Error("Expected an error for both reference and text")
+	}
+}
+
+func TestImages(t *testing.T) {
+	p, err := girocode.FromPaymentDetails("Muster GmbH", testDetails())
+	if err != nil {
+		t.Errorf("FromPaymentDetails failed: %v", err)
+		return
+	}
+	data, err := p.PNG(256)
+	if err != nil {
+		t.Errorf("PNG failed: %v", err)
+		return
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() != 256 {
+		t.Errorf("Expected a 256 pixel PNG: %v", err)
+	}
+	svg, err := p.SVG(256)
+	if err != nil {
+		t.Errorf("SVG failed: %v", err)
+		return
+	}
+	if !bytes.HasPrefix(svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`)) || !bytes.Contains(svg, []byte("M")) {
+		t.Errorf("Unexpected SVG %s", svg)
+	}
+	again, _ := p.SVG(256)
+	if !bytes.Equal(svg, again) {
+		t.Error("Expected a deterministic SVG")
+	}
+}
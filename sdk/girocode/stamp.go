@@ -0,0 +1,116 @@
+package girocode
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+)
+
+// Default placement of stamped codes: 30 mm wide, in the bottom-right
+// corner of the page, clear of the footer of a DIN 5008 letter.
+const (
+	DefaultSizeMm   = 30.0
+	DefaultRightMm  = 20.0
+	DefaultBottomMm = 35.0
+)
+
+// StampOptions place the code on the page.
+type StampOptions struct {
+	// Page is the page to stamp, starting at 1. Negative values count from
+	// the end, -1 is the last page. Defaults to the first page.
+	Page int
+	// Size is the width and height of the code in millimetres, including
+	// its quiet zone. Defaults to DefaultSizeMm.
+	Size float64
+	// X and Y are the distance of the top-left corner of the code from the
+	// top-left corner of the page in millimetres. If both are zero, the code
+	// is placed in the bottom-right corner.
+	X, Y float64
+}
+
+// StampPdf draws the code onto a page of a PDF document. The document is
+// extended with an incremental update, so its original content is kept
+// byte for byte.
+func StampPdf(pdfBytes []byte, p *Payment, opts *StampOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &StampOptions{}
+	}
+	q, err := p.code()
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+
+	r, err := pdf.Open(pdfBytes)
+	if err != nil {
+		return nil, err
+	}
+	index := opts.Page - 1
+	switch {
+	case opts.Page == 0:
+		index = 0
+	case opts.Page < 0:
+		index = r.NumPages() + opts.Page
+	}
+	if index < 0 || index >= r.NumPages() {
+		return nil, fmt.Errorf("page %d does not exist, the document has %d pages", opts.Page, r.NumPages())
+	}
+
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultSizeMm
+	}
+	return pdf.Stamp(pdfBytes, index, func(page *pdf.Page) {
+		side := pdf.Mm(size)
+		x, top := pdf.Mm(opts.X), page.Height-pdf.Mm(opts.Y)
+		if opts.X == 0 && opts.Y == 0 {
+			x = page.Width - pdf.Mm(DefaultRightMm) - side
+			top = pdf.Mm(DefaultBottomMm) + side
+		}
+		module := side / float64(len(bitmap))
+		page.SetFillGray(1)
+		page.Rect(x, top-side, side, side)
+		page.SetFillGray(0)
+		for row, modules := range bitmap {
+			for _, run := range runs(modules) {
+				page.Rect(x+float64(run[0])*module, top-float64(row+1)*module, float64(run[1])*module, module)
+			}
+		}
+	})
+}
+
+// StampInvoice stamps the code for the payment details of req onto the
+// first PDF of its body, on behalf of the beneficiary name. Call it before
+// content.SendContent, so that recipients of the paper mail fallback can
+// pay with their banking app.
+func StampInvoice(req *content.SendContentRequest, name string, opts *StampOptions) error {
+	if req == nil || req.PaymentInfo == nil || req.PaymentInfo.Details == nil {
+		return errors.New("payment details are required")
+	}
+	p, err := FromPaymentDetails(name, req.PaymentInfo.Details)
+	if err != nil {
+		return err
+	}
+	if req.Body == nil {
+		return errors.New("body is required")
+	}
+	for i, item := range *req.Body {
+		if strVal(item.Type) != content.MimeTypePdf || item.Content == nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(*item.Content)
+		if err != nil {
+			return fmt.Errorf("body item %d: %w", i, err)
+		}
+		stamped, err := StampPdf(data, p, opts)
+		if err != nil {
+			return fmt.Errorf("body item %d: %w", i, err)
+		}
+		(*req.Body)[i] = content.PdfContentItem(stamped)
+		return nil
+	}
+	return errors.New("body contains no PDF")
+}
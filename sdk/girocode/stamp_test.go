@@ -0,0 +1,164 @@
+package girocode_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/girocode"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+)
+
+func invoicePdf(t *testing.T) []byte {
+	doc := pdf.New()
+	doc.AddPage(pdf.A4Width, pdf.A4Height).Text(pdf.Helvetica, 10, 72, 72, "Rechnung")
+	doc.AddPage(pdf.A4Width, pdf.A4Height).Text(pdf.Helvetica, 10, 72, 72, "Seite 2")
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("pdf failed: %v", err)
+	}
+	return data
+}
+
+// xrefStreamPdf builds a PDF 1.5 with a rotated page in an object stream and
+// a compressed cross-reference stream with PNG predictor.
+func xrefStreamPdf() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	offsets := map[int]int{}
+	offsets[4] = buf.Len()
+	content := "0 0 m 100 100 l S"
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 595 842] >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 90 /Contents 4 0 R >>",
+	}
+	var header, body string
+	for i, o := range objs {
+		header += fmt.Sprintf("%d %d ", i+1, len(body))
+		body += o + "\n"
+	}
+	stm := header + body
+	offsets[5] = buf.Len()
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /ObjStm /N 3 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(header), len(stm), stm)
+
+	// rows of type (1 byte), offset (2 bytes) and index or generation (1 byte)
+	rows := [][]byte{{0, 0, 0, 255}, {2, 0, 5, 0}, {2, 0, 5, 1}, {2, 0, 5, 2}, {1, byte(offsets[4] >> 8), byte(offsets[4]), 0}, {1, byte(offsets[5] >> 8), byte(offsets[5]), 0}}
+	xref := buf.Len()
+	rows = append(rows, []byte{1, byte(xref >> 8), byte(xref), 0})
+	var raw []byte
+	prev := make([]byte, 4)
+	for _, row := range rows {
+		raw = append(raw, 2)
+		for i := range row {
+			raw = append(raw, row[i]-prev[i])
+		}
+		prev = row
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(raw)
+	zw.Close()
+	fmt.Fprintf(&buf, "6 0 obj\n<< /Type /XRef /Size 7 /Root 1 0 R /W [1 2 1] /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 4 >> /Length %d >>\nstream\n", z.Len())
+	buf.Write(z.Bytes())
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+func TestStampPdf(t *testing.T) {
+	p, err := girocode.FromPaymentDetails("Muster GmbH", testDetails())
+	if err != nil {
+		t.Errorf("FromPaymentDetails failed: %v", err)
+		return
+	}
+	original := invoicePdf(t)
+	stamped, err := girocode.StampPdf(original, p, &girocode.StampOptions{Page: -1})
+	if err != nil {
+		t.Errorf("StampPdf failed: %v", err)
+		return
+	}
+	if !bytes.HasPrefix(stamped, original) {
+		t.Error("Expected an incremental update")
+	}
+	r, err := pdf.Open(stamped)
+	if err != nil {
+		t.Errorf("Open failed: %v", err)
+		return
+	}
+	first, _ := r.PageContent(0)
+	last, _ := r.PageContent(1)
+	if bytes.Contains(first, []byte(" re f")) || !bytes.Contains(last, []byte(" re f")) || !bytes.Contains(last, []byte("Seite 2")) {
+		t.Errorf("Expected the code on the last page only, got %q", last)
+	}
+
+	if _, err := girocode.StampPdf(original, p, &girocode.StampOptions{Page: 3}); err == nil {
+		t.Error("Expected an error for a missing page")
+	}
+}
+
+func TestStampRotatedPdf(t *testing.T) {
+	p, _ := girocode.FromPaymentDetails("Muster GmbH", testDetails())
+	original := xrefStreamPdf()
+	r, err := pdf.Open(original)
+	if err != nil {
+		t.Errorf("Open failed: %v", err)
+		return
+	}
+	if w, h, rotate, _ := r.PageSize(0); w != 842 || h != 595 || rotate != 90 {
+		t.Errorf("Unexpected page size %v x %v rotated %d", w, h, rotate)
+	}
+
+	stamped, err := girocode.StampPdf(original, p, nil)
+	if err != nil {
+		t.Errorf("StampPdf failed: %v", err)
+		return
+	}
+	r, err = pdf.Open(stamped)
+	if err != nil {
+		t.Errorf("Open failed: %v", err)
+		return
+	}
+	data, err := r.PageContent(0)
+	if err != nil {
+		t.Errorf("PageContent failed: %v", err)
+		return
+	}
+	if !bytes.Contains(data, []byte("0 0 m 100 100 l S")) || !bytes.Contains(data, []byte("0 1 -1 0 595 0 cm")) {
+		t.Errorf("Unexpected content %q", data)
+	}
+	if !strings.Contains(string(stamped[len(original):]), "/Type /XRef") {
+		t.Error("Expected a cross-reference stream in the update")
+	}
+}
+
+func TestStampInvoice(t *testing.T) {
+	original := invoicePdf(t)
+	req := &content.SendContentRequest{
+		Type: sdk.String(content.Invoice),
+		Body: &[]content.ContentItem{
+			{Type: sdk.String("text/plain"), Content: sdk.String("")},
+			content.PdfContentItem(original),
+		},
+		PaymentInfo: &content.PaymentInfo{Details: testDetails()},
+	}
+	if err := girocode.StampInvoice(req, "Muster GmbH", nil); err != nil {
+		t.Errorf("StampInvoice failed: %v", err)
+		return
+	}
+	data, _ := base64.StdEncoding.DecodeString(*(*req.Body)[1].Content)
+	if len(data) <= len(original) || !bytes.HasPrefix(data, original) {
+		t.Error("Expected the PDF to be stamped")
+	}
+
+	req.Body = &[]content.ContentItem{}
+	if err := girocode.StampInvoice(req, "Muster GmbH", nil); err == nil {
+		t.Error("Expected an error without a PDF")
+	}
+}
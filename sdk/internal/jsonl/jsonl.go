@@ -0,0 +1,163 @@
+// Package jsonl persists records as JSON lines in a local file. It is shared
+// by the file based stores of the content package.
+//
+// Records are appended and synced to disk before Append returns. A crash
+// while writing can only leave a truncated last line, which is dropped when
+// the file is opened again. Files of stores that replace records grow with
+// every change; Rewrite compacts them to the current records.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MaxLineSize is the maximum size of a single record.
+const MaxLineSize = 1024 * 1024
+
+// compactMinLines is the number of lines below which a file is never
+// compacted.
+const compactMinLines = 1000
+
+// File is an open JSON lines file. It is not safe for concurrent use.
+type File struct {
+	path  string
+	file  *os.File
+	lines int
+}
+
+// Open opens or creates the file at path and calls read for every line,
+// numbered from 1. A truncated last line is removed from the file.
+func Open(path string, read func(line int, data []byte) error) (*File, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// drop a truncated last line left by a crash while writing, so that new
+	// records start on a line of their own
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = data[:bytes.LastIndexByte(data, '\n')+1]
+		if err := os.Truncate(path, int64(len(data))); err != nil {
+			return nil, err
+		}
+	}
+	lines, err := scan(data, read)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, file: file, lines: lines}, nil
+}
+
+// Read calls read for every complete line of the file at path, e.g. to load
+// a file that is open for appending. A truncated last line is ignored.
+func Read(path string, read func(line int, data []byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = scan(data[:bytes.LastIndexByte(data, '\n')+1], read)
+	return err
+}
+
+func scan(data []byte, read func(line int, data []byte) error) (int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), MaxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if read == nil {
+			continue
+		}
+		if err := read(line, scanner.Bytes()); err != nil {
+			return line, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return line, scanner.Err()
+}
+
+// Append writes record as a line and syncs the file to disk.
+func (f *File) Append(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if len(data) >= MaxLineSize {
+		return fmt.Errorf("record exceeds %d bytes", MaxLineSize)
+	}
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	f.lines++
+	return f.file.Sync()
+}
+
+// Lines returns the number of lines in the file.
+func (f *File) Lines() int {
+	return f.lines
+}
+
+// NeedsCompaction reports whether the file is large and holds mostly
+// replaced records, given the number of current records.
+func (f *File) NeedsCompaction(current int) bool {
+	return f.lines >= compactMinLines && f.lines > 2*current
+}
+
+// Rewrite replaces the content of the file with records. The new content is
+// written to a temporary file first and renamed over the file, so that a
+// crash leaves either the old or the new content.
+func (f *File) Rewrite(records []any) error {
+	var buf bytes.Buffer
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	// the temporary file becomes the file, so that its descriptor stays
+	// valid for appending after the rename
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(buf.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	// make the rename durable; not every platform can sync a directory
+	if dir, err := os.Open(filepath.Dir(f.path)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+
+	f.file.Close()
+	f.file = tmp
+	f.lines = len(records)
+	return nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,112 @@
+package pdf
+
+import "unicode/utf16"
+
+// EmbeddedFile is a file attached to a document, e.g. the invoice XML of a
+// ZUGFeRD PDF.
+type EmbeddedFile struct {
+	Name string
+	// MimeType is the declared subtype, e.g. "text/xml". Optional.
+	MimeType string
+	// Relationship is the AFRelationship of PDF/A-3, e.g. "Alternative".
+	Relationship string
+	Data         []byte
+}
+
+// EmbeddedFiles returns the files of the EmbeddedFiles name tree and the
+// associated files (AF) of the catalog. Files that cannot be decoded are
+// skipped.
+func (r *Reader) EmbeddedFiles() []EmbeddedFile {
+	catalog, _ := r.Resolve(r.trailer["Root"]).(Dict)
+	var specs []any
+	if names, ok := r.Resolve(catalog["Names"]).(Dict); ok {
+		r.nameTree(names["EmbeddedFiles"], &specs, 0)
+	}
+	if af, ok := r.Resolve(catalog["AF"]).(Array); ok {
+		specs = append(specs, af...)
+	}
+
+	var files []EmbeddedFile
+	seen := map[Ref]bool{}
+	for _, spec := range specs {
+		if ref, ok := spec.(Ref); ok {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+		}
+		if f, ok := r.embeddedFile(spec); ok {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// nameTree collects the values of a name tree.
+func (r *Reader) nameTree(node any, values *[]any, depth int) {
+	dict, ok := r.Resolve(node).(Dict)
+	if !ok || depth > 32 {
+		return
+	}
+	if names, ok := r.Resolve(dict["Names"]).(Array); ok {
+		for i := 1; i < len(names); i += 2 {
+			*values = append(*values, names[i])
+		}
+	}
+	if kids, ok := r.Resolve(dict["Kids"]).(Array); ok {
+		for _, kid := range kids {
+			r.nameTree(kid, values, depth+1)
+		}
+	}
+}
+
+func (r *Reader) embeddedFile(spec any) (EmbeddedFile, bool) {
+	dict, ok := r.Resolve(spec).(Dict)
+	if !ok {
+		return EmbeddedFile{}, false
+	}
+	ef, ok := r.Resolve(dict["EF"]).(Dict)
+	if !ok {
+		return EmbeddedFile{}, false
+	}
+	stream, ok := r.Resolve(ef["UF"]).(Stream)
+	if !ok {
+		if stream, ok = r.Resolve(ef["F"]).(Stream); !ok {
+			return EmbeddedFile{}, false
+		}
+	}
+	data, err := r.Decode(stream)
+	if err != nil {
+		return EmbeddedFile{}, false
+	}
+	f := EmbeddedFile{Data: data}
+	if name, ok := r.Resolve(dict["UF"]).(String); ok {
+		f.Name = textValue(name)
+	} else if name, ok := r.Resolve(dict["F"]).(String); ok {
+		f.Name = textValue(name)
+	}
+	if subtype, ok := r.Resolve(stream.Dict["Subtype"]).(Name); ok {
+		f.MimeType = string(subtype)
+	}
+	if rel, ok := r.Resolve(dict["AFRelationship"]).(Name); ok {
+		f.Relationship = string(rel)
+	}
+	return f, true
+}
+
+// textValue decodes a PDF text string, which is either UTF-16BE with a byte
+// order mark or PDFDocEncoding, treated as Latin-1.
+func textValue(s String) string {
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(s))
+	for i, b := range s {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
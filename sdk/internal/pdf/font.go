@@ -0,0 +1,139 @@
+package pdf
+
+// Font is one of the standard 14 PDF fonts supported by the writer. The
+// fonts are not embedded; every PDF viewer and printer provides them.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	Courier
+)
+
+var standardFonts = []Font{Helvetica, HelveticaBold, Courier}
+
+func (f Font) baseFont() string {
+	switch f {
+	case HelveticaBold:
+		return "Helvetica-Bold"
+	case Courier:
+		return "Courier"
+	default:
+		return "Helvetica"
+	}
+}
+
+func (f Font) resourceName() string {
+	switch f {
+	case HelveticaBold:
+		return "F2"
+	case Courier:
+		return "F3"
+	default:
+		return "F1"
+	}
+}
+
+// Width returns the width of s in points when set in the font at size.
+func (f Font) Width(s string, size float64) float64 {
+	var units int
+	for _, c := range Encode(s) {
+		units += f.glyphWidth(c)
+	}
+	return float64(units) * size / 1000
+}
+
+// glyphWidth returns the advance width of a WinAnsi byte in 1/1000 em.
+func (f Font) glyphWidth(c byte) int {
+	if f == Courier {
+		return 600
+	}
+	widths := &helveticaWidths
+	if f == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	switch {
+	case c >= 32 && c <= 126:
+		return widths[c-32]
+	case c >= 0xc0:
+		// accented Latin-1 letters share the width of their base letter
+		if base := latin1Base[c-0xc0]; base != 0 {
+			return widths[base-32]
+		}
+	case c == 0xa0:
+		return widths[0]
+	}
+	return 556
+}
+
+// Advance widths of the printable ASCII range (32-126) from the Adobe font
+// metrics of Helvetica and Helvetica-Bold.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// latin1Base maps the Latin-1 letters 0xC0-0xFF to their unaccented base
+// letter. Zero entries use the default width.
+var latin1Base = [64]byte{
+	'A', 'A', 'A', 'A', 'A', 'A', 0, 'C', 'E', 'E', 'E', 'E', 'I', 'I', 'I', 'I',
+	'D', 'N', 'O', 'O', 'O', 'O', 'O', 0, 'O', 'U', 'U', 'U', 'U', 'Y', 'P', 0,
+	'a', 'a', 'a', 'a', 'a', 'a', 0, 'c', 'e', 'e', 'e', 'e', 'i', 'i', 'i', 'i',
+	'o', 'n', 'o', 'o', 'o', 'o', 'o', 0, 'o', 'u', 'u', 'u', 'u', 'y', 'p', 'y',
+}
+
+// winAnsiExtra maps the characters of the 0x80-0x9F range of WinAnsiEncoding.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// Encode converts s to WinAnsiEncoding, the encoding used for the standard
+// fonts. Characters that cannot be represented are replaced with '?'.
+func Encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiExtra[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// Representable reports whether every character of s can be printed with the
+// standard fonts.
+func Representable(s string) bool {
+	for _, r := range s {
+		if r == '\t' || (r >= 32 && r <= 126) || (r >= 0xa0 && r <= 0xff) {
+			continue
+		}
+		if _, ok := winAnsiExtra[r]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// Image is an image XObject that can be drawn on any page of the document it
+// is first drawn on.
+type Image struct {
+	// Width and Height are the pixel dimensions.
+	Width  int
+	Height int
+
+	colorSpace string
+	bpc        int
+	filter     string
+	params     string
+	decode     string
+	data       []byte
+	smask      *Image
+	name       string
+}
+
+func (img *Image) dict(smaskRef int) string {
+	d := fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent %d",
+		img.Width, img.Height, img.colorSpace, img.bpc)
+	if img.filter != "" {
+		d += " /Filter /" + img.filter
+	}
+	if img.params != "" {
+		d += " /DecodeParms " + img.params
+	}
+	if img.decode != "" {
+		d += " /Decode " + img.decode
+	}
+	if smaskRef != 0 {
+		d += fmt.Sprintf(" /SMask %d 0 R", smaskRef)
+	}
+	return d
+}
+
+// NewJPEGImage embeds a JPEG file as-is, without decoding and re-encoding it.
+func NewJPEGImage(data []byte) (*Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{
+		Width:  cfg.Width,
+		Height: cfg.Height,
+		bpc:    8,
+		filter: "DCTDecode",
+		data:   data,
+	}
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "DeviceGray"
+	case color.CMYKModel:
+		img.colorSpace = "DeviceCMYK"
+		// Adobe applications write CMYK JPEGs with inverted components
+		if bytes.Contains(data[:min(len(data), 1024)], []byte("Adobe")) {
+			img.decode = "[1 0 1 0 1 0 1 0]"
+		}
+	default:
+		img.colorSpace = "DeviceRGB"
+	}
+	return img, nil
+}
+
+// NewImage embeds a decoded image losslessly. Grayscale images are stored
+// with a single channel and transparency is kept as a soft mask.
+func NewImage(src image.Image) (*Image, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, errors.New("pdf: image is empty")
+	}
+
+	gray := isGray(src)
+	channels := 3
+	colorSpace := "DeviceRGB"
+	if gray {
+		channels = 1
+		colorSpace = "DeviceGray"
+	}
+
+	pixels := make([]byte, 0, w*h*channels)
+	alpha := make([]byte, 0, w*h)
+	opaque, bilevel := true, gray
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			if gray {
+				pixels = append(pixels, c.R)
+				bilevel = bilevel && (c.R == 0 || c.R == 0xff)
+			} else {
+				pixels = append(pixels, c.R, c.G, c.B)
+			}
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	bpc := 8
+	if bilevel {
+		// scanned black and white pages shrink considerably at one bit per pixel
+		pixels = packBits(pixels, w, h)
+		bpc = 1
+	}
+	data, err := deflate(pixels)
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{Width: w, Height: h, colorSpace: colorSpace, bpc: bpc, filter: "FlateDecode", data: data}
+	if !opaque {
+		maskData, err := deflate(alpha)
+		if err != nil {
+			return nil, err
+		}
+		img.smask = &Image{Width: w, Height: h, colorSpace: "DeviceGray", bpc: 8, filter: "FlateDecode", data: maskData}
+	}
+	return img, nil
+}
+
+func isGray(src image.Image) bool {
+	switch src.(type) {
+	case *image.Gray, *image.Gray16:
+		return true
+	case *image.Paletted:
+		for _, c := range src.(*image.Paletted).Palette {
+			r, g, b, _ := c.RGBA()
+			if r != g || g != b {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// packBits packs 8-bit black and white samples into rows of 1-bit samples.
+func packBits(samples []byte, w, h int) []byte {
+	stride := (w + 7) / 8
+	out := make([]byte, stride*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if samples[y*w+x] != 0 {
+				out[y*stride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return out
+}
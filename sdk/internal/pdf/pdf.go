@@ -0,0 +1,317 @@
+// Package pdf is a small PDF writer used by the SDK to produce documents
+// that can be sent through the Brifle API (converted images and text, letters
+// and payment QR codes). It only supports what the SDK needs: pages, the
+// standard Helvetica and Courier fonts, lines, rectangles and images, and
+// stamping vector graphics onto existing documents.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes in PDF points (1/72 inch).
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Mm converts millimetres to PDF points.
+func Mm(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+// Document is a PDF document under construction.
+type Document struct {
+	// Title is stored in the document information dictionary. Optional.
+	Title string
+	// Creator is stored in the document information dictionary. Optional.
+	Creator string
+	// CreationDate is stored in the document information dictionary. It is
+	// omitted when zero, so that the same input renders the same bytes.
+	CreationDate time.Time
+
+	pages  []*Page
+	images []*Image
+}
+
+// New creates an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends a page of the given size in points and returns it.
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{Width: width, Height: height, doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// PageCount returns the number of pages added so far.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo renders the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		return 0, fmt.Errorf("pdf: document has no pages")
+	}
+
+	ow := &objectWriter{}
+	ow.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// object numbers: 1 catalog, 2 page tree, 3 info, then fonts, images
+	// and pages with their content streams
+	const catalogRef, pagesRef, infoRef = 1, 2, 3
+	next := 4
+	fontRefs := make(map[Font]int, len(standardFonts))
+	for _, f := range standardFonts {
+		fontRefs[f] = next
+		next++
+	}
+	imageRefs := make(map[*Image]int, len(d.images))
+	for _, img := range d.images {
+		imageRefs[img] = next
+		next++
+		if img.smask != nil {
+			imageRefs[img.smask] = next
+			next++
+		}
+	}
+	pageRefs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageRefs[i] = next
+		next += 2 // page and its content stream
+	}
+
+	ow.object(catalogRef, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesRef))
+
+	kids := make([]string, len(pageRefs))
+	for i, ref := range pageRefs {
+		kids[i] = fmt.Sprintf("%d 0 R", ref)
+	}
+	ow.object(pagesRef, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageRefs)))
+
+	info := "<< /Producer (Brifle Go SDK)"
+	if d.Title != "" {
+		info += " /Title " + textString(d.Title)
+	}
+	if d.Creator != "" {
+		info += " /Creator " + textString(d.Creator)
+	}
+	if !d.CreationDate.IsZero() {
+		info += " /CreationDate (D:" + d.CreationDate.UTC().Format("20060102150405") + "Z)"
+	}
+	info += " >>"
+	ow.object(infoRef, info)
+
+	for _, f := range standardFonts {
+		ow.object(fontRefs[f], fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont()))
+	}
+
+	for _, img := range d.images {
+		if img.smask != nil {
+			ow.stream(imageRefs[img.smask], img.smask.dict(0), img.smask.data)
+		}
+		ow.stream(imageRefs[img], img.dict(imageRefs[img.smask]), img.data)
+	}
+
+	for i, p := range d.pages {
+		var res strings.Builder
+		res.WriteString("<< /Font <<")
+		for _, f := range standardFonts {
+			fmt.Fprintf(&res, " /%s %d 0 R", f.resourceName(), fontRefs[f])
+		}
+		res.WriteString(" >>")
+		if len(p.images) > 0 {
+			res.WriteString(" /XObject <<")
+			for _, img := range p.images {
+				fmt.Fprintf(&res, " /%s %d 0 R", img.name, imageRefs[img])
+			}
+			res.WriteString(" >>")
+		}
+		res.WriteString(" >>")
+
+		ow.object(pageRefs[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesRef, num(p.Width), num(p.Height), res.String(), pageRefs[i]+1))
+
+		compressed, err := deflate(p.content.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		ow.stream(pageRefs[i]+1, "<< /Filter /FlateDecode", compressed)
+	}
+
+	ow.finish(next, catalogRef, infoRef)
+	return ow.buf.WriteTo(w)
+}
+
+// objectWriter serialises indirect objects and remembers their offsets for
+// the cross-reference table.
+type objectWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (ow *objectWriter) object(ref int, body string) {
+	if ow.offsets == nil {
+		ow.offsets = make(map[int]int)
+	}
+	ow.offsets[ref] = ow.buf.Len()
+	fmt.Fprintf(&ow.buf, "%d 0 obj\n%s\nendobj\n", ref, body)
+}
+
+// stream writes a stream object. dict is an unterminated dictionary to which
+// the length is appended.
+func (ow *objectWriter) stream(ref int, dict string, data []byte) {
+	if ow.offsets == nil {
+		ow.offsets = make(map[int]int)
+	}
+	ow.offsets[ref] = ow.buf.Len()
+	fmt.Fprintf(&ow.buf, "%d 0 obj\n%s /Length %d >>\nstream\n", ref, dict, len(data))
+	ow.buf.Write(data)
+	ow.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (ow *objectWriter) finish(size, root, info int) {
+	xref := ow.buf.Len()
+	fmt.Fprintf(&ow.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for ref := 1; ref < size; ref++ {
+		fmt.Fprintf(&ow.buf, "%010d 00000 n \n", ow.offsets[ref])
+	}
+	fmt.Fprintf(&ow.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, root, info, xref)
+}
+
+// Page is a single page of a document. Coordinates are in points with the
+// origin in the bottom-left corner.
+type Page struct {
+	Width  float64
+	Height float64
+
+	doc     *Document
+	content bytes.Buffer
+	images  []*Image
+	fonts   bool
+}
+
+// Text draws s with its baseline starting at (x, y).
+func (p *Page) Text(font Font, size, x, y float64, s string) {
+	p.fonts = true
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td %s Tj ET\n", font.resourceName(), num(size), num(x), num(y), literal(Encode(s)))
+}
+
+// TextRight draws s so that it ends at x.
+func (p *Page) TextRight(font Font, size, x, y float64, s string) {
+	p.Text(font, size, x-font.Width(s, size), y, s)
+}
+
+// SetFillGray sets the fill colour used for text and filled rectangles, from
+// 0 (black) to 1 (white).
+func (p *Page) SetFillGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s g\n", num(gray))
+}
+
+// SetFillRGB sets the fill colour, each component ranging from 0 to 1.
+func (p *Page) SetFillRGB(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", num(r), num(g), num(b))
+}
+
+// Line strokes a line from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect fills a rectangle with the current fill colour.
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// DrawImage paints img into the rectangle (x, y, w, h).
+func (p *Page) DrawImage(img *Image, x, y, w, h float64) {
+	p.DrawImageMatrix(img, Matrix{w, 0, 0, h, x, y})
+}
+
+// DrawImageMatrix paints img by mapping the unit square through m. It allows
+// images to be rotated or mirrored while placing them.
+func (p *Page) DrawImageMatrix(img *Image, m Matrix) {
+	p.register(img)
+	fmt.Fprintf(&p.content, "q %s %s %s %s %s %s cm /%s Do Q\n", num(m[0]), num(m[1]), num(m[2]), num(m[3]), num(m[4]), num(m[5]), img.name)
+}
+
+func (p *Page) register(img *Image) {
+	if img.name == "" {
+		p.doc.images = append(p.doc.images, img)
+		img.name = "Im" + strconv.Itoa(len(p.doc.images))
+	}
+	for _, existing := range p.images {
+		if existing == img {
+			return
+		}
+	}
+	p.images = append(p.images, img)
+}
+
+// Matrix is a PDF transformation matrix [a b c d e f].
+type Matrix [6]float64
+
+// num formats a number compactly for content streams.
+func num(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// literal escapes raw bytes as a PDF literal string.
+func literal(b []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, c := range b {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&sb, "\\%03o", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// textString encodes s for the document information dictionary.
+func textString(s string) string {
+	return literal(Encode(s))
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,978 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Object types of parsed documents. Integers are int64, reals float64,
+// booleans bool and null is nil.
+type (
+	// Name is a PDF name without the leading slash.
+	Name string
+	// String is a literal or hexadecimal string.
+	String []byte
+	// Array is a PDF array.
+	Array []any
+	// Dict is a PDF dictionary.
+	Dict map[Name]any
+	// Ref is an indirect reference.
+	Ref struct{ Num, Gen int }
+	// Stream is a stream object with its still encoded data.
+	Stream struct {
+		Dict Dict
+		Data []byte
+	}
+)
+
+// ErrEncrypted is returned for encrypted documents, which cannot be read.
+var ErrEncrypted = errors.New("pdf: document is encrypted")
+
+// MaxDecodedStreamSize is the maximum size of a decoded stream, e.g. of an
+// embedded file or an object stream. Documents come from third parties, and
+// a few kilobytes of FlateDecode data can inflate to gigabytes.
+const MaxDecodedStreamSize = 64 << 20
+
+// ErrStreamTooLarge is returned by Decode for streams larger than
+// MaxDecodedStreamSize.
+var ErrStreamTooLarge = fmt.Errorf("pdf: decoded stream exceeds %d bytes", MaxDecodedStreamSize)
+
+// xrefEntry locates an object: in the file at offset (type 1) or as the
+// index-th object of the object stream stream (type 2).
+type xrefEntry struct {
+	typ    int
+	offset int
+	gen    int
+	stream int
+	index  int
+}
+
+// Reader gives access to the objects and pages of an existing document. It
+// supports cross-reference tables and streams, object streams and the
+// FlateDecode filter, which covers the documents produced by common PDF
+// libraries.
+type Reader struct {
+	data    []byte
+	xref    map[int]xrefEntry
+	trailer Dict
+	// startxref is the offset of the last cross-reference section.
+	startxref int
+	// xrefStream reports whether the last section is a cross-reference
+	// stream.
+	xrefStream bool
+	objects    map[int]any
+	pages      []page
+	// depth is the number of objects being parsed, e.g. a stream whose
+	// length is an object of an object stream.
+	depth int
+}
+
+// Limits of crafted documents: the nesting of arrays and dictionaries, of
+// objects parsed while parsing an object, and of the page tree.
+const (
+	maxNesting     = 256
+	maxObjectDepth = 32
+	maxPageDepth   = 64
+)
+
+// page is a leaf of the page tree with its inherited attributes.
+type page struct {
+	ref       Ref
+	dict      Dict
+	mediaBox  Array
+	cropBox   Array
+	rotate    int
+	resources any
+}
+
+// Open parses the cross-reference sections and the page tree of data.
+func Open(data []byte) (*Reader, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, errors.New("pdf: not a PDF document")
+	}
+	r := &Reader{data: data, xref: make(map[int]xrefEntry), objects: make(map[int]any)}
+	start := bytes.LastIndex(data, []byte("startxref"))
+	if start < 0 {
+		return nil, errors.New("pdf: startxref not found")
+	}
+	lx := &lexer{data: data, pos: start + len("startxref")}
+	offset, ok := lx.object().(int64)
+	if !ok {
+		return nil, errors.New("pdf: invalid startxref")
+	}
+	r.startxref = int(offset)
+	if err := r.readXref(int(offset), map[int]bool{}, true); err != nil {
+		return nil, err
+	}
+	// objects resolved while reading older sections may have been missing
+	r.objects = make(map[int]any)
+	if _, ok := r.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+	catalog, ok := r.Resolve(r.trailer["Root"]).(Dict)
+	if !ok {
+		return nil, errors.New("pdf: document catalog not found")
+	}
+	if err := r.readPages(catalog["Pages"], page{}, map[int]bool{}, 0); err != nil {
+		return nil, err
+	}
+	if len(r.pages) == 0 {
+		return nil, errors.New("pdf: document has no pages")
+	}
+	return r, nil
+}
+
+// NumPages returns the number of pages.
+func (r *Reader) NumPages() int {
+	return len(r.pages)
+}
+
+// PageSize returns the visible size of the page with the given index in
+// points, taking the crop box and the rotation into account, and the
+// rotation in degrees.
+func (r *Reader) PageSize(index int) (width, height float64, rotate int, err error) {
+	if index < 0 || index >= len(r.pages) {
+		return 0, 0, 0, fmt.Errorf("pdf: page %d out of range", index+1)
+	}
+	box, err := r.pageBox(r.pages[index])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	width, height = box[2]-box[0], box[3]-box[1]
+	p := r.pages[index]
+	if p.rotate == 90 || p.rotate == 270 {
+		width, height = height, width
+	}
+	return width, height, p.rotate, nil
+}
+
+// PageContent returns the decoded content streams of a page, concatenated.
+func (r *Reader) PageContent(index int) ([]byte, error) {
+	if index < 0 || index >= len(r.pages) {
+		return nil, fmt.Errorf("pdf: page %d out of range", index+1)
+	}
+	var buf bytes.Buffer
+	for _, c := range r.contents(r.pages[index]) {
+		s, ok := r.Resolve(c).(Stream)
+		if !ok {
+			return nil, errors.New("pdf: page content is not a stream")
+		}
+		data, err := r.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// Trailer returns the trailer dictionary of the last cross-reference section.
+func (r *Reader) Trailer() Dict {
+	return r.trailer
+}
+
+// Resolve follows indirect references. Missing objects resolve to nil.
+func (r *Reader) Resolve(obj any) any {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		obj = r.object(ref.Num)
+	}
+	return nil
+}
+
+// Decode returns the decoded data of a stream. Streams that decode to more
+// than MaxDecodedStreamSize bytes fail with ErrStreamTooLarge.
+func (r *Reader) Decode(s Stream) ([]byte, error) {
+	filters := r.Resolve(s.Dict["Filter"])
+	params := r.Resolve(s.Dict["DecodeParms"])
+	if a, ok := filters.(Array); ok {
+		if len(a) > 1 {
+			return nil, errors.New("pdf: multiple stream filters are not supported")
+		}
+		if len(a) == 1 {
+			filters = r.Resolve(a[0])
+		} else {
+			filters = nil
+		}
+		if p, ok := params.(Array); ok && len(p) > 0 {
+			params = r.Resolve(p[0])
+		}
+	}
+	switch filters {
+	case nil:
+		return s.Data, nil
+	case Name("FlateDecode"):
+		zr, err := zlib.NewReader(bytes.NewReader(s.Data))
+		if err != nil {
+			return nil, fmt.Errorf("pdf: %w", err)
+		}
+		data, err := io.ReadAll(io.LimitReader(zr, MaxDecodedStreamSize+1))
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("pdf: %w", err)
+		}
+		if len(data) > MaxDecodedStreamSize {
+			return nil, ErrStreamTooLarge
+		}
+		if p, ok := params.(Dict); ok {
+			return unpredict(data, p)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("pdf: stream filter %v is not supported", filters)
+	}
+}
+
+func (r *Reader) object(num int) any {
+	if obj, ok := r.objects[num]; ok {
+		return obj
+	}
+	if r.depth >= maxObjectDepth {
+		return nil
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+	// guard against reference cycles while parsing
+	r.objects[num] = nil
+	var obj any
+	entry, ok := r.xref[num]
+	switch {
+	case !ok:
+	case entry.typ == 1:
+		obj, _ = r.parseIndirect(entry.offset)
+	case entry.typ == 2:
+		obj = r.streamObject(entry.stream, entry.index)
+	}
+	r.objects[num] = obj
+	return obj
+}
+
+// parseIndirect parses "num gen obj ... endobj" at offset.
+func (r *Reader) parseIndirect(offset int) (any, error) {
+	if offset < 0 || offset >= len(r.data) {
+		return nil, fmt.Errorf("pdf: object offset %d out of range", offset)
+	}
+	lx := &lexer{data: r.data, pos: offset}
+	_, ok1 := lx.object().(int64)
+	_, ok2 := lx.object().(int64)
+	if !ok1 || !ok2 || lx.keyword() != "obj" {
+		return nil, fmt.Errorf("pdf: no object at offset %d", offset)
+	}
+	obj := lx.object()
+	dict, ok := obj.(Dict)
+	if !ok {
+		return obj, nil
+	}
+	save := lx.pos
+	if lx.keyword() != "stream" {
+		lx.pos = save
+		return obj, nil
+	}
+	// the keyword is followed by CRLF or LF
+	if lx.pos < len(r.data) && r.data[lx.pos] == '\r' {
+		lx.pos++
+	}
+	if lx.pos < len(r.data) && r.data[lx.pos] == '\n' {
+		lx.pos++
+	}
+	start := lx.pos
+	if n, ok := r.Resolve(dict["Length"]).(int64); ok && n >= 0 && n <= int64(len(r.data)-start) {
+		end := start + int(n)
+		if bytes.HasPrefix(bytes.TrimLeft(r.data[end:], "\r\n \t"), []byte("endstream")) {
+			return Stream{Dict: dict, Data: r.data[start:end]}, nil
+		}
+	}
+	// wrong or missing length, fall back to searching the end
+	end := bytes.Index(r.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, fmt.Errorf("pdf: unterminated stream at offset %d", offset)
+	}
+	data := bytes.TrimSuffix(r.data[start:start+end], []byte("\n"))
+	return Stream{Dict: dict, Data: bytes.TrimSuffix(data, []byte("\r"))}, nil
+}
+
+// streamObject returns the index-th object of an object stream.
+func (r *Reader) streamObject(streamNum, index int) any {
+	s, ok := r.object(streamNum).(Stream)
+	if !ok {
+		return nil
+	}
+	data, err := r.Decode(s)
+	if err != nil {
+		return nil
+	}
+	n, _ := s.Dict["N"].(int64)
+	first, _ := s.Dict["First"].(int64)
+	if index < 0 || int64(index) >= n || first < 0 || first >= int64(len(data)) {
+		return nil
+	}
+	lx := &lexer{data: data}
+	var offset int64
+	for i := 0; i <= index; i++ {
+		start := lx.pos
+		if start >= int(first) {
+			return nil
+		}
+		lx.object()
+		offset, _ = lx.object().(int64)
+		if lx.pos == start {
+			return nil
+		}
+	}
+	if offset < 0 || offset >= int64(len(data))-first {
+		return nil
+	}
+	lx = &lexer{data: data, pos: int(first + offset)}
+	return lx.object()
+}
+
+// readXref reads the cross-reference section at offset and the sections it
+// refers to. Entries of later sections take precedence.
+func (r *Reader) readXref(offset int, seen map[int]bool, last bool) error {
+	if seen[offset] {
+		return nil
+	}
+	seen[offset] = true
+	if offset < 0 || offset >= len(r.data) {
+		return fmt.Errorf("pdf: cross-reference offset %d out of range", offset)
+	}
+	lx := &lexer{data: r.data, pos: offset}
+	var trailer Dict
+	if lx.keyword() == "xref" {
+		var err error
+		if trailer, err = r.readXrefTable(lx); err != nil {
+			return err
+		}
+		if stm, ok := trailer["XRefStm"].(int64); ok {
+			if err := r.readXref(int(stm), seen, false); err != nil {
+				return err
+			}
+		}
+	} else {
+		obj, err := r.parseIndirect(offset)
+		if err != nil {
+			return err
+		}
+		s, ok := obj.(Stream)
+		if !ok || s.Dict["Type"] != Name("XRef") {
+			return fmt.Errorf("pdf: no cross-reference section at offset %d", offset)
+		}
+		if err := r.readXrefStream(s); err != nil {
+			return err
+		}
+		trailer = s.Dict
+		if last {
+			r.xrefStream = true
+		}
+	}
+	if last {
+		r.trailer = trailer
+	}
+	if prev, ok := trailer["Prev"].(int64); ok {
+		return r.readXref(int(prev), seen, false)
+	}
+	return nil
+}
+
+func (r *Reader) readXrefTable(lx *lexer) (Dict, error) {
+	for {
+		save := lx.pos
+		if lx.keyword() == "trailer" {
+			trailer, ok := lx.object().(Dict)
+			if !ok {
+				return nil, errors.New("pdf: invalid trailer")
+			}
+			return trailer, nil
+		}
+		lx.pos = save
+		start, ok1 := lx.object().(int64)
+		count, ok2 := lx.object().(int64)
+		if !ok1 || !ok2 {
+			return nil, errors.New("pdf: invalid cross-reference table")
+		}
+		for i := 0; i < int(count); i++ {
+			offset, ok1 := lx.object().(int64)
+			gen, ok2 := lx.object().(int64)
+			kind := lx.keyword()
+			if !ok1 || !ok2 || (kind != "n" && kind != "f") {
+				return nil, errors.New("pdf: invalid cross-reference entry")
+			}
+			num := int(start) + i
+			if _, exists := r.xref[num]; exists || kind == "f" {
+				continue
+			}
+			r.xref[num] = xrefEntry{typ: 1, offset: int(offset), gen: int(gen)}
+		}
+	}
+}
+
+func (r *Reader) readXrefStream(s Stream) error {
+	data, err := r.Decode(s)
+	if err != nil {
+		return err
+	}
+	w, ok := s.Dict["W"].(Array)
+	if !ok || len(w) != 3 {
+		return errors.New("pdf: invalid cross-reference stream")
+	}
+	// fields are big-endian integers of up to 8 bytes
+	var widths [3]int
+	for i, v := range w {
+		n, _ := v.(int64)
+		if n < 0 || n > 8 {
+			return fmt.Errorf("pdf: invalid cross-reference stream field width %d", n)
+		}
+		widths[i] = int(n)
+	}
+	index, _ := s.Dict["Index"].(Array)
+	if index == nil {
+		size, _ := s.Dict["Size"].(int64)
+		index = Array{int64(0), size}
+	}
+	field := func(b []byte, def int) int {
+		if len(b) == 0 {
+			return def
+		}
+		v := 0
+		for _, c := range b {
+			v = v<<8 | int(c)
+		}
+		return v
+	}
+	rowLen := widths[0] + widths[1] + widths[2]
+	if rowLen == 0 {
+		return errors.New("pdf: invalid cross-reference stream")
+	}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, _ := index[i].(int64)
+		count, _ := index[i+1].(int64)
+		for j := 0; j < int(count); j++ {
+			if pos+rowLen > len(data) {
+				return errors.New("pdf: truncated cross-reference stream")
+			}
+			row := data[pos : pos+rowLen]
+			pos += rowLen
+			typ := field(row[:widths[0]], 1)
+			f2 := field(row[widths[0]:widths[0]+widths[1]], 0)
+			f3 := field(row[widths[0]+widths[1]:], 0)
+			num := int(start) + j
+			if _, exists := r.xref[num]; exists {
+				continue
+			}
+			switch typ {
+			case 0:
+				// free objects shadow older entries as well
+				r.xref[num] = xrefEntry{}
+			case 1:
+				r.xref[num] = xrefEntry{typ: 1, offset: f2, gen: f3}
+			case 2:
+				r.xref[num] = xrefEntry{typ: 2, stream: f2, index: f3}
+			}
+		}
+	}
+	return nil
+}
+
+// readPages walks the page tree and collects its leaves.
+func (r *Reader) readPages(node any, inherited page, seen map[int]bool, depth int) error {
+	if depth > maxPageDepth {
+		return errors.New("pdf: page tree is too deep")
+	}
+	ref, _ := node.(Ref)
+	if ref.Num != 0 {
+		if seen[ref.Num] {
+			return errors.New("pdf: page tree contains a cycle")
+		}
+		seen[ref.Num] = true
+	}
+	dict, ok := r.Resolve(node).(Dict)
+	if !ok {
+		return errors.New("pdf: invalid page tree")
+	}
+	if v, ok := r.Resolve(dict["MediaBox"]).(Array); ok {
+		inherited.mediaBox = v
+	}
+	if v, ok := r.Resolve(dict["CropBox"]).(Array); ok {
+		inherited.cropBox = v
+	}
+	if v, ok := r.Resolve(dict["Rotate"]).(int64); ok {
+		inherited.rotate = int((v%360 + 360) % 360)
+	}
+	if v, ok := dict["Resources"]; ok {
+		inherited.resources = v
+	}
+	if dict["Type"] == Name("Page") || dict["Kids"] == nil {
+		if ref.Num == 0 {
+			return errors.New("pdf: page is not an indirect object")
+		}
+		inherited.ref = ref
+		inherited.dict = dict
+		r.pages = append(r.pages, inherited)
+		return nil
+	}
+	kids, ok := r.Resolve(dict["Kids"]).(Array)
+	if !ok {
+		return errors.New("pdf: invalid page tree")
+	}
+	for _, kid := range kids {
+		if err := r.readPages(kid, inherited, seen, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageBox returns the crop box of a page, or its media box.
+func (r *Reader) pageBox(p page) ([4]float64, error) {
+	var box [4]float64
+	src := p.cropBox
+	if src == nil {
+		src = p.mediaBox
+	}
+	if len(src) != 4 {
+		return box, errors.New("pdf: page has no media box")
+	}
+	for i, v := range src {
+		switch n := r.Resolve(v).(type) {
+		case int64:
+			box[i] = float64(n)
+		case float64:
+			box[i] = n
+		default:
+			return box, errors.New("pdf: invalid media box")
+		}
+	}
+	if box[0] > box[2] {
+		box[0], box[2] = box[2], box[0]
+	}
+	if box[1] > box[3] {
+		box[1], box[3] = box[3], box[1]
+	}
+	return box, nil
+}
+
+// contents returns the content streams of a page as references or streams.
+func (r *Reader) contents(p page) Array {
+	switch c := p.dict["Contents"].(type) {
+	case nil:
+		return nil
+	case Array:
+		return c
+	case Ref:
+		if a, ok := r.Resolve(c).(Array); ok {
+			return a
+		}
+		return Array{c}
+	default:
+		return Array{c}
+	}
+}
+
+// Limits of the predictor parameters; larger values only occur in crafted
+// documents.
+const (
+	maxPredictorColumns = 1 << 20
+	maxPredictorColors  = 32
+)
+
+// unpredict reverses the PNG predictors of a FlateDecode stream.
+func unpredict(data []byte, params Dict) ([]byte, error) {
+	predictor, _ := params["Predictor"].(int64)
+	if predictor < 10 {
+		if predictor > 1 {
+			return nil, fmt.Errorf("pdf: predictor %d is not supported", predictor)
+		}
+		return data, nil
+	}
+	columns, colors, bpc := int64(1), int64(1), int64(8)
+	if v, ok := params["Columns"].(int64); ok {
+		columns = v
+	}
+	if v, ok := params["Colors"].(int64); ok {
+		colors = v
+	}
+	if v, ok := params["BitsPerComponent"].(int64); ok {
+		bpc = v
+	}
+	switch {
+	case columns < 1 || columns > maxPredictorColumns:
+		return nil, fmt.Errorf("pdf: invalid predictor columns %d", columns)
+	case colors < 1 || colors > maxPredictorColors:
+		return nil, fmt.Errorf("pdf: invalid predictor colors %d", colors)
+	case bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 && bpc != 16:
+		return nil, fmt.Errorf("pdf: invalid predictor bits per component %d", bpc)
+	}
+	bpp := int((colors*bpc + 7) / 8)
+	rowLen := int((columns*colors*bpc + 7) / 8)
+	if len(data)%(rowLen+1) != 0 {
+		return nil, fmt.Errorf("pdf: predicted data of %d bytes is not a multiple of the row length %d", len(data), rowLen+1)
+	}
+	out := make([]byte, 0, len(data)/(rowLen+1)*rowLen)
+	prev := make([]byte, rowLen)
+	for pos := 0; pos < len(data); pos += rowLen + 1 {
+		filter := data[pos]
+		row := append([]byte(nil), data[pos+1:pos+1+rowLen]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("pdf: invalid PNG filter %d", filter)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// lexer parses PDF objects from data. Positions outside of data are
+// treated as the end of data.
+type lexer struct {
+	data []byte
+	pos  int
+	// depth is the nesting of the array or dictionary being parsed.
+	depth int
+}
+
+func isWhite(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelim(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (lx *lexer) skip() {
+	if lx.pos < 0 || lx.pos > len(lx.data) {
+		lx.pos = len(lx.data)
+	}
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		if c == '%' {
+			for lx.pos < len(lx.data) && lx.data[lx.pos] != '\n' && lx.data[lx.pos] != '\r' {
+				lx.pos++
+			}
+			continue
+		}
+		if !isWhite(c) {
+			return
+		}
+		lx.pos++
+	}
+}
+
+// keyword reads a regular token, e.g. "obj" or "xref".
+func (lx *lexer) keyword() string {
+	lx.skip()
+	start := lx.pos
+	for lx.pos < len(lx.data) && !isWhite(lx.data[lx.pos]) && !isDelim(lx.data[lx.pos]) {
+		lx.pos++
+	}
+	return string(lx.data[start:lx.pos])
+}
+
+// object parses the next object. Syntax errors yield nil, as do arrays and
+// dictionaries nested deeper than maxNesting.
+func (lx *lexer) object() any {
+	lx.skip()
+	if lx.pos >= len(lx.data) {
+		return nil
+	}
+	if c := lx.data[lx.pos]; c == '<' || c == '[' {
+		if lx.depth >= maxNesting {
+			lx.pos = len(lx.data)
+			return nil
+		}
+		lx.depth++
+		defer func() { lx.depth-- }()
+	}
+	switch c := lx.data[lx.pos]; {
+	case c == '/':
+		lx.pos++
+		return lx.name()
+	case c == '(':
+		lx.pos++
+		return lx.literal()
+	case c == '<' && lx.pos+1 < len(lx.data) && lx.data[lx.pos+1] == '<':
+		lx.pos += 2
+		dict := Dict{}
+		for {
+			lx.skip()
+			if lx.pos+1 >= len(lx.data) {
+				return dict
+			}
+			if lx.data[lx.pos] == '>' && lx.data[lx.pos+1] == '>' {
+				lx.pos += 2
+				return dict
+			}
+			key, ok := lx.object().(Name)
+			if !ok {
+				return dict
+			}
+			dict[key] = lx.object()
+		}
+	case c == '<':
+		lx.pos++
+		return lx.hex()
+	case c == '[':
+		lx.pos++
+		arr := Array{}
+		for {
+			lx.skip()
+			if lx.pos >= len(lx.data) {
+				return arr
+			}
+			if lx.data[lx.pos] == ']' {
+				lx.pos++
+				return arr
+			}
+			start := lx.pos
+			arr = append(arr, lx.object())
+			if lx.pos == start {
+				// unexpected delimiter
+				lx.pos++
+			}
+		}
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return lx.number()
+	}
+	switch kw := lx.keyword(); kw {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return nil
+	}
+}
+
+func (lx *lexer) name() Name {
+	var b []byte
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		if isWhite(c) || isDelim(c) {
+			break
+		}
+		if c == '#' && lx.pos+2 < len(lx.data) {
+			if v, err := strconv.ParseUint(string(lx.data[lx.pos+1:lx.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				lx.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		lx.pos++
+	}
+	return Name(b)
+}
+
+func (lx *lexer) literal() String {
+	var b []byte
+	depth := 1
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		lx.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return b
+			}
+		case '\\':
+			if lx.pos >= len(lx.data) {
+				return b
+			}
+			e := lx.data[lx.pos]
+			lx.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if lx.pos < len(lx.data) && lx.data[lx.pos] == '\n' {
+					lx.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && lx.pos < len(lx.data) && lx.data[lx.pos] >= '0' && lx.data[lx.pos] <= '7'; i++ {
+						v = v*8 + int(lx.data[lx.pos]-'0')
+						lx.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (lx *lexer) hex() String {
+	var digits []byte
+	for lx.pos < len(lx.data) && lx.data[lx.pos] != '>' {
+		if c := lx.data[lx.pos]; !isWhite(c) {
+			digits = append(digits, c)
+		}
+		lx.pos++
+	}
+	if lx.pos < len(lx.data) {
+		lx.pos++
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b := make([]byte, len(digits)/2)
+	for i := range b {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		b[i] = byte(v)
+	}
+	return b
+}
+
+// number parses an integer, a real or an indirect reference "num gen R".
+func (lx *lexer) number() any {
+	start := lx.pos
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		if !(c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9')) {
+			break
+		}
+		lx.pos++
+	}
+	token := string(lx.data[start:lx.pos])
+	n, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	// look ahead for a reference
+	save := lx.pos
+	lx.skip()
+	genStart := lx.pos
+	for lx.pos < len(lx.data) && lx.data[lx.pos] >= '0' && lx.data[lx.pos] <= '9' {
+		lx.pos++
+	}
+	if lx.pos > genStart {
+		gen, _ := strconv.Atoi(string(lx.data[genStart:lx.pos]))
+		lx.skip()
+		if lx.pos < len(lx.data) && lx.data[lx.pos] == 'R' && (lx.pos+1 == len(lx.data) || isWhite(lx.data[lx.pos+1]) || isDelim(lx.data[lx.pos+1])) {
+			lx.pos++
+			return Ref{Num: int(n), Gen: gen}
+		}
+	}
+	lx.pos = save
+	return n
+}
+
+// writeObject serialises obj in PDF syntax.
+func writeObject(buf *bytes.Buffer, obj any) {
+	switch v := obj.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case int:
+		buf.WriteString(strconv.Itoa(v))
+	case float64:
+		buf.WriteString(num(v))
+	case Name:
+		buf.WriteByte('/')
+		for _, c := range []byte(v) {
+			if c < '!' || c > '~' || c == '#' || isDelim(c) {
+				fmt.Fprintf(buf, "#%02X", c)
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	case String:
+		fmt.Fprintf(buf, "<%X>", []byte(v))
+	case Ref:
+		fmt.Fprintf(buf, "%d %d R", v.Num, v.Gen)
+	case Array:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writeObject(buf, e)
+		}
+		buf.WriteByte(']')
+	case Dict:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+		buf.WriteString("<<")
+		for _, k := range keys {
+			buf.WriteByte(' ')
+			writeObject(buf, Name(k))
+			buf.WriteByte(' ')
+			writeObject(buf, v[Name(k)])
+		}
+		buf.WriteString(" >>")
+	default:
+		panic(fmt.Sprintf("pdf: cannot serialise %T", obj))
+	}
+}
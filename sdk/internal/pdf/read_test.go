@@ -0,0 +1,117 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+)
+
+// xrefStreamDocument builds a document whose objects 1 to len(objects) are
+// located by a cross-reference stream with the given dictionary entries.
+// Object 1 is the catalog unless rows says otherwise; rows overrides the
+// entries of the objects, keyed by object number.
+func xrefStreamDocument(objects []string, dict string, rows map[int][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects)+1)
+	for i, obj := range objects {
+		offsets[i+1] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	num := len(objects) + 1
+	offset := buf.Len()
+	var data []byte
+	for i := 0; i <= num; i++ {
+		switch {
+		case rows[i] != nil:
+			data = append(data, rows[i]...)
+		case i == 0:
+			data = append(data, 0, 0, 0, 0)
+		case i == num:
+			data = append(data, 1, byte(offset>>8), byte(offset), 0)
+		default:
+			data = append(data, 1, byte(offsets[i]>>8), byte(offsets[i]), 0)
+		}
+	}
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /XRef /Size %d /Root 1 0 R %s /Length %d >>\nstream\n", num, num+1, dict, len(data))
+	buf.Write(data)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", offset)
+	return buf.Bytes()
+}
+
+func testDocument(t testing.TB) []byte {
+	doc := pdf.New()
+	page := doc.AddPage(pdf.A4Width, pdf.A4Height)
+	page.Text(pdf.Helvetica, 12, 72, 720, "Rechnung")
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOpenMalformed(t *testing.T) {
+	page := "<< /Type /Page /Parent 3 0 R /MediaBox [0 0 595 842] >>"
+	pages := "<< /Type /Pages /Kids [2 0 R] /Count 1 >>"
+	objStm := func(first int) string {
+		header := "1 0 "
+		body := "<< /Type /Catalog /Pages 3 0 R >>"
+		return fmt.Sprintf("<< /Type /ObjStm /N 1 /First %d /Length %d >>\nstream\n%s%s\nendstream", first, len(header)+len(body), header, body)
+	}
+	tests := map[string][]byte{
+		"negative field width":  xrefStreamDocument([]string{"<< /Type /Catalog /Pages 3 0 R >>", page, pages}, "/W [1 -1 3]", nil),
+		"oversized field width": xrefStreamDocument([]string{"<< /Type /Catalog /Pages 3 0 R >>", page, pages}, "/W [1 9 1]", nil),
+		"zero field widths":     xrefStreamDocument([]string{"<< /Type /Catalog /Pages 3 0 R >>", page, pages}, "/W [0 0 0]", nil),
+		// the catalog is object 0 of the object stream 4
+		"negative first":     xrefStreamDocument([]string{"null", page, pages, objStm(-100)}, "/W [1 2 1]", map[int][]byte{1: {2, 0, 4, 0}}),
+		"first beyond data":  xrefStreamDocument([]string{"null", page, pages, objStm(1 << 40)}, "/W [1 2 1]", map[int][]byte{1: {2, 0, 4, 0}}),
+		"negative length":    []byte("%PDF-1.4\n1 0 obj\n<< /Length -5 >>\nstream\nendstream\nendobj\nstartxref\n9\n%%EOF"),
+		"deeply nested":      append([]byte("%PDF-1.4\n1 0 obj\n"), append(bytes.Repeat([]byte("["), 1<<20), "\nendobj\nstartxref\n9\n%%EOF"...)...),
+		"unterminated hex":   []byte("%PDF-1.4\nxref\n0 1\n<00\nstartxref\n9\n%%EOF"),
+		"startxref past end": []byte("%PDF-1.4\nstartxref\n99999\n%%EOF"),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := pdf.Open(data); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestOpenObjectStream(t *testing.T) {
+	page := "<< /Type /Page /Parent 3 0 R /MediaBox [0 0 595 842] >>"
+	pages := "<< /Type /Pages /Kids [2 0 R] /Count 1 >>"
+	header := "1 0 "
+	body := "<< /Type /Catalog /Pages 3 0 R >>"
+	objStm := fmt.Sprintf("<< /Type /ObjStm /N 1 /First %d /Length %d >>\nstream\n%s%s\nendstream", len(header), len(header)+len(body), header, body)
+	data := xrefStreamDocument([]string{"null", page, pages, objStm}, "/W [1 2 1]", map[int][]byte{1: {2, 0, 4, 0}})
+	r, err := pdf.Open(data)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if w, h, _, err := r.PageSize(0); err != nil || w != 595 || h != 842 {
+		t.Errorf("Unexpected page size %vx%v: %v", w, h, err)
+	}
+}
+
+func FuzzOpen(f *testing.F) {
+	f.Add(testDocument(f))
+	page := "<< /Type /Page /Parent 3 0 R /MediaBox [0 0 595 842] >>"
+	pages := "<< /Type /Pages /Kids [2 0 R] /Count 1 >>"
+	f.Add(xrefStreamDocument([]string{"<< /Type /Catalog /Pages 3 0 R >>", page, pages}, "/W [1 2 1]", nil))
+	f.Add(xrefStreamDocument([]string{"null", page, pages, "<< /Type /ObjStm /N 1 /First 4 /Length 37 >>\nstream\n1 0 << /Type /Catalog /Pages 3 0 R >>\nendstream"}, "/W [1 2 1]", map[int][]byte{1: {2, 0, 4, 0}}))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := pdf.Open(data)
+		if err != nil {
+			return
+		}
+		for i := 0; i < r.NumPages(); i++ {
+			_, _, _, _ = r.PageSize(i)
+			_, _ = r.PageContent(i)
+		}
+		_ = r.EmbeddedFiles()
+	})
+}
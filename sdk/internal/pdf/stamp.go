@@ -0,0 +1,197 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Stamp draws on the page with the given index of an existing document and
+// returns the document with an incremental update appended, so the original
+// bytes stay untouched. The page passed to draw has the visible size of the
+// page with the origin in its visible bottom-left corner, regardless of the
+// page rotation. Stamps are limited to vector graphics: drawing text or
+// images fails, because the page resources are not changed.
+func Stamp(data []byte, index int, draw func(p *Page)) ([]byte, error) {
+	r, err := Open(data)
+	if err != nil {
+		return nil, err
+	}
+	width, height, rotate, err := r.PageSize(index)
+	if err != nil {
+		return nil, err
+	}
+	pg := r.pages[index]
+	box, err := r.pageBox(pg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Page{Width: width, Height: height, doc: New()}
+	draw(p)
+	if len(p.images) > 0 || p.fonts {
+		return nil, errors.New("pdf: stamps support vector graphics only")
+	}
+
+	// map the visible page onto the user space of the rotated page
+	var m Matrix
+	switch rotate {
+	case 90:
+		m = Matrix{0, 1, -1, 0, box[2], box[1]}
+	case 180:
+		m = Matrix{-1, 0, 0, -1, box[2], box[3]}
+	case 270:
+		m = Matrix{0, -1, 1, 0, box[0], box[3]}
+	default:
+		m = Matrix{1, 0, 0, 1, box[0], box[1]}
+	}
+	var stamp bytes.Buffer
+	// restore the graphics state left by the page content first
+	fmt.Fprintf(&stamp, "Q\nq\n%s %s %s %s %s %s cm\n", num(m[0]), num(m[1]), num(m[2]), num(m[3]), num(m[4]), num(m[5]))
+	stamp.Write(p.content.Bytes())
+	stamp.WriteString("Q\n")
+
+	size, ok := r.trailer["Size"].(int64)
+	if !ok {
+		return nil, errors.New("pdf: trailer has no size")
+	}
+	saveRef, stampRef := Ref{Num: int(size)}, Ref{Num: int(size) + 1}
+	contents := Array{saveRef}
+	for _, c := range r.contents(pg) {
+		if _, ok := c.(Ref); !ok {
+			return nil, errors.New("pdf: page content is not an indirect stream")
+		}
+		contents = append(contents, c)
+	}
+	contents = append(contents, stampRef)
+	pageDict := make(Dict, len(pg.dict)+1)
+	for k, v := range pg.dict {
+		pageDict[k] = v
+	}
+	pageDict["Contents"] = contents
+
+	u := &update{buf: bytes.NewBuffer(append([]byte(nil), data...)), gens: map[int]int{}}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		u.buf.WriteByte('\n')
+	}
+	if err := u.stream(saveRef.Num, []byte("q\n")); err != nil {
+		return nil, err
+	}
+	if err := u.stream(stampRef.Num, stamp.Bytes()); err != nil {
+		return nil, err
+	}
+	u.object(pg.ref, pageDict)
+
+	trailer := Dict{"Root": r.trailer["Root"], "Prev": int64(r.startxref)}
+	for _, key := range []Name{"Info", "ID"} {
+		if v, ok := r.trailer[key]; ok {
+			trailer[key] = v
+		}
+	}
+	if r.xrefStream {
+		u.finishStream(int(size)+2, trailer)
+	} else {
+		u.finishTable(int(size)+2, trailer)
+	}
+	return u.buf.Bytes(), nil
+}
+
+// update collects the objects of an incremental update.
+type update struct {
+	buf     *bytes.Buffer
+	offsets map[int]int
+	gens    map[int]int
+}
+
+func (u *update) object(ref Ref, obj any) {
+	if u.offsets == nil {
+		u.offsets = make(map[int]int)
+	}
+	u.offsets[ref.Num] = u.buf.Len()
+	u.gens[ref.Num] = ref.Gen
+	fmt.Fprintf(u.buf, "%d %d obj\n", ref.Num, ref.Gen)
+	writeObject(u.buf, obj)
+	u.buf.WriteString("\nendobj\n")
+}
+
+func (u *update) stream(ref int, data []byte) error {
+	compressed, err := deflate(data)
+	if err != nil {
+		return err
+	}
+	if u.offsets == nil {
+		u.offsets = make(map[int]int)
+	}
+	u.offsets[ref] = u.buf.Len()
+	fmt.Fprintf(u.buf, "%d 0 obj\n<< /Filter /FlateDecode /Length %d >>\nstream\n", ref, len(compressed))
+	u.buf.Write(compressed)
+	u.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// sections groups the updated object numbers into runs of consecutive
+// numbers, as [start, count] pairs.
+func (u *update) sections() ([]int, [][2]int) {
+	nums := make([]int, 0, len(u.offsets))
+	for n := range u.offsets {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	var runs [][2]int
+	for _, n := range nums {
+		if len(runs) > 0 && runs[len(runs)-1][0]+runs[len(runs)-1][1] == n {
+			runs[len(runs)-1][1]++
+		} else {
+			runs = append(runs, [2]int{n, 1})
+		}
+	}
+	return nums, runs
+}
+
+// finishTable appends a cross-reference table and the trailer.
+func (u *update) finishTable(size int, trailer Dict) {
+	xref := u.buf.Len()
+	nums, runs := u.sections()
+	u.buf.WriteString("xref\n")
+	i := 0
+	for _, run := range runs {
+		fmt.Fprintf(u.buf, "%d %d\n", run[0], run[1])
+		for j := 0; j < run[1]; j++ {
+			n := nums[i]
+			i++
+			fmt.Fprintf(u.buf, "%010d %05d n \n", u.offsets[n], u.gens[n])
+		}
+	}
+	trailer["Size"] = int64(size)
+	u.buf.WriteString("trailer\n")
+	writeObject(u.buf, trailer)
+	fmt.Fprintf(u.buf, "\nstartxref\n%d\n%%%%EOF\n", xref)
+}
+
+// finishStream appends a cross-reference stream with the object number ref,
+// for documents whose previous section is a stream as well.
+func (u *update) finishStream(ref int, trailer Dict) {
+	xref := u.buf.Len()
+	u.offsets[ref] = xref
+	nums, runs := u.sections()
+	var data []byte
+	for _, n := range nums {
+		off, gen := u.offsets[n], u.gens[n]
+		data = append(data, 1, byte(off>>24), byte(off>>16), byte(off>>8), byte(off), byte(gen>>8), byte(gen))
+	}
+	index := Array{}
+	for _, run := range runs {
+		index = append(index, int64(run[0]), int64(run[1]))
+	}
+	trailer["Type"] = Name("XRef")
+	trailer["Size"] = int64(ref + 1)
+	trailer["W"] = Array{int64(1), int64(4), int64(2)}
+	trailer["Index"] = index
+	trailer["Length"] = int64(len(data))
+	fmt.Fprintf(u.buf, "%d 0 obj\n", ref)
+	writeObject(u.buf, trailer)
+	u.buf.WriteString("\nstream\n")
+	u.buf.Write(data)
+	fmt.Fprintf(u.buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xref)
+}
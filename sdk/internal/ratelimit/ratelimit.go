@@ -0,0 +1,24 @@
+// Package ratelimit paces the requests of the bulk operations to a number of
+// requests per second. It is shared by the content and address packages.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Start returns a channel that delivers a tick ratePerSecond times per
+// second, and a function that stops it. A rate that is not positive means
+// unlimited: the channel is nil. Rates above one per nanosecond tick every
+// nanosecond, rates below one per 292 years never tick.
+func Start(ratePerSecond float64) (<-chan time.Time, func()) {
+	if !(ratePerSecond > 0) {
+		return nil, func() {}
+	}
+	interval := time.Duration(math.MaxInt64)
+	if d := float64(time.Second) / ratePerSecond; d < math.MaxInt64 {
+		interval = max(time.Duration(d), time.Nanosecond)
+	}
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
@@ -0,0 +1,258 @@
+// Package xmlsig verifies enveloped XML signatures (XML-DSig) offline and
+// extracts the signed content. It is shared by the certificates and
+// signatures packages.
+package xmlsig
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	dsigNamespace  = "http://www.w3.org/2000/09/xmldsig#"
+	xadesNamespace = "http://uri.etsi.org/01903/v1.3.2#"
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// Signature describes the XML signature of a document. It is read without
+// verifying anything.
+type Signature struct {
+	SignatureMethod string
+	DigestMethod    string
+	Value           []byte
+	// Certificates are the certificates embedded in KeyInfo; the first one
+	// is the signing certificate.
+	Certificates []*x509.Certificate
+	// SigningTime is the XAdES signing time, if present. It is only
+	// covered by the signature if the signature references the XAdES
+	// signed properties, which Verify does not check.
+	SigningTime time.Time
+}
+
+// Field is a leaf element of a document.
+type Field struct {
+	// Path is the slash separated path of local names below the root.
+	Path  string
+	Name  string
+	Value string
+}
+
+// Options configure Verify.
+type Options struct {
+	// Roots are the trust anchors. Required.
+	Roots *x509.CertPool
+	// Intermediates are used in addition to the certificates in KeyInfo to
+	// build the chain.
+	Intermediates []*x509.Certificate
+	// Time is the time at which the certificates must be valid. Defaults to
+	// the current time.
+	Time time.Time
+}
+
+// Parse reads an XML document and its signature.
+func Parse(data []byte) (*etree.Element, *Signature, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, nil, fmt.Errorf("parsing xml: %w", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, nil, errors.New("xml document is empty")
+	}
+	sigEl := find(root, dsigNamespace, "Signature")
+	if sigEl == nil {
+		return root, nil, nil
+	}
+	sig := &Signature{
+		SignatureMethod: attr(find(sigEl, dsigNamespace, "SignatureMethod"), "Algorithm"),
+		DigestMethod:    attr(find(sigEl, dsigNamespace, "DigestMethod"), "Algorithm"),
+	}
+	if v := find(sigEl, dsigNamespace, "SignatureValue"); v != nil {
+		value, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(v.Text(), ""))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding signature value: %w", err)
+		}
+		sig.Value = value
+	}
+	for _, el := range findAll(sigEl, dsigNamespace, "X509Certificate") {
+		der, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(el.Text(), ""))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding certificate: %w", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing certificate: %w", err)
+		}
+		sig.Certificates = append(sig.Certificates, cert)
+	}
+	if el := find(sigEl, xadesNamespace, "SigningTime"); el != nil {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(el.Text())); err == nil {
+			sig.SigningTime = t
+		}
+	}
+	return root, sig, nil
+}
+
+// Verify checks the enveloped signature of the root element of data and the
+// chain of its signing certificate. It returns the signed content, i.e. the
+// root element without the signature, in canonical form; only data from the
+// returned element is covered by the signature.
+func Verify(data []byte, sig *Signature, opts Options) (*etree.Element, []*x509.Certificate, error) {
+	if opts.Roots == nil {
+		return nil, nil, errors.New("trust anchors are required")
+	}
+	if sig == nil || len(sig.Certificates) == 0 {
+		return nil, nil, errors.New("document has no signing certificate")
+	}
+	at := opts.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range sig.Certificates[1:] {
+		intermediates.AddCert(c)
+	}
+	for _, c := range opts.Intermediates {
+		intermediates.AddCert(c)
+	}
+	leaf := sig.Certificates[0]
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("certificate chain: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, chains[0], fmt.Errorf("parsing xml: %w", err)
+	}
+	// the chain is verified, so the signature only has to match the leaf
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{leaf}})
+	ctx.Clock = dsig.NewFakeClockAt(at)
+	// the root may be referenced by an id attribute of any name
+	if ref := find(doc.Root(), dsigNamespace, "Reference"); ref != nil {
+		if uri := attr(ref, "URI"); strings.HasPrefix(uri, "#") {
+			for _, a := range doc.Root().Attr {
+				if a.Value == uri[1:] {
+					ctx.IdAttribute = a.Key
+				}
+			}
+		}
+	}
+	signed, err := ctx.Validate(doc.Root())
+	if err != nil {
+		return nil, chains[0], fmt.Errorf("xml signature: %w", err)
+	}
+	return signed, chains[0], nil
+}
+
+// Fields returns the leaf elements of el, excluding signatures, in document
+// order.
+func Fields(el *etree.Element) []Field {
+	var fields []Field
+	var walk func(el *etree.Element, path string)
+	walk = func(el *etree.Element, path string) {
+		// attributes are reported as fields of their element
+		for _, a := range el.Attr {
+			if a.Space == "xmlns" || a.Key == "xmlns" {
+				continue
+			}
+			fields = append(fields, Field{Path: path + "@" + a.Key, Name: a.Key, Value: a.Value})
+		}
+		children := el.ChildElements()
+		if len(children) == 0 {
+			fields = append(fields, Field{Path: path, Name: el.Tag, Value: strings.TrimSpace(el.Text())})
+			return
+		}
+		for _, c := range children {
+			if c.Tag == "Signature" && c.NamespaceURI() == dsigNamespace {
+				continue
+			}
+			p := c.Tag
+			if path != "" {
+				p = path + "/" + c.Tag
+			}
+			walk(c, p)
+		}
+	}
+	if el != nil {
+		walk(el, "")
+	}
+	return fields
+}
+
+// Lookup returns the value of the first field whose name or path matches one
+// of names. Names are compared case insensitively, ignoring underscores,
+// dashes and path separators, so "document_id" matches DocumentId and
+// "signer_name" matches Signer/Name.
+func Lookup(fields []Field, names ...string) string {
+	for _, name := range names {
+		n := normalize(name)
+		for _, f := range fields {
+			if (normalize(f.Name) == n || normalize(f.Path) == n) && f.Value != "" {
+				return f.Value
+			}
+		}
+	}
+	return ""
+}
+
+// ParseTime parses an ISO 8601 date. An empty string is the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an ISO 8601 date", s)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", "/", "", "@", "").Replace(s))
+}
+
+func find(el *etree.Element, space, tag string) *etree.Element {
+	if all := findAll(el, space, tag); len(all) > 0 {
+		return all[0]
+	}
+	return nil
+}
+
+func findAll(el *etree.Element, space, tag string) []*etree.Element {
+	var found []*etree.Element
+	var walk func(*etree.Element)
+	walk = func(e *etree.Element) {
+		if e.Tag == tag && e.NamespaceURI() == space {
+			found = append(found, e)
+		}
+		for _, c := range e.ChildElements() {
+			walk(c)
+		}
+	}
+	walk(el)
+	return found
+}
+
+func attr(el *etree.Element, key string) string {
+	if el == nil {
+		return ""
+	}
+	return el.SelectAttrValue(key, "")
+}
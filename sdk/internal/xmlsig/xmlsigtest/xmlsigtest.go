@@ -0,0 +1,103 @@
+// Package xmlsigtest creates signed XML documents for tests.
+package xmlsigtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// Authority is a throwaway root CA with an intermediate CA that issues a
+// signing certificate.
+type Authority struct {
+	Root         *x509.Certificate
+	Intermediate *x509.Certificate
+	Leaf         *x509.Certificate
+	leafKey      *rsa.PrivateKey
+}
+
+// NewAuthority creates a certificate hierarchy valid from an hour ago for a
+// year.
+func NewAuthority(t testing.TB, leafName string) *Authority {
+	t.Helper()
+	rootKey := newKey(t)
+	root := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Root CA"}, IsCA: true}, nil, rootKey, rootKey)
+	interKey := newKey(t)
+	inter := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Issuing CA"}, IsCA: true}, root, rootKey, interKey)
+	leafKey := newKey(t)
+	leaf := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: leafName, Organization: []string{"Brifle Test"}}}, inter, interKey, leafKey)
+	return &Authority{Root: root, Intermediate: inter, Leaf: leaf, leafKey: leafKey}
+}
+
+// Roots returns a pool with the root CA.
+func (a *Authority) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.Root)
+	return pool
+}
+
+// Sign adds an enveloped signature to the root element of xml. The signature
+// embeds the signing and the intermediate certificate.
+func (a *Authority) Sign(t testing.TB, xml string) []byte {
+	t.Helper()
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(xml); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := dsig.NewSigningContext(a.leafKey, [][]byte{a.Leaf.Raw, a.Intermediate.Raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := ctx.SignEnveloped(doc.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.SetRoot(signed)
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newKey(t testing.TB) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+var serial atomic.Int64
+
+func issue(t testing.TB, template, parent *x509.Certificate, parentKey, key *rsa.PrivateKey) *x509.Certificate {
+	template.SerialNumber = big.NewInt(serial.Add(1))
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().AddDate(1, 0, 0)
+	template.BasicConstraintsValid = true
+	if template.IsCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
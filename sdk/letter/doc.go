@@ -0,0 +1,36 @@
+// Package letter renders DIN 5008 business letters (form A or B) as PDFs
+// that can be sent with content.SendContent and printed through the paper
+// mail fallback alike, because the recipient sits in the standard address
+// window.
+//
+// Subject, salutation, body and closing are Go templates executed with the
+// data passed to [Letter.Render]:
+//
+//	l := letter.Letter{
+//		Form: letter.FormB,
+//		Sender: letter.Sender{
+//			Name:    "Muster GmbH",
+//			Address: []string{"Musterstraße 1", "10115 Berlin"},
+//			Bank:    &letter.BankDetails{BankName: "Musterbank", Iban: "DE89370400440532013000"},
+//		},
+//		To: letter.Recipient{
+//			Name: []string{"Max Mustermann"},
+//			Address: &content.Recipient{
+//				AddressLine1: sdk.String("Hauptstraße 5"),
+//				PostalCode:   sdk.String("12345"),
+//				City:         sdk.String("Berlin"),
+//				Country:      sdk.String("DE"),
+//			},
+//		},
+//		Subject:    "Ihre Rechnung {{.Number}}",
+//		Salutation: "Sehr geehrter Herr {{.LastName}},",
+//		Body:       "anbei erhalten Sie Ihre Rechnung.\n\nBitte überweisen Sie den Betrag bis {{.Due}}.",
+//		Closing:    "Mit freundlichen Grüßen",
+//	}
+//	pdfBytes, err := l.Render(data)
+//
+// Use [Letter.PaperMailFallback] to enable physical delivery to the same
+// recipient.
+//
+// See docs/letters.md for more examples.
+package letter
@@ -0,0 +1,66 @@
+package letter_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/letter"
+	"github.com/brifle-de/brifle-sdk/sdk/middleware"
+)
+
+// Render a letter and send it electronically with paper mail as fallback.
+func ExampleLetter_Render() {
+	client, _ := sdk.NewClient("https://sandbox-api.brifle.de", middleware.Credentials{
+		ApiKey:    "your-api-key",
+		ApiSecret: "your-api-secret",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tenant := "567e44de-b6b6-4dac-cbce-c5515031f9ea"
+	l := letter.Letter{
+		Sender: letter.Sender{
+			Name:    "Muster GmbH",
+			Address: []string{"Musterstraße 1", "10115 Berlin"},
+		},
+		To: letter.Recipient{
+			Name: []string{"Max Mustermann"},
+			Address: &content.Recipient{
+				AddressLine1: sdk.String("Hauptstraße 5"),
+				PostalCode:   sdk.String("12345"),
+				City:         sdk.String("Berlin"),
+				Country:      sdk.String("DE"),
+			},
+		},
+		Subject:    "Willkommen, {{.Name}}",
+		Salutation: "Guten Tag {{.Name}},",
+		Body:       "willkommen bei Brifle.",
+		Closing:    "Viele Grüße",
+	}
+
+	pdfBytes, err := l.Render(map[string]string{"Name": "Max Mustermann"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	req := content.SendContentRequest{
+		To: &content.ReceiverData{
+			Email: &content.EmailReceiver{Email: sdk.String("max@example.com"), Name: sdk.String("Max Mustermann")},
+		},
+		Type:     sdk.String(content.Letter),
+		Subject:  sdk.String("Willkommen"),
+		Body:     &[]content.ContentItem{content.PdfContentItem(pdfBytes)},
+		Fallback: l.PaperMailFallback(),
+	}
+	res, respStatus, err := content.SendContent(client, ctx, &tenant, &req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if respStatus.HttpStatus == 200 {
+		fmt.Println("document id:", *res.Id)
+	}
+}
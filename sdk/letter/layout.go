@@ -0,0 +1,87 @@
+package letter
+
+import (
+	"strings"
+
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+)
+
+// textLine is a single line of flowing text. An empty text is a blank line.
+type textLine struct {
+	text string
+	font pdf.Font
+}
+
+// paragraphs splits text at blank lines.
+func paragraphs(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var out []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.Trim(p, "\n"); strings.TrimSpace(p) != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// wrap breaks text into lines no wider than width. Existing line breaks are
+// kept and words longer than a line are split.
+func wrap(text string, font pdf.Font, width float64) []textLine {
+	var lines []textLine
+	for _, raw := range strings.Split(text, "\n") {
+		words := strings.Fields(raw)
+		if len(words) == 0 {
+			lines = append(lines, textLine{})
+			continue
+		}
+		current := ""
+		for _, word := range words {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if font.Width(candidate, bodyFontSize) <= width {
+				current = candidate
+				continue
+			}
+			if current != "" {
+				lines = append(lines, textLine{text: current, font: font})
+			}
+			for font.Width(word, bodyFontSize) > width {
+				cut := len([]rune(word)) - 1
+				for cut > 1 && font.Width(string([]rune(word)[:cut]), bodyFontSize) > width {
+					cut--
+				}
+				lines = append(lines, textLine{text: string([]rune(word)[:cut]), font: font})
+				word = string([]rune(word)[cut:])
+			}
+			current = word
+		}
+		lines = append(lines, textLine{text: current, font: font})
+	}
+	return lines
+}
+
+// paginate distributes lines over pages. The first page starts at firstTop
+// millimetres, following pages at nextPageTopMm.
+func paginate(lines []textLine, firstTop float64) [][]textLine {
+	for len(lines) > 0 && lines[len(lines)-1].text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	linesPerPage := func(top float64) int {
+		return int((bodyBottomMm-top)/lineHeightMm) + 1
+	}
+	capacity := linesPerPage(firstTop)
+	pages := [][]textLine{}
+	for {
+		if len(lines) <= capacity {
+			return append(pages, lines)
+		}
+		pages = append(pages, lines[:capacity])
+		lines = lines[capacity:]
+		for len(lines) > 0 && lines[0].text == "" {
+			lines = lines[1:]
+		}
+		capacity = linesPerPage(nextPageTopMm)
+	}
+}
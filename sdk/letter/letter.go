@@ -0,0 +1,461 @@
+package letter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/internal/pdf"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+// DIN 5008 letter forms. Form B leaves a taller letterhead (45 mm) than
+// form A (27 mm); both place the address window so that it fits a DL
+// window envelope.
+const (
+	FormA = "A"
+	FormB = "B"
+)
+
+// Letter is a DIN 5008 business letter. Subject, Salutation, Body and
+// Closing are Go templates (text/template) that are executed with the data
+// passed to Render.
+type Letter struct {
+	// Form is FormA or FormB. Defaults to FormB.
+	Form string
+	// Sender is printed in the letterhead, the return address line and the
+	// footer.
+	Sender Sender
+	// To is the recipient printed in the address window.
+	To Recipient
+	// Remarks are printed below the return address in the address field,
+	// e.g. "Einschreiben". At most 3 lines.
+	Remarks []string
+	// Info lines are printed in the information block next to the address
+	// field, e.g. {"Kundennummer", "4711"}.
+	Info []InfoLine
+	// Date of the letter. Defaults to today. When set, it is also stored as
+	// the creation date of the PDF.
+	Date time.Time
+	// DateFormat is the Go time layout of the date. Defaults to "02.01.2006".
+	DateFormat string
+	// DateLabel is the label of the date in the information block. Defaults
+	// to "Datum".
+	DateLabel string
+	// Subject template, printed in bold.
+	Subject string
+	// Salutation template, e.g. "Sehr geehrte Frau {{.LastName}},".
+	Salutation string
+	// Body template. Paragraphs are separated by blank lines; single line
+	// breaks are kept.
+	Body string
+	// Closing template, e.g. "Mit freundlichen Grüßen".
+	Closing string
+	// Signature is printed below the closing, leaving room for a handwritten
+	// signature.
+	Signature string
+	// Logo is an optional JPEG or PNG printed in the top right corner.
+	Logo []byte
+}
+
+// Sender describes the author of the letter.
+type Sender struct {
+	// Name of the company or person.
+	Name string
+	// Address lines, e.g. street and "12345 Berlin".
+	Address []string
+	// ReturnAddress is the single line printed above the address window. It is
+	// built from Name and Address when empty.
+	ReturnAddress string
+	// Contact lines for the footer, e.g. phone, email and web site.
+	Contact []string
+	// Bank details for the footer. Optional.
+	Bank *BankDetails
+	// Legal lines for the footer, e.g. register court and managing directors.
+	Legal []string
+}
+
+// BankDetails are printed in the footer. Render rejects an invalid IBAN or
+// BIC, so that no invoice goes out with bank details the recipient cannot
+// pay to.
+type BankDetails struct {
+	AccountHolder string
+	BankName      string
+	Iban          string
+	Bic           string
+}
+
+// Validate checks the IBAN and BIC, if set.
+func (b *BankDetails) Validate() error {
+	if b.Iban != "" {
+		if err := payments.ValidateIban(b.Iban); err != nil {
+			return err
+		}
+	}
+	if b.Bic != "" {
+		if err := payments.ValidateBic(b.Bic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recipient is the addressee printed in the address window.
+type Recipient struct {
+	// Name lines printed above the address, e.g. company and contact person.
+	Name []string
+	// Address is the postal address of the recipient.
+	Address *content.Recipient
+}
+
+// RecipientFromPreview builds a Recipient from the receiver of a paper mail
+// preview.
+func RecipientFromPreview(name []string, receiver *content.PreviewReceiver) Recipient {
+	if receiver == nil {
+		return Recipient{Name: name}
+	}
+	return Recipient{
+		Name: name,
+		Address: &content.Recipient{
+			AddressLine1: receiver.AddressLine1,
+			AddressLine2: receiver.AddressLine2,
+			City:         receiver.City,
+			Country:      receiver.Country,
+			PostalCode:   receiver.PostalCode,
+		},
+	}
+}
+
+// InfoLine is a label and value of the information block.
+type InfoLine struct {
+	Label string
+	Value string
+}
+
+// PaperMailFallback returns a fallback that enables physical delivery to the
+// recipient printed in the address window, so the rendered letter can be sent
+// electronically and on paper alike.
+func (l *Letter) PaperMailFallback() *content.Fallback {
+	return &content.Fallback{
+		EnabledPhysicalDelivery: true,
+		PaperMail:               &content.PaperMail{Recipient: l.To.Address},
+	}
+}
+
+// geometry of the letter in millimetres from the top left corner
+const (
+	pageWidthMm   = 210.0
+	pageHeightMm  = 297.0
+	leftMarginMm  = 25.0
+	rightMarginMm = 20.0
+
+	addressLeftMm   = 20.0
+	addressWidthMm  = 85.0
+	remarkZoneMm    = 17.7
+	infoLeftMm      = 125.0
+
+	footerTopMm    = 272.0
+	bodyBottomMm   = 262.0
+	nextPageTopMm  = 20.0
+	lineHeightMm   = 4.5
+	bodyFontSize   = 11.0
+	addressFontPt  = 10.0
+	smallFontSize  = 7.0
+	footerFontSize = 7.5
+)
+
+// form specific positions in millimetres from the top
+type formLayout struct {
+	addressTop float64
+	infoTop    float64
+	subjectTop float64
+	foldMarks  []float64
+}
+
+var layouts = map[string]formLayout{
+	FormA: {addressTop: 27, infoTop: 32, subjectTop: 98.46, foldMarks: []float64{87, 192}},
+	FormB: {addressTop: 45, infoTop: 50, subjectTop: 103.4, foldMarks: []float64{105, 210}},
+}
+
+// Render executes the templates with data and lays out the letter as an A4
+// PDF. Body text that does not fit on the first page continues on further
+// pages, which are numbered.
+func (l *Letter) Render(data any) ([]byte, error) {
+	form := l.Form
+	if form == "" {
+		form = FormB
+	}
+	layout, ok := layouts[strings.ToUpper(form)]
+	if !ok {
+		return nil, fmt.Errorf("unknown letter form %q", l.Form)
+	}
+
+	address, err := l.addressLines()
+	if err != nil {
+		return nil, err
+	}
+	if len(l.Remarks) > 3 {
+		return nil, errors.New("at most 3 remark lines fit above the address")
+	}
+	if b := l.Sender.Bank; b != nil {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("bank details: %w", err)
+		}
+	}
+
+	subject, err := execute("subject", l.Subject, data)
+	if err != nil {
+		return nil, err
+	}
+	salutation, err := execute("salutation", l.Salutation, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute("body", l.Body, data)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := execute("closing", l.Closing, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var logo *pdf.Image
+	if len(l.Logo) > 0 {
+		src, _, err := image.Decode(bytes.NewReader(l.Logo))
+		if err != nil {
+			return nil, fmt.Errorf("logo: %w", err)
+		}
+		if logo, err = pdf.NewImage(src); err != nil {
+			return nil, fmt.Errorf("logo: %w", err)
+		}
+	}
+
+	// lay out the flowing text first to know the number of pages
+	textWidth := pdf.Mm(pageWidthMm - leftMarginMm - rightMarginMm)
+	var lines []textLine
+	if salutation != "" {
+		lines = append(lines, wrap(salutation, pdf.Helvetica, textWidth)...)
+		lines = append(lines, textLine{})
+	}
+	for _, paragraph := range paragraphs(body) {
+		lines = append(lines, wrap(paragraph, pdf.Helvetica, textWidth)...)
+		lines = append(lines, textLine{})
+	}
+	if closing != "" {
+		lines = append(lines, wrap(closing, pdf.Helvetica, textWidth)...)
+	}
+	if l.Signature != "" {
+		lines = append(lines, textLine{}, textLine{}, textLine{})
+		lines = append(lines, wrap(l.Signature, pdf.Helvetica, textWidth)...)
+	}
+
+	firstTop := layout.subjectTop
+	if subject != "" {
+		firstTop += 2 * lineHeightMm
+	}
+	pages := paginate(lines, firstTop)
+
+	doc := pdf.New()
+	doc.Title = subject
+	doc.Creator = l.Sender.Name
+	doc.CreationDate = l.Date
+	for i, pageLines := range pages {
+		page := doc.AddPage(pdf.A4Width, pdf.A4Height)
+		top := nextPageTopMm
+		if i == 0 {
+			l.drawFirstPage(page, layout, address, subject, logo)
+			top = firstTop
+		}
+		for j, line := range pageLines {
+			if line.text != "" {
+				page.Text(line.font, bodyFontSize, pdf.Mm(leftMarginMm), y(top+float64(j)*lineHeightMm), line.text)
+			}
+		}
+		if len(pages) > 1 {
+			page.TextRight(pdf.Helvetica, smallFontSize+1, pdf.Mm(pageWidthMm-rightMarginMm), y(bodyBottomMm+lineHeightMm),
+				fmt.Sprintf("Seite %d von %d", i+1, len(pages)))
+		}
+		l.drawFooter(page)
+		drawFoldMarks(page, layout)
+	}
+	return doc.Bytes()
+}
+
+func (l *Letter) drawFirstPage(page *pdf.Page, layout formLayout, address []string, subject string, logo *pdf.Image) {
+	left := pdf.Mm(leftMarginMm)
+
+	// letterhead
+	if l.Sender.Name != "" {
+		page.Text(pdf.HelveticaBold, 14, left, y(layout.addressTop-10), l.Sender.Name)
+	}
+	if logo != nil {
+		w, h := pdf.Mm(40), pdf.Mm(40)*float64(logo.Height)/float64(logo.Width)
+		if maxH := pdf.Mm(layout.addressTop - 12); h > maxH {
+			w, h = w*maxH/h, maxH
+		}
+		page.DrawImage(logo, pdf.Mm(pageWidthMm-rightMarginMm)-w, y(8)-h, w, h)
+	}
+
+	// return address and remarks in the remark zone of the address field
+	addrLeft := pdf.Mm(addressLeftMm + 5)
+	ret := l.returnAddress()
+	if ret != "" {
+		size := float64(smallFontSize)
+		maxWidth := pdf.Mm(addressWidthMm - 5)
+		for size > 5 && pdf.Helvetica.Width(ret, size) > maxWidth {
+			size -= 0.5
+		}
+		page.Text(pdf.Helvetica, size, addrLeft, y(layout.addressTop+4), ret)
+		page.Line(addrLeft, y(layout.addressTop+4.8), addrLeft+pdf.Helvetica.Width(ret, size), y(layout.addressTop+4.8), 0.3)
+	}
+	for i, remark := range l.Remarks {
+		page.Text(pdf.Helvetica, 8, addrLeft, y(layout.addressTop+8.5+float64(i)*3.5), remark)
+	}
+
+	// address
+	for i, line := range address {
+		page.Text(pdf.Helvetica, addressFontPt, addrLeft, y(layout.addressTop+remarkZoneMm+4+float64(i)*4.23), line)
+	}
+
+	// information block with the date as the last entry
+	info := append([]InfoLine{}, l.Info...)
+	info = append(info, InfoLine{Label: l.dateLabel(), Value: l.date()})
+	infoLeft := pdf.Mm(infoLeftMm)
+	for i, line := range info {
+		ly := y(layout.infoTop + 4 + float64(i)*lineHeightMm)
+		page.Text(pdf.Helvetica, 8, infoLeft, ly, line.Label)
+		page.Text(pdf.Helvetica, 9, infoLeft+pdf.Mm(30), ly, line.Value)
+	}
+
+	if subject != "" {
+		page.Text(pdf.HelveticaBold, bodyFontSize, left, y(layout.subjectTop), subject)
+	}
+}
+
+func (l *Letter) drawFooter(page *pdf.Page) {
+	var columns [][]string
+	if l.Sender.Name != "" || len(l.Sender.Address) > 0 {
+		columns = append(columns, append([]string{l.Sender.Name}, l.Sender.Address...))
+	}
+	if len(l.Sender.Contact) > 0 {
+		columns = append(columns, l.Sender.Contact)
+	}
+	if b := l.Sender.Bank; b != nil {
+		var col []string
+		for _, v := range []string{b.AccountHolder, b.BankName} {
+			if v != "" {
+				col = append(col, v)
+			}
+		}
+		if b.Iban != "" {
+			col = append(col, "IBAN: "+payments.FormatIban(b.Iban))
+		}
+		if b.Bic != "" {
+			col = append(col, "BIC: "+strings.ToUpper(b.Bic))
+		}
+		columns = append(columns, col)
+	}
+	if len(l.Sender.Legal) > 0 {
+		columns = append(columns, l.Sender.Legal)
+	}
+	if len(columns) == 0 {
+		return
+	}
+
+	left := pdf.Mm(leftMarginMm)
+	width := pdf.Mm(pageWidthMm-leftMarginMm-rightMarginMm) / float64(len(columns))
+	page.SetFillGray(0.35)
+	for c, col := range columns {
+		for i, line := range col {
+			page.Text(pdf.Helvetica, footerFontSize, left+float64(c)*width, y(footerTopMm+3+float64(i)*3.3), line)
+		}
+	}
+	page.SetFillGray(0)
+}
+
+// drawFoldMarks prints the two fold marks and the hole punch mark on the
+// left edge.
+func drawFoldMarks(page *pdf.Page, layout formLayout) {
+	for _, mark := range layout.foldMarks {
+		page.Line(pdf.Mm(3), y(mark), pdf.Mm(8), y(mark), 0.3)
+	}
+	page.Line(pdf.Mm(3), y(pageHeightMm/2), pdf.Mm(10), y(pageHeightMm/2), 0.3)
+}
+
+// addressLines returns the lines of the address window, formatted with
+// content.FormatAddressBlock. Addresses with problems, e.g. an invalid
+// postcode or more than content.MaxAddressBlockLines lines, are rejected.
+func (l *Letter) addressLines() ([]string, error) {
+	if l.To.Address == nil {
+		return nil, errors.New("recipient address is required")
+	}
+	block := content.FormatAddressBlock(l.To.Name, l.To.Address)
+	if !block.Valid() {
+		problems := make([]string, len(block.Problems))
+		for i, p := range block.Problems {
+			problems[i] = p.String()
+		}
+		return nil, fmt.Errorf("recipient address: %s", strings.Join(problems, "; "))
+	}
+	return block.Lines, nil
+}
+
+func (l *Letter) returnAddress() string {
+	if l.Sender.ReturnAddress != "" {
+		return l.Sender.ReturnAddress
+	}
+	parts := []string{}
+	if l.Sender.Name != "" {
+		parts = append(parts, l.Sender.Name)
+	}
+	parts = append(parts, l.Sender.Address...)
+	return strings.Join(parts, " · ")
+}
+
+func (l *Letter) date() string {
+	d := l.Date
+	if d.IsZero() {
+		d = time.Now()
+	}
+	layout := l.DateFormat
+	if layout == "" {
+		layout = "02.01.2006"
+	}
+	return d.Format(layout)
+}
+
+func (l *Letter) dateLabel() string {
+	if l.DateLabel != "" {
+		return l.DateLabel
+	}
+	return "Datum"
+}
+
+func execute(name, text string, data any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s template: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// y converts a distance from the top of the page in millimetres into a PDF
+// y coordinate.
+func y(mmFromTop float64) float64 {
+	return pdf.A4Height - pdf.Mm(mmFromTop)
+}
@@ -0,0 +1,121 @@
+package letter_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brifle-de/brifle-sdk/sdk"
+	"github.com/brifle-de/brifle-sdk/sdk/endpoints/content"
+	"github.com/brifle-de/brifle-sdk/sdk/letter"
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func testLetter() letter.Letter {
+	return letter.Letter{
+		Sender: letter.Sender{
+			Name:    "Muster GmbH",
+			Address: []string{"Musterstraße 1", "10115 Berlin"},
+			Contact: []string{"Tel. 030 123456", "info@example.com"},
+			Bank:    &letter.BankDetails{BankName: "Musterbank", Iban: "DE89370400440532013000", Bic: "COBADEFFXXX"},
+		},
+		To: letter.Recipient{
+			Name: []string{"Max Mustermann"},
+			Address: &content.Recipient{
+				AddressLine1: sdk.String("Hauptstraße 5"),
+				PostalCode:   sdk.String("12345"),
+				City:         sdk.String("Berlin"),
+				Country:      sdk.String("DE"),
+			},
+		},
+		Info:       []letter.InfoLine{{Label: "Kundennummer", Value: "4711"}},
+		Date:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Subject:    "Rechnung {{.Number}}",
+		Salutation: "Sehr geehrter Herr {{.LastName}},",
+		Body:       "anbei erhalten Sie Ihre Rechnung.\n\nBitte überweisen Sie den Betrag.",
+		Closing:    "Mit freundlichen Grüßen",
+		Signature:  "Erika Muster",
+	}
+}
+
+type testData struct {
+	Number   string
+	LastName string
+}
+
+func TestRender(t *testing.T) {
+	l := testLetter()
+	pdf, err := l.Render(testData{Number: "R-1", LastName: "Mustermann"})
+	if err != nil {
+		t.Errorf("Render failed: %v", err)
+		return
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+	if n := bytes.Count(pdf, []byte("/Type /Page /Parent")); n != 1 {
+		t.Errorf("Expected 1 page, got %d", n)
+	}
+	if !bytes.Contains(pdf, []byte("/CreationDate (D:20250301000000Z)")) {
+		t.Error("Expected the date of the letter as creation date")
+	}
+	again, err := l.Render(testData{Number: "R-1", LastName: "Mustermann"})
+	if err != nil || !bytes.Equal(again, pdf) {
+		t.Error("Expected the same PDF for the same letter")
+	}
+}
+
+func TestRenderMultiplePages(t *testing.T) {
+	l := testLetter()
+	l.Body = strings.Repeat("Dies ist ein langer Absatz mit sehr viel Text, der umbrochen werden muss. ", 20)
+	l.Body = strings.Repeat(l.Body+"\n\n", 6)
+	pdf, err := l.Render(testData{Number: "R-2", LastName: "Mustermann"})
+	if err != nil {
+		t.Errorf("Render failed: %v", err)
+		return
+	}
+	if n := bytes.Count(pdf, []byte("/Type /Page /Parent")); n < 2 {
+		t.Errorf("Expected more than one page, got %d", n)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	l := testLetter()
+	if _, err := l.Render(map[string]string{"Number": "R-3"}); err == nil {
+		t.Error("Expected an error for a missing template key")
+	}
+
+	l = testLetter()
+	l.To.Name = []string{"1", "2", "3", "4", "5"}
+	if _, err := l.Render(testData{}); err == nil {
+		t.Errorf("Expected an error for an address of %d lines, the window fits %d", 7, content.MaxAddressBlockLines)
+	}
+
+	l = testLetter()
+	l.To.Address.PostalCode = sdk.String("1234")
+	if _, err := l.Render(testData{}); err == nil || !strings.Contains(err.Error(), content.AddressFieldPostalCode) {
+		t.Errorf("Expected an error for an invalid postcode, got %v", err)
+	}
+
+	l = testLetter()
+	l.To.Address = nil
+	if _, err := l.Render(testData{}); err == nil {
+		t.Error("Expected an error for a missing address")
+	}
+
+	l = testLetter()
+	l.Sender.Bank.Iban = "DE89370400440532013001"
+	if _, err := l.Render(testData{}); !errors.Is(err, payments.ErrInvalidIban) {
+		t.Errorf("Expected an invalid IBAN error, got %v", err)
+	}
+}
+
+func TestPaperMailFallback(t *testing.T) {
+	l := testLetter()
+	fallback := l.PaperMailFallback()
+	if !fallback.EnabledPhysicalDelivery || fallback.PaperMail.Recipient != l.To.Address {
+		t.Error("Expected the fallback to use the letter's recipient")
+	}
+}
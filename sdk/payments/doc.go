@@ -0,0 +1,20 @@
+// Package payments provides types and validation for the payment information
+// of invoices.
+//
+// [Money] holds an amount in the minor unit of its ISO 4217 currency, e.g.
+// cents, as an int64 so that large amounts keep their precision:
+//
+//	amount, err := payments.ParseMoney("1.234,56", "EUR")
+//	fmt.Println(amount.Amount) // 123456
+//	fmt.Println(amount)        // 1234.56 EUR
+//
+// [ValidateIban], [ValidateBic] and [ValidateReference] check bank details and
+// SEPA payment references locally, before an invoice is sent:
+//
+//	if err := payments.ValidateIban("DE89 3704 0044 0532 0130 00"); err != nil {
+//		return err
+//	}
+//	ref, err := payments.NewCreditorReference("2025017") // RF creditor reference
+//
+// See docs/payments.md for more examples.
+package payments
@@ -0,0 +1,149 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Errors returned by the validation functions. They are wrapped with the
+// reason, so use errors.Is to check them.
+var (
+	ErrInvalidIban      = errors.New("invalid iban")
+	ErrInvalidBic       = errors.New("invalid bic")
+	ErrInvalidReference = errors.New("invalid payment reference")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+)
+
+// ibanLengths are the lengths of the IBANs of the countries in the SWIFT IBAN
+// registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27,
+	"MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24, "SC": 31,
+	"SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20, "YE": 30,
+}
+
+// sepaCurrencies are the national currencies of the countries of the SEPA
+// scheme. Credit transfers within SEPA are in EUR; the national currency is
+// accepted as well for domestic transfers.
+var sepaCurrencies = map[string]string{
+	"AD": "EUR", "AL": "ALL", "AT": "EUR", "BE": "EUR", "BG": "EUR", "CH": "CHF",
+	"CY": "EUR", "CZ": "CZK", "DE": "EUR", "DK": "DKK", "EE": "EUR", "ES": "EUR",
+	"FI": "EUR", "FR": "EUR", "GB": "GBP", "GI": "GBP", "GR": "EUR", "HR": "EUR",
+	"HU": "HUF", "IE": "EUR", "IS": "ISK", "IT": "EUR", "LI": "CHF", "LT": "EUR",
+	"LU": "EUR", "LV": "EUR", "MC": "EUR", "MD": "MDL", "ME": "EUR", "MK": "MKD",
+	"MT": "EUR", "NL": "EUR", "NO": "NOK", "PL": "PLN", "PT": "EUR", "RO": "RON",
+	"SE": "SEK", "SI": "EUR", "SK": "EUR", "SM": "EUR", "VA": "EUR",
+}
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// NormalizeIban removes spaces and converts an IBAN to upper case.
+func NormalizeIban(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// FormatIban formats an IBAN in groups of four characters, as printed on
+// paper, e.g. "DE89 3704 0044 0532 0130 00".
+func FormatIban(iban string) string {
+	compact := NormalizeIban(iban)
+	var parts []string
+	for len(compact) > 4 {
+		parts = append(parts, compact[:4])
+		compact = compact[4:]
+	}
+	return strings.Join(append(parts, compact), " ")
+}
+
+// IbanCountry returns the ISO 3166-1 country code of an IBAN.
+func IbanCountry(iban string) string {
+	compact := NormalizeIban(iban)
+	if len(compact) < 2 {
+		return ""
+	}
+	return compact[:2]
+}
+
+// IsSepaCountry reports whether a country takes part in the SEPA scheme.
+func IsSepaCountry(country string) bool {
+	_, ok := sepaCurrencies[strings.ToUpper(country)]
+	return ok
+}
+
+// ValidateIban checks the format, the length for the country and the mod-97
+// checksum of an IBAN. Spaces are ignored.
+func ValidateIban(iban string) error {
+	compact := NormalizeIban(iban)
+	if !ibanPattern.MatchString(compact) {
+		return fmt.Errorf("%w: %q is not an IBAN", ErrInvalidIban, iban)
+	}
+	country := compact[:2]
+	length, ok := ibanLengths[country]
+	if !ok {
+		return fmt.Errorf("%w: unknown country %s", ErrInvalidIban, country)
+	}
+	if len(compact) != length {
+		return fmt.Errorf("%w: %s IBANs have %d characters, got %d", ErrInvalidIban, country, length, len(compact))
+	}
+	if mod97(compact[4:]+compact[:4]) != 1 {
+		return fmt.Errorf("%w: checksum of %s does not match", ErrInvalidIban, FormatIban(compact))
+	}
+	return nil
+}
+
+// ValidateBic checks the format of a BIC (ISO 9362) with 8 or 11
+// characters.
+func ValidateBic(bic string) error {
+	compact := strings.ToUpper(strings.TrimSpace(bic))
+	if !bicPattern.MatchString(compact) {
+		return fmt.Errorf("%w: %q is not a BIC", ErrInvalidBic, bic)
+	}
+	return nil
+}
+
+// CheckCurrency checks that a transfer in currency can be made to an IBAN:
+// SEPA accounts accept EUR and their national currency. Accounts outside of
+// SEPA are not checked.
+func CheckCurrency(iban, currency string) error {
+	if _, ok := CurrencyExponent(currency); !ok {
+		return fmt.Errorf("%w: unknown currency %q", ErrInvalidCurrency, currency)
+	}
+	country := IbanCountry(iban)
+	national, ok := sepaCurrencies[country]
+	if !ok || currency == "EUR" || currency == national {
+		return nil
+	}
+	if national == "EUR" {
+		return fmt.Errorf("%w: accounts in %s only accept EUR, got %s", ErrInvalidCurrency, country, currency)
+	}
+	return fmt.Errorf("%w: accounts in %s accept EUR or %s, got %s", ErrInvalidCurrency, country, national, currency)
+}
+
+// mod97 computes the remainder of the number formed by s, with letters
+// replaced by 10 to 35, divided by 97 (ISO 7064).
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return -1
+		}
+	}
+	return remainder
+}
@@ -0,0 +1,71 @@
+package payments_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func TestValidateIban(t *testing.T) {
+	for _, iban := range []string{"DE89370400440532013000", "de89 3704 0044 0532 0130 00", "GB82WEST12345698765432", "CH9300762011623852957", "NO9386011117947", "BE68539007547034"} {
+		if err := payments.ValidateIban(iban); err != nil {
+			t.Errorf("%s: %v", iban, err)
+		}
+	}
+	for _, iban := range []string{"", "DE89370400440532013001", "DE8937040044053201300", "XX89370400440532013000", "DE89-3704-0044-0532-0130-00"} {
+		if err := payments.ValidateIban(iban); !errors.Is(err, payments.ErrInvalidIban) {
+			t.Errorf("%q: expected ErrInvalidIban, got %v", iban, err)
+		}
+	}
+}
+
+func TestFormatIban(t *testing.T) {
+	if got := payments.FormatIban("de89370400440532013000"); got != "DE89 3704 0044 0532 0130 00" {
+		t.Errorf("Unexpected format %q", got)
+	}
+	if got := payments.IbanCountry(" ch93 0076 2011 6238 5295 7"); got != "CH" {
+		t.Errorf("Expected CH, got %q", got)
+	}
+}
+
+func TestValidateBic(t *testing.T) {
+	for _, bic := range []string{"COBADEFFXXX", "COBADEFF", "deutdeff500"} {
+		if err := payments.ValidateBic(bic); err != nil {
+			t.Errorf("%s: %v", bic, err)
+		}
+	}
+	for _, bic := range []string{"", "COBADEF", "COBADEFFXX", "1OBADEFF"} {
+		if err := payments.ValidateBic(bic); !errors.Is(err, payments.ErrInvalidBic) {
+			t.Errorf("%q: expected ErrInvalidBic, got %v", bic, err)
+		}
+	}
+}
+
+func TestCheckCurrency(t *testing.T) {
+	tests := []struct {
+		iban, currency string
+		ok             bool
+	}{
+		{"DE89370400440532013000", "EUR", true},
+		{"DE89370400440532013000", "CHF", false},
+		{"CH9300762011623852957", "CHF", true},
+		{"CH9300762011623852957", "EUR", true},
+		{"CH9300762011623852957", "GBP", false},
+		{"DE89370400440532013000", "XYZ", false},
+		// outside of SEPA
+		{"BR1800360305000010009795493C1", "USD", true},
+	}
+	for _, tt := range tests {
+		err := payments.CheckCurrency(tt.iban, tt.currency)
+		if tt.ok && err != nil {
+			t.Errorf("%s %s: %v", tt.iban, tt.currency, err)
+		}
+		if !tt.ok && !errors.Is(err, payments.ErrInvalidCurrency) {
+			t.Errorf("%s %s: expected ErrInvalidCurrency, got %v", tt.iban, tt.currency, err)
+		}
+	}
+	if !payments.IsSepaCountry("no") || payments.IsSepaCountry("US") {
+		t.Error("Unexpected SEPA membership")
+	}
+}
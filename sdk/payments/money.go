@@ -0,0 +1,233 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor unit of its currency, e.g. cents for EUR.
+// The zero value is invalid as it has no currency.
+type Money struct {
+	// Amount in minor units.
+	Amount int64 `json:"amount"`
+	// Currency is an ISO 4217 code, e.g. "EUR".
+	Currency string `json:"currency"`
+}
+
+// currencyExponents are the number of minor unit digits of the currencies
+// of ISO 4217 that differ from two.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// currencies are the active ISO 4217 currency codes with two minor unit
+// digits.
+var currencies = strings.Fields(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV
+	BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK
+	DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL
+	HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL
+	MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO
+	NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK
+	SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TOP TRY TTD TWD TZS
+	UAH USD USN UYU UZS VED VES WST XCD XCG YER ZAR ZMW ZWG
+`)
+
+func init() {
+	for _, c := range currencies {
+		currencyExponents[c] = 2
+	}
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217
+// currency, e.g. 2 for EUR and 0 for JPY. ok is false for unknown codes.
+func CurrencyExponent(currency string) (exponent int, ok bool) {
+	exponent, ok = currencyExponents[currency]
+	return exponent, ok
+}
+
+// NewMoney returns an amount in minor units. It fails for unknown
+// currencies.
+func NewMoney(minorUnits int64, currency string) (Money, error) {
+	m := Money{Amount: minorUnits, Currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+	return m, nil
+}
+
+// Cents returns an amount in euro cents.
+func Cents(cents int64) Money {
+	return Money{Amount: cents, Currency: "EUR"}
+}
+
+// ParseMoney parses an amount in major units, e.g. "1234.56" or "1.234,56"
+// EUR. Both "." and "," are accepted as the decimal separator; if both
+// occur, the last one is the decimal separator and the other one groups
+// thousands. A single separator followed by three digits groups thousands
+// unless the currency has three decimals. Amounts with more decimals than
+// the currency has minor unit digits are rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("unknown currency %q", currency)
+	}
+	s := strings.ReplaceAll(strings.TrimSpace(amount), " ", "")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	intPart, fracPart := s, ""
+	dots, commas := strings.Count(s, "."), strings.Count(s, ",")
+	switch {
+	case dots > 0 && commas > 0:
+		// the last separator is the decimal separator
+		i := strings.LastIndexAny(s, ".,")
+		intPart, fracPart = strings.NewReplacer(".", "", ",", "").Replace(s[:i]), s[i+1:]
+	case dots > 1 || commas > 1:
+		intPart = strings.NewReplacer(".", "", ",", "").Replace(s)
+	case dots == 1 || commas == 1:
+		i := strings.IndexAny(s, ".,")
+		intPart, fracPart = s[:i], s[i+1:]
+		// "1.234" groups thousands, as the currency has no third decimal
+		if len(fracPart) == 3 && exponent != 3 && len(intPart) >= 1 && len(intPart) <= 3 && intPart[0] != '0' {
+			intPart, fracPart = intPart+fracPart, ""
+		}
+	}
+	return fromParts(amount, intPart, fracPart, negative, currency, exponent)
+}
+
+// ParseDecimal parses an amount in major units with "." as the decimal
+// separator and no grouping, as used in XML documents such as e-invoices,
+// e.g. "1234.50". Unlike ParseMoney, "1.234" is one and a bit, not a
+// thousand. Trailing zeros beyond the minor unit digits are accepted.
+func ParseDecimal(amount, currency string) (Money, error) {
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("unknown currency %q", currency)
+	}
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart != "" {
+		intPart = "0"
+	}
+	for len(fracPart) > exponent && strings.HasSuffix(fracPart, "0") {
+		fracPart = fracPart[:len(fracPart)-1]
+	}
+	return fromParts(amount, intPart, fracPart, negative, currency, exponent)
+}
+
+// fromParts converts the integer and fraction digits of amount to minor
+// units.
+func fromParts(amount, intPart, fracPart string, negative bool, currency string, exponent int) (Money, error) {
+	if intPart == "" || !digits(intPart) || (fracPart != "" && !digits(fracPart)) {
+		return Money{}, fmt.Errorf("amount %q is not a number", amount)
+	}
+	if len(fracPart) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimals for %s", amount, exponent, currency)
+	}
+	minor, err := strconv.ParseInt(intPart+fracPart+strings.Repeat("0", exponent-len(fracPart)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q is out of range", amount)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// ParseMinorUnits converts an amount in minor units as sent by the API,
+// which encodes it as a JSON number, e.g. "1250" or "1250.0". Fractions of
+// minor units are rejected.
+func ParseMinorUnits(s string) (int64, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a number", s)
+	}
+	if f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+		return 0, fmt.Errorf("amount %q is not a whole number of minor units", s)
+	}
+	return int64(f), nil
+}
+
+// Validate checks that the currency is known.
+func (m Money) Validate() error {
+	if m.Currency == "" {
+		return errors.New("currency is required")
+	}
+	if _, ok := CurrencyExponent(m.Currency); !ok {
+		return fmt.Errorf("unknown currency %q", m.Currency)
+	}
+	return nil
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal formats the amount in major units with "." as the decimal
+// separator and no grouping, e.g. "1234.56".
+func (m Money) Decimal() string {
+	exponent, ok := CurrencyExponent(m.Currency)
+	if !ok {
+		exponent = 2
+	}
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(amount), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// String formats the amount with its currency, e.g. "1234.56 EUR".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add returns the sum of two amounts of the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("can not add %s to %s", o.Currency, m.Currency)
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, errors.New("amount overflows")
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+func absUint(i int64) uint64 {
+	if i < 0 {
+		return uint64(-(i + 1)) + 1
+	}
+	return uint64(i)
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
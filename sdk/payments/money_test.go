@@ -0,0 +1,105 @@
+package payments_test
+
+import (
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		minor            int64
+	}{
+		{"12.50", "EUR", 1250},
+		{"12,5", "EUR", 1250},
+		{"1.234,56", "EUR", 123456},
+		{"1,234.56", "USD", 123456},
+		{"1.234", "EUR", 123400},
+		{"1.234.567", "EUR", 123456700},
+		{"0.125", "KWD", 125},
+		{"1500", "JPY", 1500},
+		{"-3", "EUR", -300},
+		{"92233720368547758.07", "EUR", 9223372036854775807},
+	}
+	for _, tt := range tests {
+		m, err := payments.ParseMoney(tt.amount, tt.currency)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.amount, tt.currency, err)
+			continue
+		}
+		if m.Amount != tt.minor || m.Currency != tt.currency {
+			t.Errorf("%s %s: expected %d, got %+v", tt.amount, tt.currency, tt.minor, m)
+		}
+	}
+
+	for _, tt := range [][2]string{{"12.5678", "KWD"}, {"1.5", "JPY"}, {"12.5x", "EUR"}, {"", "EUR"}, {"1", "XYZ"}, {"92233720368547758.08", "EUR"}} {
+		if _, err := payments.ParseMoney(tt[0], tt[1]); err == nil {
+			t.Errorf("%s %s: expected an error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		minor            int64
+	}{
+		{"1234.56", "EUR", 123456},
+		{"1.234", "KWD", 1234},
+		{"1.2300", "EUR", 123},
+		{".5", "EUR", 50},
+		{"-10", "EUR", -1000},
+		{"1500", "JPY", 1500},
+	}
+	for _, tt := range tests {
+		m, err := payments.ParseDecimal(tt.amount, tt.currency)
+		if err != nil || m.Amount != tt.minor {
+			t.Errorf("%s %s: expected %d, got %d: %v", tt.amount, tt.currency, tt.minor, m.Amount, err)
+		}
+	}
+	for _, amount := range []string{"1.234", "1,50", "1.234,56", ""} {
+		if _, err := payments.ParseDecimal(amount, "EUR"); err == nil {
+			t.Errorf("%q: expected an error", amount)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		money payments.Money
+		text  string
+	}{
+		{payments.Cents(123456), "1234.56 EUR"},
+		{payments.Cents(5), "0.05 EUR"},
+		{payments.Cents(-5), "-0.05 EUR"},
+		{payments.Money{Amount: 1500, Currency: "JPY"}, "1500 JPY"},
+		{payments.Money{Amount: 1, Currency: "BHD"}, "0.001 BHD"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.text {
+			t.Errorf("Expected %q, got %q", tt.text, got)
+		}
+	}
+}
+
+func TestMoneyValidation(t *testing.T) {
+	if _, err := payments.NewMoney(100, "EUR"); err != nil {
+		t.Errorf("NewMoney failed: %v", err)
+	}
+	if _, err := payments.NewMoney(100, "EURO"); err == nil {
+		t.Errorf("Expected an error for an unknown currency")
+	}
+	if _, err := payments.Cents(1).Add(payments.Money{Amount: 1, Currency: "USD"}); err == nil {
+		t.Errorf("Expected an error when adding different currencies")
+	}
+	if exp, ok := payments.CurrencyExponent("JPY"); !ok || exp != 0 {
+		t.Errorf("Expected exponent 0 for JPY, got %d", exp)
+	}
+	if _, err := payments.ParseMinorUnits("12.5"); err == nil {
+		t.Errorf("Expected an error for a fraction of a minor unit")
+	}
+	if amount, err := payments.ParseMinorUnits("1250.0"); err != nil || amount != 1250 {
+		t.Errorf("Expected 1250, got %d: %v", amount, err)
+	}
+}
@@ -0,0 +1,66 @@
+package payments
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxReferenceLength is the maximum length of an unstructured SEPA
+	// remittance information.
+	MaxReferenceLength = 140
+	// MaxCreditorReferenceLength is the maximum length of a structured
+	// creditor reference (ISO 11649).
+	MaxCreditorReferenceLength = 25
+)
+
+var (
+	// sepaCharacters is the Latin character set of the EPC guidelines that
+	// all banks in SEPA must support.
+	sepaCharacters    = regexp.MustCompile(`^[A-Za-z0-9/\-?:().,'+ ]*$`)
+	creditorReference = regexp.MustCompile(`^RF[0-9]{2}[A-Z0-9]{1,21}$`)
+)
+
+// ValidateReference checks an unstructured payment reference against the
+// constraints of SEPA credit transfers: at most 140 characters of the EPC
+// character set. Free text such as "RF12INV2024" is valid even though it
+// starts like a creditor reference; use ValidateCreditorReference to check a
+// structured reference.
+func ValidateReference(reference string) error {
+	switch {
+	case strings.TrimSpace(reference) == "":
+		return fmt.Errorf("%w: reference is empty", ErrInvalidReference)
+	case len(reference) > MaxReferenceLength:
+		return fmt.Errorf("%w: reference has %d characters, at most %d are allowed", ErrInvalidReference, len(reference), MaxReferenceLength)
+	case !sepaCharacters.MatchString(reference):
+		return fmt.Errorf("%w: %q contains characters outside of the SEPA character set", ErrInvalidReference, reference)
+	}
+	return nil
+}
+
+// ValidateCreditorReference checks an RF creditor reference (ISO 11649),
+// e.g. "RF18 5390 0754 7034". Spaces are ignored.
+func ValidateCreditorReference(reference string) error {
+	compact := strings.ToUpper(strings.ReplaceAll(reference, " ", ""))
+	if !creditorReference.MatchString(compact) {
+		return fmt.Errorf("%w: %q is not an RF creditor reference", ErrInvalidReference, reference)
+	}
+	if mod97(compact[4:]+compact[:4]) != 1 {
+		return fmt.Errorf("%w: checksum of creditor reference %q does not match", ErrInvalidReference, reference)
+	}
+	return nil
+}
+
+// NewCreditorReference builds an RF creditor reference from a reference of
+// up to 21 letters and digits, e.g. an invoice number:
+//
+//	ref, _ := payments.NewCreditorReference("539007547034") // RF18539007547034
+func NewCreditorReference(reference string) (string, error) {
+	compact := strings.ToUpper(strings.ReplaceAll(reference, " ", ""))
+	if compact == "" || len(compact) > 21 || strings.Trim(compact, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return "", fmt.Errorf("%w: %q must be 1 to 21 letters and digits", ErrInvalidReference, reference)
+	}
+	check := 98 - mod97(compact+"RF00")
+	return fmt.Sprintf("RF%02d%s", check, compact), nil
+}
@@ -0,0 +1,45 @@
+package payments_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brifle-de/brifle-sdk/sdk/payments"
+)
+
+func TestValidateReference(t *testing.T) {
+	for _, ref := range []string{"R-1", "Rechnung 2025/17 (Kd. 4711)", "RF18539007547034", "RF18 5390 0754 7034", "RF12INV2024", strings.Repeat("x", 140)} {
+		if err := payments.ValidateReference(ref); err != nil {
+			t.Errorf("%q: %v", ref, err)
+		}
+	}
+	for _, ref := range []string{"", " ", "Rechnung Nr. 1 für Müller", "R_1", strings.Repeat("x", 141)} {
+		if err := payments.ValidateReference(ref); !errors.Is(err, payments.ErrInvalidReference) {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", ref, err)
+		}
+	}
+}
+
+func TestCreditorReference(t *testing.T) {
+	ref, err := payments.NewCreditorReference("5390 0754 7034")
+	if err != nil || ref != "RF18539007547034" {
+		t.Errorf("Expected RF18539007547034, got %q: %v", ref, err)
+	}
+	ref, err = payments.NewCreditorReference("inv2025x17")
+	if err != nil {
+		t.Errorf("NewCreditorReference failed: %v", err)
+	} else if err := payments.ValidateCreditorReference(ref); err != nil {
+		t.Errorf("%s: %v", ref, err)
+	}
+	for _, base := range []string{"", "R-1", strings.Repeat("1", 22)} {
+		if _, err := payments.NewCreditorReference(base); err == nil {
+			t.Errorf("%q: expected an error", base)
+		}
+	}
+	for _, ref := range []string{"R-1", "RF19539007547034", "RF12INV2024"} {
+		if err := payments.ValidateCreditorReference(ref); !errors.Is(err, payments.ErrInvalidReference) {
+			t.Errorf("%q: expected ErrInvalidReference, got %v", ref, err)
+		}
+	}
+}